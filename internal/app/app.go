@@ -1,23 +1,69 @@
 package app
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"slices"
+	"syscall"
 
 	"github.com/alesr/mcpgen/internal/checks"
+	"github.com/alesr/mcpgen/internal/config"
 	"github.com/alesr/mcpgen/internal/generator"
 	"github.com/alesr/mcpgen/internal/inspector"
+	"github.com/alesr/mcpgen/internal/pkg/outfs"
+	"github.com/alesr/mcpgen/internal/pkg/utils"
 	"github.com/alesr/mcpgen/internal/scaffold"
 	"github.com/alesr/mcpgen/internal/ui"
 	"github.com/charmbracelet/x/term"
 )
 
+// Run runs the mcpgen command line. SIGINT and SIGTERM cancel the run:
+// the checks and servers it started are stopped, and an existing project
+// whose checks did not finish is restored.
 func Run() error {
-	fmt.Printf("+---------------------------------------+\n| [ MCPGEN ] Go MCP Server Cookiecutter |\n+---------------------------------------+\n\n")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	opts, err := parseRunOptions(os.Args[1:], os.Stdout)
-	if err != nil {
-		return err
+	err := run(ctx)
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("interrupted: %w", err)
+	}
+	return err
+}
+
+func run(ctx context.Context) error {
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch args[0] {
+		case "status":
+			return runStatus(args[1:], os.Stdout)
+		case "add":
+			return runAdd(ctx, args[1:], os.Stdout, nil)
+		case "remove":
+			return runRemove(ctx, args[1:], os.Stdout, nil)
+		case "verify":
+			return runVerify(ctx, args[1:], os.Stdout)
+		case "conformance":
+			return runConformance(ctx, args[1:], os.Stdout)
+		}
+	}
+
+	// usage is buffered so the banner, which goes to stderr when an archive
+	// is streamed to stdout, still comes first
+	var usage bytes.Buffer
+	opts, parseErr := parseRunOptions(args, &usage)
+	msgs := opts.messages()
+
+	fmt.Fprintf(msgs, "+---------------------------------------+\n| [ MCPGEN ] Go MCP Server Cookiecutter |\n+---------------------------------------+\n\n")
+	_, _ = usage.WriteTo(os.Stdout)
+
+	if parseErr != nil {
+		return parseErr
 	}
 
 	if opts.ShowHelp {
@@ -46,24 +92,178 @@ func Run() error {
 			return err
 		}
 
-		cfg = &ConfigRun{Config: runCfg, OutDir: runOut}
+		cfg = &ConfigRun{Config: runCfg, OutDir: runOut, OutFormat: outFormatDir}
 		shouldTest = runShouldTest
 	}
 
-	gen := &generator.Generator{Config: cfg.Config, OutDir: cfg.OutDir}
-	if err := gen.Run(); err != nil {
+	if cfg.OutFormat != outFormatDir {
+		return writeArchive(cfg, msgs)
+	}
+
+	gen := &generator.Generator{
+		Config:    cfg.Config,
+		OutDir:    cfg.OutDir,
+		Force:     cfg.Force,
+		Log:       os.Stdout,
+		Templates: templateDir(cfg.Templates),
+	}
+
+	if cfg.DryRun {
+		plan, err := gen.Plan()
+		if err != nil {
+			return err
+		}
+		return plan.Print(os.Stdout)
+	}
+
+	pipeline, err := checkPipeline(cfg.Config, cfg.Checks, shouldTest, cfg.Inspector)
+	if err != nil {
 		return err
 	}
 
-	if err := checks.Run(cfg.OutDir); err != nil {
+	if err := generateChecked(ctx, gen, os.Stdout, pipeline, cfg.Report, cfg.ReportFile); err != nil {
+		return err
+	}
+	scaffold.PrintInspectorHint(os.Stdout, cfg.OutDir, cfg.Config)
+	return nil
+}
+
+// writeArchive generates a new project into a tar.gz or zip archive and
+// writes it to cfg.OutDir, or to stdout for "-". Checks and the inspector
+// need a directory, so they are skipped.
+func writeArchive(cfg *ConfigRun, msgs io.Writer) error {
+	serverName := utils.DefaultServerName(cfg.Config.Server.Name)
+
+	var buf bytes.Buffer
+	archive, err := outfs.NewArchive(&buf, outfs.Format(cfg.OutFormat), serverName)
+	if err != nil {
 		return err
 	}
 
-	if shouldTest {
-		if err := inspector.RunTest(cfg.OutDir, cfg.Config); err != nil {
+	gen := &generator.Generator{
+		Config:    cfg.Config,
+		OutDir:    cfg.OutDir,
+		FS:        archive,
+		Log:       msgs,
+		Templates: templateDir(cfg.Templates),
+	}
+
+	if cfg.DryRun {
+		plan, err := gen.Plan()
+		if err != nil {
 			return err
 		}
+		return plan.Print(msgs)
+	}
+
+	if err := gen.Run(); err != nil {
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("could not encode archive: %w", err)
+	}
+
+	dest := cfg.OutDir
+	if dest == stdoutPath {
+		dest = "stdout"
+		if _, err := buf.WriteTo(os.Stdout); err != nil {
+			return fmt.Errorf("could not write archive: %w", err)
+		}
+	} else if err := os.WriteFile(dest, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("could not write archive: %w", err)
+	}
+
+	fmt.Fprintf(msgs, "Wrote %s to %s. Extract it to run the checks and the inspector.\n", serverName, dest)
+	return nil
+}
+
+// checkFunc verifies a generated project, as checks.Pipeline.Run does.
+type checkFunc func(ctx context.Context, outDir string, out io.Writer) error
+
+// checkPipeline builds the checks configured in cfg, narrowed by opts,
+// with the inspector run with inspectOpts last unless the config places
+// it or inspect is false.
+func checkPipeline(cfg *config.Config, opts checks.Options, inspect bool, inspectOpts inspector.Options) (*checks.Pipeline, error) {
+	if !inspect {
+		opts.Skip = append(slices.Clone(opts.Skip), inspector.StepName)
+	}
+
+	pipeline, err := checks.Build(cfg.Checks, opts, inspector.Step(cfg, inspectOpts))
+	if err != nil {
+		return nil, fmt.Errorf("could not configure checks: %w", err)
+	}
+	return pipeline, nil
+}
+
+// generateChecked runs generate with pipeline as the check, then writes
+// the report of the checks to file when format is set, whether they
+// passed or not.
+func generateChecked(ctx context.Context, gen *generator.Generator, out io.Writer, pipeline *checks.Pipeline, format checks.ReportFormat, file string) error {
+	var report *checks.Report
+	err := generate(ctx, gen, out, func(ctx context.Context, outDir string, out io.Writer) error {
+		var err error
+		report, err = pipeline.RunReport(ctx, outDir, out)
+		return err
+	})
+
+	return reportChecks(report, format, file, out, err)
+}
+
+// reportChecks writes report to file when format is set and returns err,
+// the error of the run report describes, joined with any write error.
+func reportChecks(report *checks.Report, format checks.ReportFormat, file string, out io.Writer, err error) error {
+	if report == nil || format == "" {
+		return err
+	}
+	if wErr := writeReport(report, format, file); wErr != nil {
+		return errors.Join(err, wErr)
+	}
+	fmt.Fprintf(out, "Wrote the %s report of the checks to %s.\n", format, file)
+	return err
+}
+
+func writeReport(report *checks.Report, format checks.ReportFormat, file string) error {
+	var buf bytes.Buffer
+	if err := report.Write(&buf, format); err != nil {
+		return fmt.Errorf("could not encode report: %w", err)
+	}
+	if err := os.WriteFile(file, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("could not write report: %w", err)
 	}
-	scaffold.PrintInspectorHint(cfg.OutDir, cfg.Config)
 	return nil
 }
+
+// generate applies the plan of gen and runs check on the result. The
+// previous output is kept until check passes and restored if it fails; a
+// new project is left in place for the failure to be looked into.
+func generate(ctx context.Context, gen *generator.Generator, out io.Writer, check checkFunc) error {
+	plan, err := gen.Plan()
+	if err != nil {
+		return err
+	}
+
+	tx, err := gen.Begin(plan)
+	if err != nil {
+		if errors.Is(err, generator.ErrLegacyProject) {
+			return fmt.Errorf("%w\nmove the code you added to them elsewhere, or rerun with --force to regenerate %s; handlers then live in the handlers.go files mcpgen keeps", err, gen.OutDir)
+		}
+		if errors.Is(err, generator.ErrModifiedFiles) {
+			return fmt.Errorf("%w\nreview them with `mcpgen status %s`, or rerun with --force to overwrite them", err, gen.OutDir)
+		}
+		return err
+	}
+
+	if err := check(ctx, gen.OutDir, out); err != nil {
+		restored := tx.Backup() != ""
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		if restored {
+			fmt.Fprintf(out, "Checks failed; restored the previous contents of %s.\n", gen.OutDir)
+		} else {
+			fmt.Fprintf(out, "Checks failed; kept the new project in %s.\n", gen.OutDir)
+		}
+		return err
+	}
+	return tx.Commit()
+}
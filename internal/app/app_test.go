@@ -0,0 +1,37 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/generator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_FailedChecksKeepNewProject(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{Server: config.ServerConfig{Name: "weather"}, Tools: []config.ToolConfig{{ID: "forecast"}}}
+	require.NoError(t, cfg.Validate())
+
+	dir := filepath.Join(t.TempDir(), "generated")
+	gen := &generator.Generator{Config: cfg, OutDir: dir}
+
+	errCheck := errors.New("go mod tidy failed")
+	var out bytes.Buffer
+	err := generate(context.Background(), gen, &out, func(context.Context, string, io.Writer) error { return errCheck })
+
+	require.ErrorIs(t, err, errCheck)
+	assert.Contains(t, out.String(), "Checks failed; kept the new project in "+dir+".")
+	assert.NotContains(t, out.String(), "restored")
+
+	_, err = os.Stat(filepath.Join(dir, "go.mod"))
+	assert.NoError(t, err)
+}
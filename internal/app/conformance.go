@@ -0,0 +1,36 @@
+package app
+
+import (
+	"context"
+	"io"
+
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/inspector"
+)
+
+const conformanceUsage = `Usage: mcpgen conformance [flags] [dir]
+
+Build the server of a generated project and check how it behaves at the
+JSON-RPC and MCP level, over the transport it is configured with: protocol
+version negotiation, batches, and the errors for unknown tools, prompts and
+resources, invalid tool arguments and missing prompt arguments. Prints a
+pass/fail matrix of the cases. dir defaults to ` + config.DefaultOutputDir + `.
+
+Flags:
+  --timeout duration        Give up after this long (default: none)
+  --ready-timeout duration  How long to wait for an HTTP server to serve /mcp (default: 10s)
+  --report json|junit       Write a report of every case
+  --report-file path        File of the --report (default: mcpgen-report.json or mcpgen-report.xml)
+`
+
+// runConformance runs the conformance cases against an existing project.
+func runConformance(ctx context.Context, args []string, out io.Writer) error {
+	return serverCheck{
+		Name:   "conformance",
+		Usage:  conformanceUsage,
+		Run:    "conformance run",
+		Report: "cases",
+		Step:   inspector.ConformanceStep,
+		Passed: "The server in %s passed every conformance case.",
+	}.run(ctx, args, out)
+}
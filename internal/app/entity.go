@@ -0,0 +1,301 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alesr/mcpgen/internal/checks"
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/generator"
+	"github.com/alesr/mcpgen/internal/inspector"
+)
+
+// entityOptions are the flags shared by add and remove.
+type entityOptions struct {
+	Dir       string
+	Force     bool
+	DryRun    bool
+	Templates string
+	checkFlags
+}
+
+func (o *entityOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.Dir, "dir", config.DefaultOutputDir, "Directory of the generated project")
+	fs.BoolVar(&o.Force, "force", false, "Overwrite generated files even if they were edited by hand")
+	fs.BoolVar(&o.DryRun, "dry-run", false, "Print the planned changes and diffs without writing anything")
+	fs.StringVar(&o.Templates, "templates", "", "Directory of templates overriding the embedded ones")
+	o.checkFlags.register(fs)
+}
+
+// runAdd adds a tool, prompt or resource to the config of a generated
+// project and regenerates it. Every generator-owned file is rendered
+// again; existing handlers are left alone and only the stub of the new
+// entity is appended. A nil check runs the checks the project
+// configures.
+func runAdd(ctx context.Context, args []string, out io.Writer, check checkFunc) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		_, _ = io.WriteString(out, addUsage)
+		return errors.New("add needs a kind: tool, prompt or resource")
+	}
+	kind := args[0]
+
+	fs := flag.NewFlagSet("mcpgen add "+kind, flag.ContinueOnError)
+	fs.SetOutput(out)
+	fs.Usage = func() { _, _ = io.WriteString(out, addUsage) }
+
+	var opts entityOptions
+	opts.register(fs)
+
+	var (
+		title, description string
+		tool               config.ToolConfig
+		prompt             config.PromptConfig
+		resource           config.ResourceConfig
+	)
+	fs.StringVar(&title, "title", "", "Human-readable title")
+	fs.StringVar(&description, "description", "", "Description shown to clients")
+
+	switch kind {
+	case config.KindTool:
+		fs.StringVar(&tool.InputSchema, "input-schema", "", "Input JSON schema, or @file to read it from a file")
+		fs.StringVar(&tool.OutputSchema, "output-schema", "", "Output JSON schema, or @file to read it from a file")
+	case config.KindPrompt:
+		fs.StringVar(&prompt.Role, "role", "", "Role of the prompt message: user|assistant")
+		fs.StringVar(&prompt.Template, "template", "", "Prompt template, or @file to read it from a file")
+		// both flags append to the same list, so arguments keep the
+		// order they were given in
+		fs.Func("arg", "Optional prompt argument (repeatable)", promptArg(&prompt, false))
+		fs.Func("required-arg", "Required prompt argument (repeatable)", promptArg(&prompt, true))
+	case config.KindResource:
+		fs.StringVar(&resource.URI, "uri", "", "Resource URI")
+		fs.StringVar(&resource.URITemplate, "uri-template", "", "Resource URI template, e.g. docs://pages/{id}")
+		fs.StringVar(&resource.MIMEType, "mime-type", "", "MIME type of the resource")
+		fs.StringVar(&resource.Text, "text", "", "Stub text content, or @file to read it from a file")
+	default:
+		_, _ = io.WriteString(out, addUsage)
+		return fmt.Errorf("%w: %q (expected tool, prompt or resource)", config.ErrUnknownKind, kind)
+	}
+
+	id, err := parseEntityArgs(fs, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	cfg, err := loadProjectConfig(opts.Dir)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Has(kind, id) {
+		return fmt.Errorf("%w: %s %q is already in %s", config.ErrDuplicateName, kind, id, opts.Dir)
+	}
+
+	switch kind {
+	case config.KindTool:
+		tool.ID, tool.Title, tool.Description = id, title, description
+		if tool.InputSchema, err = readValue(tool.InputSchema); err != nil {
+			return err
+		}
+		if tool.OutputSchema, err = readValue(tool.OutputSchema); err != nil {
+			return err
+		}
+		cfg.Tools = append(cfg.Tools, tool)
+	case config.KindPrompt:
+		prompt.ID, prompt.Title, prompt.Description = id, title, description
+		if prompt.Template, err = readValue(prompt.Template); err != nil {
+			return err
+		}
+		cfg.Prompts = append(cfg.Prompts, prompt)
+	case config.KindResource:
+		resource.ID, resource.Title, resource.Description = id, title, description
+		if resource.Text, err = readValue(resource.Text); err != nil {
+			return err
+		}
+		cfg.Resources = append(cfg.Resources, resource)
+	}
+
+	if err := regenerate(ctx, cfg, opts, nil, out, check); err != nil {
+		return err
+	}
+	if !opts.DryRun {
+		fmt.Fprintf(out, "Added %s %s to %s.\n", kind, id, opts.Dir)
+	}
+	return nil
+}
+
+// runRemove removes a tool, prompt or resource from the config of a
+// generated project and regenerates it. Every generator-owned file is
+// rendered again; the stubs of the removed entity are deleted from the
+// handler files and other handlers are left alone.
+func runRemove(ctx context.Context, args []string, out io.Writer, check checkFunc) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		_, _ = io.WriteString(out, removeUsage)
+		return errors.New("remove needs a kind: tool, prompt or resource")
+	}
+	kind := args[0]
+
+	fs := flag.NewFlagSet("mcpgen remove "+kind, flag.ContinueOnError)
+	fs.SetOutput(out)
+	fs.Usage = func() { _, _ = io.WriteString(out, removeUsage) }
+
+	var opts entityOptions
+	opts.register(fs)
+
+	id, err := parseEntityArgs(fs, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	cfg, err := loadProjectConfig(opts.Dir)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.Remove(kind, id); err != nil {
+		return err
+	}
+
+	removed := []generator.Entity{{Kind: kind, ID: id}}
+	if err := regenerate(ctx, cfg, opts, removed, out, check); err != nil {
+		return err
+	}
+	if !opts.DryRun {
+		fmt.Fprintf(out, "Removed %s %s from %s.\n", kind, id, opts.Dir)
+	}
+	return nil
+}
+
+const addUsage = `Usage: mcpgen add tool <id> [--input-schema json|@file] [--output-schema json|@file]
+       mcpgen add prompt <id> [--template text|@file] [--role user|assistant] [--arg name] [--required-arg name]
+       mcpgen add resource <id> (--uri uri | --uri-template template) [--mime-type type] [--text text|@file]
+
+Add an entity to the mcpgen.toml of a generated project and regenerate it.
+Every generator-owned file is rendered again from the config, not only those
+of the new entity; handlers are kept and the stub of the entity is appended.
+All kinds accept --title, --description, --dir, --force, --dry-run, --templates,
+and the check flags --checks, --skip-checks, --check-timeout, --keep-going,
+--offline, --vendor, --report and --report-file.
+Pass the same --templates the project was generated with to keep custom templates.
+`
+
+const removeUsage = `Usage: mcpgen remove tool|prompt|resource <id> [--dir dir] [--force] [--dry-run] [--templates dir] [check flags]
+
+Remove an entity from the mcpgen.toml of a generated project and regenerate it.
+Every generator-owned file is rendered again from the config. The stubs of the
+entity are deleted from the handler files; other handlers are left alone.
+The check flags are those of mcpgen add.
+`
+
+// parseEntityArgs parses flags placed before or after the entity ID and
+// returns the ID, or flag.ErrHelp when help was requested. The ID is
+// checked before the project is loaded, so a blank one is reported first.
+func parseEntityArgs(fs *flag.FlagSet, args []string) (string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return "", err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if len(positional) != 1 {
+		fs.Usage()
+		return "", fmt.Errorf("%s takes exactly one id, got %d", fs.Name(), len(positional))
+	}
+
+	id := strings.TrimSpace(positional[0])
+	if id == "" {
+		return "", fmt.Errorf("%s needs an id, got a blank one", fs.Name())
+	}
+	return id, nil
+}
+
+// loadProjectConfig reads the config a generated project was generated from.
+func loadProjectConfig(dir string) (*config.Config, error) {
+	cfg, err := config.Load(filepath.Join(dir, config.ProjectFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no %s in %s: generate the project with mcpgen first", config.ProjectFile, dir)
+		}
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func regenerate(ctx context.Context, cfg *config.Config, opts entityOptions, removed []generator.Entity, out io.Writer, check checkFunc) error {
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("could not validate config: %w", err)
+	}
+
+	gen := &generator.Generator{
+		Config:    cfg,
+		OutDir:    opts.Dir,
+		Force:     opts.Force,
+		Log:       out,
+		Removed:   removed,
+		Templates: templateDir(opts.Templates),
+	}
+
+	if opts.DryRun {
+		plan, err := gen.Plan()
+		if err != nil {
+			return err
+		}
+		return plan.Print(out)
+	}
+	if check != nil {
+		return generate(ctx, gen, out, check)
+	}
+
+	pipeline, err := checkPipeline(cfg, opts.checkFlags.options(), false, inspector.Options{})
+	if err != nil {
+		return err
+	}
+	return generateChecked(ctx, gen, out, pipeline, checks.ReportFormat(opts.Report), opts.reportFile())
+}
+
+// readValue returns the content of the file named after a leading @,
+// or value itself.
+func readValue(value string) (string, error) {
+	path, ok := strings.CutPrefix(value, "@")
+	if !ok {
+		return value, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read %s: %w", path, err)
+	}
+	return string(raw), nil
+}
+
+// promptArg returns the setter of a repeatable flag that appends an
+// argument to prompt.
+func promptArg(prompt *config.PromptConfig, required bool) func(string) error {
+	return func(name string) error {
+		prompt.Arguments = append(prompt.Arguments, config.PromptArgumentConfig{Name: name, Required: required})
+		return nil
+	}
+}
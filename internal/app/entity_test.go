@@ -0,0 +1,201 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/generator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunAddRemove(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	noCheck := func(context.Context, string, io.Writer) error { return nil }
+
+	newProject := func(t *testing.T) string {
+		t.Helper()
+
+		dir := filepath.Join(t.TempDir(), "generated")
+		cfg := &config.Config{
+			Server:  config.ServerConfig{Name: "entities"},
+			Tools:   []config.ToolConfig{{ID: "search"}},
+			Prompts: []config.PromptConfig{{ID: "review"}},
+		}
+		require.NoError(t, cfg.Validate())
+		require.NoError(t, (&generator.Generator{Config: cfg, OutDir: dir}).Run())
+		return dir
+	}
+
+	read := func(t *testing.T, path ...string) string {
+		t.Helper()
+
+		content, err := os.ReadFile(filepath.Join(path...))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	t.Run("add and remove a tool", func(t *testing.T) {
+		t.Parallel()
+
+		dir := newProject(t)
+		handlersPath := filepath.Join(dir, "internal", "mcpapp", "tools", "handlers", "handlers.go")
+
+		// hand-written code in an existing handler
+		edited := strings.Replace(read(t, handlersPath), "_ = in\n", "_ = in // mine\n", 1)
+		require.NoError(t, os.WriteFile(handlersPath, []byte(edited), 0o644))
+
+		schemaPath := filepath.Join(t.TempDir(), "schema.json")
+		require.NoError(t, os.WriteFile(schemaPath, []byte(`{"type":"object","properties":{"url":{"type":"string"}},"required":["url"]}`), 0o644))
+
+		var out bytes.Buffer
+		require.NoError(t, runAdd(ctx, []string{"tool", "fetch", "--dir", dir, "--input-schema", "@" + schemaPath}, &out, noCheck))
+		assert.Contains(t, out.String(), "Added tool fetch to "+dir+".")
+
+		cfg, err := config.Load(filepath.Join(dir, config.ProjectFile))
+		require.NoError(t, err)
+		require.Len(t, cfg.Tools, 2)
+		assert.Contains(t, cfg.Tools[1].InputSchema, `"url"`)
+
+		assert.Contains(t, read(t, dir, "internal", "mcpapp", "tools", "tools.go"), "mcp.AddTool(server, ToolFetch, h.HandleFetch)")
+		assert.Contains(t, read(t, dir, "internal", "mcpapp", "tools", "handlers", "types.go"), "Url string `json:\"url\"`")
+
+		handlers := read(t, handlersPath)
+		assert.Contains(t, handlers, "func (h *Handlers) HandleFetch(")
+		assert.Contains(t, handlers, "_ = in // mine", "existing handlers are left alone")
+
+		out.Reset()
+		require.NoError(t, runRemove(ctx, []string{"tool", "--dir", dir, "search"}, &out, noCheck))
+		assert.Contains(t, out.String(), "Removed Handlers.HandleSearch, ToolNameFallbackSearch from internal/mcpapp/tools/handlers/handlers.go")
+
+		handlers = read(t, handlersPath)
+		assert.NotContains(t, handlers, "HandleSearch")
+		assert.Contains(t, handlers, "func (h *Handlers) HandleFetch(")
+		assert.NotContains(t, read(t, dir, "internal", "mcpapp", "tools", "tools.go"), "ToolSearch")
+		assert.NotContains(t, read(t, dir, config.ProjectFile), `"search"`)
+	})
+
+	t.Run("add a prompt with arguments", func(t *testing.T) {
+		t.Parallel()
+
+		dir := newProject(t)
+
+		args := []string{"prompt", "welcome", "--dir", dir, "--template", "Hi {{.name}}", "--arg", "tone", "--required-arg", "name", "--arg", "style"}
+		require.NoError(t, runAdd(ctx, args, &bytes.Buffer{}, noCheck))
+
+		cfg, err := config.Load(filepath.Join(dir, config.ProjectFile))
+		require.NoError(t, err)
+		require.Len(t, cfg.Prompts, 2)
+		assert.Equal(t, []config.PromptArgumentConfig{{Name: "tone"}, {Name: "name", Required: true}, {Name: "style"}}, cfg.Prompts[1].Arguments)
+
+		assert.Contains(t, read(t, dir, "internal", "mcpapp", "prompts", "handlers.go"), "func HandlePromptWelcome(")
+	})
+
+	t.Run("comments in the config are kept", func(t *testing.T) {
+		t.Parallel()
+
+		dir := newProject(t)
+		configPath := filepath.Join(dir, config.ProjectFile)
+		commented := "# Weather server, deployed by hand.\n" + read(t, configPath)
+		require.NoError(t, os.WriteFile(configPath, []byte(commented), 0o644))
+
+		var out bytes.Buffer
+		require.NoError(t, runAdd(ctx, []string{"tool", "fetch", "--dir", dir}, &out, noCheck))
+		require.NoError(t, runRemove(ctx, []string{"prompt", "review", "--dir", dir}, &out, noCheck))
+		assert.NotContains(t, out.String(), "Rewrote")
+
+		content := read(t, configPath)
+		assert.True(t, strings.HasPrefix(content, "# Weather server, deployed by hand.\n"), content)
+		assert.Contains(t, content, `id = "fetch"`)
+		assert.NotContains(t, content, `id = "review"`)
+	})
+
+	t.Run("failed checks restore the project", func(t *testing.T) {
+		t.Parallel()
+
+		dir := newProject(t)
+		before := read(t, dir, config.ProjectFile)
+
+		errCheck := errors.New("vet failed")
+		var out bytes.Buffer
+		err := runAdd(ctx, []string{"resource", "docs", "--dir", dir, "--uri", "file:///docs"}, &out, func(context.Context, string, io.Writer) error { return errCheck })
+		require.ErrorIs(t, err, errCheck)
+		assert.Contains(t, out.String(), "Checks failed; restored the previous contents of "+dir+".")
+
+		assert.Equal(t, before, read(t, dir, config.ProjectFile))
+		_, err = os.Stat(filepath.Join(dir, "internal", "mcpapp", "resources"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("dry run writes nothing", func(t *testing.T) {
+		t.Parallel()
+
+		dir := newProject(t)
+		before := read(t, dir, config.ProjectFile)
+
+		var out bytes.Buffer
+		require.NoError(t, runRemove(ctx, []string{"prompt", "review", "--dir", dir, "--dry-run"}, &out, noCheck))
+		assert.Contains(t, out.String(), "  delete     internal/mcpapp/prompts/prompts.go\n")
+		assert.Equal(t, before, read(t, dir, config.ProjectFile))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+
+		dir := newProject(t)
+		var out bytes.Buffer
+
+		err := runAdd(ctx, []string{"tool", "search", "--dir", dir}, &out, noCheck)
+		assert.ErrorIs(t, err, config.ErrDuplicateName)
+
+		err = runRemove(ctx, []string{"tool", "missing", "--dir", dir}, &out, noCheck)
+		assert.ErrorIs(t, err, config.ErrEntityNotFound)
+
+		err = runAdd(ctx, []string{"widget", "x", "--dir", dir}, &out, noCheck)
+		assert.ErrorIs(t, err, config.ErrUnknownKind)
+
+		err = runAdd(ctx, []string{"tool", "a", "b", "--dir", dir}, &out, noCheck)
+		assert.ErrorContains(t, err, "takes exactly one id, got 2")
+
+		err = runAdd(ctx, []string{"tool", "x", "--dir", t.TempDir()}, &out, noCheck)
+		assert.ErrorContains(t, err, "no mcpgen.toml in")
+
+		err = runRemove(ctx, nil, &out, noCheck)
+		assert.Error(t, err)
+	})
+
+	t.Run("blank id", func(t *testing.T) {
+		t.Parallel()
+
+		// reported before the missing mcpgen.toml of the directory
+		dir := t.TempDir()
+		var out bytes.Buffer
+
+		err := runAdd(ctx, []string{"tool", "", "--dir", dir}, &out, noCheck)
+		assert.ErrorContains(t, err, "mcpgen add tool needs an id, got a blank one")
+
+		err = runAdd(ctx, []string{"tool", " ", "--dir", dir}, &out, noCheck)
+		assert.ErrorContains(t, err, "mcpgen add tool needs an id, got a blank one")
+
+		err = runRemove(ctx, []string{"prompt", "  ", "--dir", dir}, &out, noCheck)
+		assert.ErrorContains(t, err, "mcpgen remove prompt needs an id, got a blank one")
+	})
+
+	t.Run("help", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		require.NoError(t, runAdd(ctx, []string{"tool", "--help"}, &out, noCheck))
+		require.NoError(t, runRemove(ctx, []string{"tool", "-h"}, &out, noCheck))
+		assert.Contains(t, out.String(), "Usage: mcpgen remove")
+	})
+}
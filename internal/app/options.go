@@ -5,43 +5,102 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"io/fs"
+	"os"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/alesr/mcpgen/internal/checks"
 	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/inspector"
+	"github.com/alesr/mcpgen/internal/pkg/outfs"
 	"github.com/alesr/mcpgen/internal/scaffold"
 )
 
 type runOptions struct {
+	ConfigPath    string
 	Name          string
 	Transport     string
 	WithTools     bool
 	WithPrompts   bool
 	WithResources bool
 	NoInspector   bool
+	InspectorMode string
+	ReadyTimeout  time.Duration
+	Force         bool
+	DryRun        bool
+	Out           string
+	OutFormat     string
+	Templates     string
 	ShowHelp      bool
 	HasCLIInput   bool
+	checkFlags
+
+	// setFlags records flags passed explicitly on the command line,
+	// so they can override values loaded from a config file.
+	setFlags map[string]bool
 }
 
 type ConfigRun struct {
 	Config *config.Config
-	OutDir string
+	// OutDir is the output directory, or the archive path when OutFormat
+	// is an archive format, "-" meaning stdout.
+	OutDir    string
+	OutFormat string
+	// Templates is a custom template directory, see generator.Generator.
+	Templates string
+	// Checks selects and tunes the checks run on the project.
+	Checks checks.Options
+	// Report, when set, is the format of the check report written to
+	// ReportFile.
+	Report     checks.ReportFormat
+	ReportFile string
+	// Inspector is how the inspector talks to the server.
+	Inspector inspector.Options
+	Force     bool
+	DryRun    bool
 }
 
+// Output formats of --out-format. Archive formats match outfs.Format.
+const (
+	outFormatDir = "dir"
+	outFormatTar = string(outfs.FormatTarGz)
+	outFormatZip = string(outfs.FormatZip)
+)
+
+// stdoutPath is the --out value that streams an archive to stdout.
+const stdoutPath = "-"
+
 func parseRunOptions(args []string, out io.Writer) (runOptions, error) {
 	var opts runOptions
 
 	fs := flag.NewFlagSet("mcpgen", flag.ContinueOnError)
 
 	fs.SetOutput(out)
+	fs.StringVar(&opts.ConfigPath, "config", "", "Path to an mcpgen.toml config file")
 	fs.StringVar(&opts.Name, "name", config.DefaultServerName, "Server name")
 	fs.StringVar(&opts.Transport, "transport", config.DefaultTransport, "Transport: stdio|http")
 	fs.BoolVar(&opts.WithTools, "with-tools", true, "Generate tool stub")
 	fs.BoolVar(&opts.WithPrompts, "with-prompts", true, "Generate prompt stub")
 	fs.BoolVar(&opts.WithResources, "with-resources", true, "Generate resource stub")
 	fs.BoolVar(&opts.NoInspector, "no-inspector", false, "Skip inspector checks")
+	fs.StringVar(&opts.InspectorMode, "inspector", string(inspector.ModeNative), "Inspector: native (Go MCP client) or npx (MCP inspector CLI, needs Node.js)")
+	fs.DurationVar(&opts.ReadyTimeout, "ready-timeout", inspector.DefaultReadyTimeout, "How long the inspector waits for an HTTP server to serve /mcp")
+	fs.BoolVar(&opts.Force, "force", false, "Overwrite generated files even if they were edited by hand")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "Print the planned changes and diffs without writing anything")
+	fs.StringVar(&opts.Out, "out", "", "Output directory, or archive file with --out-format tar|zip (- for stdout)")
+	fs.StringVar(&opts.OutFormat, "out-format", outFormatDir, "Output format: dir|tar|zip")
+	fs.StringVar(&opts.Templates, "templates", "", "Directory of templates overriding the embedded ones")
+	opts.checkFlags.register(fs)
 
 	fs.Usage = func() {
 		_, _ = io.WriteString(out, `Usage: mcpgen [flags]
+       mcpgen status [dir]
+       mcpgen add tool|prompt|resource <id> [flags]
+       mcpgen remove tool|prompt|resource <id> [flags]
+       mcpgen verify [flags] [dir]
+       mcpgen conformance [flags] [dir]
 
 Generate a new Go MCP server interactively or from flags.
 
@@ -52,11 +111,45 @@ Flags:
 Examples:
   mcpgen --name weather --transport stdio
   mcpgen --name weather --transport http --no-inspector
+  mcpgen --config mcpgen.toml --transport http
+  mcpgen --config mcpgen.toml --dry-run
+  mcpgen --config mcpgen.toml --out-format tar --out - > weather.tar.gz
+  mcpgen --config mcpgen.toml --templates ./templates
+  mcpgen --config mcpgen.toml --offline --vendor ./vendor
+  mcpgen --config mcpgen.toml --checks vet,race,coverage --check-timeout 5m
+  mcpgen --config mcpgen.toml --report junit --report-file checks.xml
 
 Notes:
   - With no flags on a TTY, mcpgen starts interactive mode.
   - --with-tools, --with-prompts, and --with-resources default to true.
   - Inspector checks run only when stdin is a TTY (or in interactive mode).
+    The native inspector builds the server and calls it with the Go MCP
+    client; --inspector npx uses the MCP inspector CLI instead.
+    Either way the server is built once, and an HTTP server listens on a
+    free port set through $MCP_HTTP_PORT.
+    The inspector lists what the server advertises, which must match the
+    config, then calls every tool, gets every prompt and reads every
+    resource, checking each result.
+    mcpgen verify runs the same checks again after handlers are edited.
+  - mcpgen conformance checks the JSON-RPC errors, batches and protocol
+    version negotiation of a generated server.
+  - Flags passed with --config override the matching values from the file.
+  - Generated files edited by hand stop the run unless --force is set;
+    mcpgen status lists them.
+  - Generated projects keep their config in mcpgen.toml; add and remove
+    edit it and regenerate the project in place.
+  - Archives are always new projects; checks and inspector runs need
+    --out-format dir and are skipped otherwise.
+  - --templates overrides embedded templates by file name; extra templates
+    are declared in templates.toml in the same directory.
+  - Checks run in the order of the [[check]] tables of the config, or tidy,
+    fmt, vet and test, followed by the inspector. --checks and --skip-checks
+    take comma-separated step names.
+  - --report writes what every check step and inspector method did, with
+    durations and output, as JSON or JUnit XML, even when checks fail.
+  - --offline never touches the network: steps that need it, the inspector
+    included, are skipped and the project is type-checked in-process. The
+    SDK must be in --vendor or the module cache.
 `)
 	}
 
@@ -72,9 +165,17 @@ Notes:
 		return opts, fmt.Errorf("unexpected positional arguments: %s", strings.Join(fs.Args(), " "))
 	}
 
+	opts.setFlags = make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { opts.setFlags[f.Name] = true })
+
 	opts.HasCLIInput = len(args) > 0
+	opts.ConfigPath = strings.TrimSpace(opts.ConfigPath)
 	opts.Name = strings.TrimSpace(opts.Name)
 	opts.Transport = strings.ToLower(strings.TrimSpace(opts.Transport))
+	opts.Out = strings.TrimSpace(opts.Out)
+	opts.OutFormat = strings.ToLower(strings.TrimSpace(opts.OutFormat))
+	opts.Templates = strings.TrimSpace(opts.Templates)
+	opts.InspectorMode = strings.ToLower(strings.TrimSpace(opts.InspectorMode))
 
 	if opts.Name == "" {
 		return opts, errors.New("--name cannot be empty")
@@ -87,26 +188,245 @@ Notes:
 		return opts, fmt.Errorf("invalid --transport %q (expected stdio or http)", opts.Transport)
 	}
 
+	if !slices.Contains(inspector.Modes, inspector.Mode(opts.InspectorMode)) {
+		return opts, fmt.Errorf("invalid --inspector %q (expected native or npx)", opts.InspectorMode)
+	}
+
+	if opts.ReadyTimeout <= 0 {
+		return opts, fmt.Errorf("invalid --ready-timeout %s (expected a positive duration)", opts.ReadyTimeout)
+	}
+
+	if err := opts.checkFlags.validate(); err != nil {
+		return opts, err
+	}
+
+	switch opts.OutFormat {
+	case outFormatDir:
+		if opts.Out == stdoutPath {
+			return opts, errors.New("--out - needs --out-format tar or zip")
+		}
+	case outFormatTar, outFormatZip:
+		// valid
+	default:
+		return opts, fmt.Errorf("invalid --out-format %q (expected dir, tar or zip)", opts.OutFormat)
+	}
+
 	return opts, nil
 }
 
+// messages returns where progress and summaries are printed: stderr
+// when the archive itself is streamed to stdout.
+func (o runOptions) messages() io.Writer {
+	if o.Out == stdoutPath {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// format returns the output format, dir unless --out-format said otherwise.
+func (o runOptions) format() string {
+	if o.OutFormat == "" {
+		return outFormatDir
+	}
+	return o.OutFormat
+}
+
+// outPath resolves --out for the chosen format.
+func (o runOptions) outPath() string {
+	switch {
+	case o.Out != "":
+		return o.Out
+	case o.OutFormat == outFormatTar:
+		return config.DefaultOutputDir + ".tar.gz"
+	case o.OutFormat == outFormatZip:
+		return config.DefaultOutputDir + ".zip"
+	default:
+		return config.DefaultOutputDir
+	}
+}
+
 func runWithOptions(opts runOptions, canRunInspector bool) (*ConfigRun, bool, error) {
-	cfg, outDir := scaffold.DefaultConfig(
-		config.DefaultOutputDir,
-		opts.Transport,
-		config.DefaultHTTPPort,
-		opts.WithTools,
-		opts.WithResources,
-		opts.WithPrompts,
+	var (
+		cfg    *config.Config
+		outDir = opts.outPath()
 	)
 
-	cfg.Server.Name = opts.Name
+	if opts.ConfigPath != "" {
+		fileCfg, err := config.Load(opts.ConfigPath)
+		if err != nil {
+			return nil, false, fmt.Errorf("could not load config file: %w", err)
+		}
+		cfg = fileCfg
+		applyFlagOverrides(cfg, opts)
+	} else {
+		cfg, outDir = scaffold.DefaultConfig(
+			outDir,
+			opts.Transport,
+			config.DefaultHTTPPort,
+			opts.WithTools,
+			opts.WithResources,
+			opts.WithPrompts,
+		)
+		cfg.Server.Name = opts.Name
+	}
 
 	if err := cfg.Validate(); err != nil {
 		return nil, false, fmt.Errorf("could not validate config: %w", err)
 	}
 
-	scaffold.PrintSummary(cfg, outDir)
-	shouldTest := canRunInspector && !opts.NoInspector
-	return &ConfigRun{Config: cfg, OutDir: outDir}, shouldTest, nil
+	scaffold.PrintSummary(opts.messages(), cfg, outDir)
+	shouldTest := canRunInspector && !opts.NoInspector && !opts.Offline && opts.format() == outFormatDir
+	return &ConfigRun{
+		Config:     cfg,
+		OutDir:     outDir,
+		OutFormat:  opts.format(),
+		Templates:  opts.Templates,
+		Checks:     opts.checkFlags.options(),
+		Report:     checks.ReportFormat(opts.Report),
+		ReportFile: opts.reportFile(),
+		Inspector: inspector.Options{
+			Mode:         inspector.Mode(opts.InspectorMode),
+			ReadyTimeout: opts.ReadyTimeout,
+		},
+		Force:  opts.Force,
+		DryRun: opts.DryRun,
+	}, shouldTest, nil
+}
+
+// applyFlagOverrides replaces config file values with the flags
+// that were passed explicitly. Feature flags set to true only add
+// the default stub when the file does not declare that feature.
+func applyFlagOverrides(cfg *config.Config, opts runOptions) {
+	if opts.setFlags["name"] {
+		cfg.Server.Name = opts.Name
+	}
+
+	if opts.setFlags["transport"] {
+		cfg.Transport.Type = opts.Transport
+	}
+
+	defaults, _ := scaffold.DefaultConfig(
+		config.DefaultOutputDir,
+		config.DefaultTransport,
+		config.DefaultHTTPPort,
+		true, true, true,
+	)
+
+	if opts.setFlags["with-tools"] {
+		switch {
+		case !opts.WithTools:
+			cfg.Tools = nil
+		case len(cfg.Tools) == 0:
+			cfg.Tools = defaults.Tools
+		}
+	}
+
+	if opts.setFlags["with-resources"] {
+		switch {
+		case !opts.WithResources:
+			cfg.Resources = nil
+		case len(cfg.Resources) == 0:
+			cfg.Resources = defaults.Resources
+		}
+	}
+
+	if opts.setFlags["with-prompts"] {
+		switch {
+		case !opts.WithPrompts:
+			cfg.Prompts = nil
+		case len(cfg.Prompts) == 0:
+			cfg.Prompts = defaults.Prompts
+		}
+	}
+}
+
+// checkFlags are the check pipeline flags of every command that generates.
+type checkFlags struct {
+	Only       commaList
+	Skip       commaList
+	Timeout    time.Duration
+	KeepGoing  bool
+	Offline    bool
+	Vendor     string
+	Report     string
+	ReportFile string
+}
+
+func (f *checkFlags) register(fs *flag.FlagSet) {
+	fs.Var(&f.Only, "checks", "Run only these check steps, e.g. vet,race,coverage")
+	fs.Var(&f.Skip, "skip-checks", "Skip these check steps, e.g. tidy,inspector")
+	fs.DurationVar(&f.Timeout, "check-timeout", 0, "Timeout of check steps without their own (default: none)")
+	fs.BoolVar(&f.KeepGoing, "keep-going", false, "Run the remaining check steps after one fails")
+	fs.BoolVar(&f.Offline, "offline", false, "Skip checks that need the network and type-check in-process instead")
+	fs.StringVar(&f.Vendor, "vendor", "", "Vendor directory with the SDK for --offline (default: module cache)")
+	fs.StringVar(&f.Report, "report", "", "Write a report of the checks: json|junit")
+	fs.StringVar(&f.ReportFile, "report-file", "", "File of the --report (default: mcpgen-report.json or mcpgen-report.xml)")
+}
+
+func (f *checkFlags) validate() error {
+	f.Vendor = strings.TrimSpace(f.Vendor)
+	if f.Vendor != "" && !f.Offline {
+		return errors.New("--vendor needs --offline")
+	}
+	if f.Timeout < 0 {
+		return fmt.Errorf("invalid --check-timeout %s (expected a positive duration)", f.Timeout)
+	}
+
+	f.Report = strings.ToLower(strings.TrimSpace(f.Report))
+	f.ReportFile = strings.TrimSpace(f.ReportFile)
+	if f.Report != "" && !slices.Contains(checks.ReportFormats, checks.ReportFormat(f.Report)) {
+		return fmt.Errorf("invalid --report %q (expected json or junit)", f.Report)
+	}
+	if f.ReportFile != "" && f.Report == "" {
+		return errors.New("--report-file needs --report")
+	}
+	return nil
+}
+
+// reportFile returns where the report goes.
+func (f checkFlags) reportFile() string {
+	switch {
+	case f.ReportFile != "" || f.Report == "":
+		return f.ReportFile
+	case checks.ReportFormat(f.Report) == checks.ReportJUnit:
+		return "mcpgen-report.xml"
+	default:
+		return "mcpgen-report.json"
+	}
+}
+
+func (f checkFlags) options() checks.Options {
+	return checks.Options{
+		Only:      f.Only,
+		Skip:      f.Skip,
+		Timeout:   f.Timeout,
+		KeepGoing: f.KeepGoing,
+		Offline:   f.Offline,
+		Vendor:    f.Vendor,
+	}
+}
+
+// commaList collects the comma-separated values of a repeatable flag.
+type commaList []string
+
+func (l *commaList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *commaList) Set(value string) error {
+	for v := range strings.SplitSeq(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+// templateDir returns the custom template directory at dir, or nil when
+// dir is empty.
+func templateDir(dir string) fs.FS {
+	if dir == "" {
+		return nil
+	}
+	return os.DirFS(dir)
 }
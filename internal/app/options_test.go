@@ -2,9 +2,13 @@ package app
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/inspector"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -28,6 +32,12 @@ func TestParseRunOptions(t *testing.T) {
 		assert.True(t, opts.WithPrompts)
 		assert.True(t, opts.WithResources)
 		assert.False(t, opts.NoInspector)
+		assert.False(t, opts.Force)
+		assert.False(t, opts.DryRun)
+		assert.Equal(t, "dir", opts.OutFormat)
+		assert.Equal(t, "native", opts.InspectorMode)
+		assert.Equal(t, inspector.DefaultReadyTimeout, opts.ReadyTimeout)
+		assert.Equal(t, config.DefaultOutputDir, opts.outPath())
 	})
 
 	t.Run("custom flags", func(t *testing.T) {
@@ -42,6 +52,8 @@ func TestParseRunOptions(t *testing.T) {
 			"--with-prompts=false",
 			"--with-resources=true",
 			"--no-inspector",
+			"--force",
+			"--dry-run",
 		}, out)
 		require.NoError(t, err)
 
@@ -52,6 +64,30 @@ func TestParseRunOptions(t *testing.T) {
 		assert.False(t, opts.WithPrompts)
 		assert.True(t, opts.WithResources)
 		assert.True(t, opts.NoInspector)
+		assert.True(t, opts.Force)
+		assert.True(t, opts.DryRun)
+	})
+
+	t.Run("inspector mode", func(t *testing.T) {
+		t.Parallel()
+
+		out := bytes.NewBuffer(nil)
+
+		opts, err := parseRunOptions([]string{"--inspector", "NPX"}, out)
+		require.NoError(t, err)
+		assert.Equal(t, "npx", opts.InspectorMode)
+
+		_, err = parseRunOptions([]string{"--inspector", "telnet"}, out)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid --inspector")
+
+		opts, err = parseRunOptions([]string{"--ready-timeout", "30s"}, out)
+		require.NoError(t, err)
+		assert.Equal(t, 30*time.Second, opts.ReadyTimeout)
+
+		_, err = parseRunOptions([]string{"--ready-timeout", "0s"}, out)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid --ready-timeout")
 	})
 
 	t.Run("invalid transport", func(t *testing.T) {
@@ -64,6 +100,93 @@ func TestParseRunOptions(t *testing.T) {
 		assert.Contains(t, err.Error(), "invalid --transport")
 	})
 
+	t.Run("archive output", func(t *testing.T) {
+		t.Parallel()
+
+		out := bytes.NewBuffer(nil)
+
+		opts, err := parseRunOptions([]string{"--out-format", "TAR"}, out)
+		require.NoError(t, err)
+		assert.Equal(t, "tar", opts.OutFormat)
+		assert.Equal(t, config.DefaultOutputDir+".tar.gz", opts.outPath())
+		assert.Equal(t, os.Stdout, opts.messages())
+
+		opts, err = parseRunOptions([]string{"--out-format", "zip", "--out", "-"}, out)
+		require.NoError(t, err)
+		assert.Equal(t, "-", opts.outPath())
+		assert.Equal(t, os.Stderr, opts.messages())
+	})
+
+	t.Run("invalid output", func(t *testing.T) {
+		t.Parallel()
+
+		out := bytes.NewBuffer(nil)
+
+		_, err := parseRunOptions([]string{"--out-format", "rar"}, out)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid --out-format")
+
+		_, err = parseRunOptions([]string{"--out", "-"}, out)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "needs --out-format tar or zip")
+	})
+
+	t.Run("vendor needs offline", func(t *testing.T) {
+		t.Parallel()
+
+		out := bytes.NewBuffer(nil)
+
+		_, err := parseRunOptions([]string{"--vendor", "./vendor"}, out)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--vendor needs --offline")
+
+		opts, err := parseRunOptions([]string{"--offline", "--vendor", "./vendor"}, out)
+		require.NoError(t, err)
+		assert.True(t, opts.Offline)
+		assert.Equal(t, "./vendor", opts.Vendor)
+	})
+
+	t.Run("check flags", func(t *testing.T) {
+		t.Parallel()
+
+		out := bytes.NewBuffer(nil)
+
+		opts, err := parseRunOptions([]string{"--checks", "vet, race", "--checks", "coverage", "--skip-checks", "inspector", "--check-timeout", "90s", "--keep-going"}, out)
+		require.NoError(t, err)
+
+		checkOpts := opts.checkFlags.options()
+		assert.Equal(t, []string{"vet", "race", "coverage"}, checkOpts.Only)
+		assert.Equal(t, []string{"inspector"}, checkOpts.Skip)
+		assert.Equal(t, 90*time.Second, checkOpts.Timeout)
+		assert.True(t, checkOpts.KeepGoing)
+
+		_, err = parseRunOptions([]string{"--check-timeout", "-1s"}, out)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid --check-timeout")
+	})
+
+	t.Run("report flags", func(t *testing.T) {
+		t.Parallel()
+
+		out := bytes.NewBuffer(nil)
+
+		opts, err := parseRunOptions([]string{"--report", "JUnit"}, out)
+		require.NoError(t, err)
+		assert.Equal(t, "mcpgen-report.xml", opts.reportFile())
+
+		opts, err = parseRunOptions([]string{"--report", "json", "--report-file", "out/checks.json"}, out)
+		require.NoError(t, err)
+		assert.Equal(t, "out/checks.json", opts.reportFile())
+
+		_, err = parseRunOptions([]string{"--report", "tap"}, out)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid --report")
+
+		_, err = parseRunOptions([]string{"--report-file", "checks.json"}, out)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--report-file needs --report")
+	})
+
 	t.Run("help", func(t *testing.T) {
 		t.Parallel()
 
@@ -76,6 +199,20 @@ func TestParseRunOptions(t *testing.T) {
 		assert.Contains(t, out.String(), "--transport")
 	})
 
+	t.Run("config path", func(t *testing.T) {
+		t.Parallel()
+
+		out := bytes.NewBuffer(nil)
+
+		opts, err := parseRunOptions([]string{"--config", " mcpgen.toml ", "--name", "weather"}, out)
+		require.NoError(t, err)
+
+		assert.True(t, opts.HasCLIInput)
+		assert.Equal(t, "mcpgen.toml", opts.ConfigPath)
+		assert.True(t, opts.setFlags["name"])
+		assert.False(t, opts.setFlags["transport"])
+	})
+
 	t.Run("positional args are rejected", func(t *testing.T) {
 		t.Parallel()
 
@@ -111,6 +248,27 @@ func TestRunWithOptions(t *testing.T) {
 		assert.True(t, shouldTest)
 	})
 
+	t.Run("inspector disabled for archives", func(t *testing.T) {
+		opts := base
+		opts.OutFormat = "zip"
+
+		cfg, shouldTest, err := runWithOptions(opts, true)
+		require.NoError(t, err)
+		assert.False(t, shouldTest)
+		assert.Equal(t, "zip", cfg.OutFormat)
+		assert.Equal(t, config.DefaultOutputDir+".zip", cfg.OutDir)
+	})
+
+	t.Run("inspector disabled offline", func(t *testing.T) {
+		opts := base
+		opts.Offline = true
+
+		cfg, shouldTest, err := runWithOptions(opts, true)
+		require.NoError(t, err)
+		assert.False(t, shouldTest)
+		assert.True(t, cfg.Checks.Offline)
+	})
+
 	t.Run("no-inspector wins even with tty", func(t *testing.T) {
 		opts := base
 		opts.NoInspector = true
@@ -119,5 +277,80 @@ func TestRunWithOptions(t *testing.T) {
 		require.NoError(t, err)
 		assert.False(t, shouldTest)
 	})
+}
+
+func TestRunWithOptions_ConfigFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "mcpgen.toml")
+	content := `
+[server]
+name = "from-file"
+version = "v1.2.3"
+
+[transport]
+type = "stdio"
+
+[[tool]]
+id = "search"
+
+[[prompt]]
+id = "summary"
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	t.Run("file values are used", func(t *testing.T) {
+		t.Parallel()
+
+		opts, err := parseRunOptions([]string{"--config", path}, bytes.NewBuffer(nil))
+		require.NoError(t, err)
+
+		run, _, err := runWithOptions(opts, false)
+		require.NoError(t, err)
+
+		assert.Equal(t, config.DefaultOutputDir, run.OutDir)
+		assert.Equal(t, "from-file", run.Config.Server.Name)
+		assert.Equal(t, "v1.2.3", run.Config.Server.Version)
+		assert.Equal(t, "example.com/from-file", run.Config.Server.Module)
+		require.Len(t, run.Config.Tools, 1)
+		assert.Equal(t, "search", run.Config.Tools[0].ID)
+		assert.Empty(t, run.Config.Resources)
+		require.Len(t, run.Config.Prompts, 1)
+	})
 
+	t.Run("explicit flags override file values", func(t *testing.T) {
+		t.Parallel()
+
+		opts, err := parseRunOptions([]string{
+			"--config", path,
+			"--name", "weather",
+			"--transport", "http",
+			"--with-tools=false",
+			"--with-resources",
+		}, bytes.NewBuffer(nil))
+		require.NoError(t, err)
+
+		run, _, err := runWithOptions(opts, false)
+		require.NoError(t, err)
+
+		assert.Equal(t, "weather", run.Config.Server.Name)
+		assert.Equal(t, "http", run.Config.Transport.Type)
+		assert.Equal(t, config.DefaultHTTPPort, run.Config.Transport.HTTPPort)
+		assert.Empty(t, run.Config.Tools)
+		require.Len(t, run.Config.Resources, 1)
+		assert.Equal(t, config.DefaultResourceID, run.Config.Resources[0].ID)
+		require.Len(t, run.Config.Prompts, 1)
+		assert.Equal(t, "summary", run.Config.Prompts[0].ID)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Parallel()
+
+		opts, err := parseRunOptions([]string{"--config", path + ".missing"}, bytes.NewBuffer(nil))
+		require.NoError(t, err)
+
+		_, _, err = runWithOptions(opts, false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "could not load config file")
+	})
 }
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alesr/mcpgen/internal/checks"
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/inspector"
+)
+
+// serverCheck is a subcommand that builds the server of an existing
+// project and runs a single step against it: verify and conformance.
+type serverCheck struct {
+	// Name is the subcommand, as in "mcpgen <Name>".
+	Name  string
+	Usage string
+	// Run names what the --timeout bounds, Report what --report lists.
+	Run    string
+	Report string
+	// Inspector adds the --inspector flag.
+	Inspector bool
+	Step      func(*config.Config, inspector.Options) checks.Step
+	// Passed is printed with the project directory once the step passed.
+	Passed string
+}
+
+func (c serverCheck) run(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("mcpgen "+c.Name, flag.ContinueOnError)
+	fs.SetOutput(out)
+	fs.Usage = func() { _, _ = io.WriteString(out, c.Usage) }
+
+	var (
+		mode         = string(inspector.ModeNative)
+		timeout      time.Duration
+		readyTimeout time.Duration
+		flags        checkFlags
+	)
+	if c.Inspector {
+		fs.StringVar(&mode, "inspector", mode, "Inspector: native (Go MCP client) or npx (MCP inspector CLI, needs Node.js)")
+	}
+	fs.DurationVar(&timeout, "timeout", 0, "Timeout of the "+c.Run+" (default: none)")
+	fs.DurationVar(&readyTimeout, "ready-timeout", inspector.DefaultReadyTimeout, "How long to wait for an HTTP server to serve /mcp")
+	fs.StringVar(&flags.Report, "report", "", "Write a report of the "+c.Report+": json|junit")
+	fs.StringVar(&flags.ReportFile, "report-file", "", "File of the --report (default: mcpgen-report.json or mcpgen-report.xml)")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	if fs.NArg() > 1 {
+		return fmt.Errorf("%s takes at most one directory, got %d", c.Name, fs.NArg())
+	}
+
+	dir := config.DefaultOutputDir
+	if fs.NArg() == 1 {
+		dir = fs.Arg(0)
+	}
+
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if !slices.Contains(inspector.Modes, inspector.Mode(mode)) {
+		return fmt.Errorf("invalid --inspector %q (expected native or npx)", mode)
+	}
+
+	if timeout < 0 {
+		return fmt.Errorf("invalid --timeout %s (expected a positive duration)", timeout)
+	}
+
+	if readyTimeout <= 0 {
+		return fmt.Errorf("invalid --ready-timeout %s (expected a positive duration)", readyTimeout)
+	}
+
+	if err := flags.validate(); err != nil {
+		return err
+	}
+
+	cfg, err := loadProjectConfig(dir)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("could not validate config: %w", err)
+	}
+
+	step := c.Step(cfg, inspector.Options{Mode: inspector.Mode(mode), ReadyTimeout: readyTimeout})
+	pipeline := &checks.Pipeline{Steps: []checks.Configured{{Step: step, Timeout: timeout}}}
+
+	report, err := pipeline.RunReport(ctx, dir, out)
+	if err = reportChecks(report, checks.ReportFormat(flags.Report), flags.reportFile(), out, err); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, c.Passed+"\n", dir)
+	return nil
+}
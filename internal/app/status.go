@@ -0,0 +1,75 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/manifest"
+)
+
+// runStatus lists how the files of a generated project compare with the
+// manifest written by the last generation.
+func runStatus(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("mcpgen status", flag.ContinueOnError)
+	fs.SetOutput(out)
+	fs.Usage = func() {
+		_, _ = io.WriteString(out, `Usage: mcpgen status [dir]
+
+List the files of a generated project as pristine, modified, missing or unknown.
+dir defaults to `+config.DefaultOutputDir+`.
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	if fs.NArg() > 1 {
+		return fmt.Errorf("status takes at most one directory, got %d", fs.NArg())
+	}
+
+	outDir := config.DefaultOutputDir
+	if fs.NArg() == 1 {
+		outDir = fs.Arg(0)
+	}
+
+	m, err := manifest.Load(outDir)
+	if err != nil {
+		if errors.Is(err, manifest.ErrNotFound) {
+			return fmt.Errorf("%w in %s: generate the project with mcpgen first", err, outDir)
+		}
+		return err
+	}
+
+	statuses, err := manifest.Status(outDir, m)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Status of %s (generated by mcpgen %s)\n\n", outDir, m.Version)
+
+	counts := make(map[manifest.State]int)
+	for _, s := range statuses {
+		counts[s.State]++
+
+		note := ""
+		if s.Owner == manifest.OwnerUser {
+			note = "  (yours to edit)"
+		}
+		fmt.Fprintf(out, "  %-9s %s%s\n", s.State, s.Path, note)
+	}
+
+	fmt.Fprintf(out, "\n%d pristine, %d modified, %d missing, %d unknown\n",
+		counts[manifest.StatePristine],
+		counts[manifest.StateModified],
+		counts[manifest.StateMissing],
+		counts[manifest.StateUnknown],
+	)
+	return nil
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alesr/mcpgen/internal/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunStatus(t *testing.T) {
+	t.Parallel()
+
+	t.Run("lists files by state", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "handlers.go"), []byte("edited"), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("mine"), 0o644))
+
+		m := manifest.New()
+		m.Add("main.go", "cmd_main.go.gotmpl", manifest.OwnerGenerator, []byte("package main\n"))
+		m.Add("handlers.go", "handlers.go.gotmpl", manifest.OwnerUser, []byte("stub"))
+		m.Add("gone.go", "tools.go.gotmpl", manifest.OwnerGenerator, []byte("x"))
+		raw, err := m.Encode()
+		require.NoError(t, err)
+		path := filepath.Join(dir, filepath.FromSlash(manifest.Path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, raw, 0o644))
+
+		var out bytes.Buffer
+		require.NoError(t, runStatus([]string{dir}, &out))
+
+		assert.Contains(t, out.String(), "  pristine  main.go\n")
+		assert.Contains(t, out.String(), "  modified  handlers.go  (yours to edit)\n")
+		assert.Contains(t, out.String(), "  missing   gone.go\n")
+		assert.Contains(t, out.String(), "  unknown   notes.txt\n")
+		assert.Contains(t, out.String(), "1 pristine, 1 modified, 1 missing, 1 unknown")
+	})
+
+	t.Run("no manifest", func(t *testing.T) {
+		t.Parallel()
+
+		err := runStatus([]string{t.TempDir()}, &bytes.Buffer{})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, manifest.ErrNotFound))
+	})
+
+	t.Run("too many arguments", func(t *testing.T) {
+		t.Parallel()
+
+		err := runStatus([]string{"a", "b"}, &bytes.Buffer{})
+		require.Error(t, err)
+	})
+}
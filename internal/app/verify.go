@@ -0,0 +1,39 @@
+package app
+
+import (
+	"context"
+	"io"
+
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/inspector"
+)
+
+const verifyUsage = `Usage: mcpgen verify [flags] [dir]
+
+Build the server of a generated project and check it against its mcpgen.toml:
+the tools, resources and prompts it advertises must match the config field by
+field, and every tool, prompt and resource must answer with a valid result.
+dir defaults to ` + config.DefaultOutputDir + `.
+
+Flags:
+  --inspector native|npx    How to talk to the server (default: native)
+  --timeout duration        Give up after this long (default: none)
+  --ready-timeout duration  How long to wait for an HTTP server to serve /mcp (default: 10s)
+  --report json|junit       Write a report of every method called
+  --report-file path        File of the --report (default: mcpgen-report.json or mcpgen-report.xml)
+`
+
+// runVerify runs the inspector against an existing project, reporting
+// where what the server advertises drifted from the config it was
+// generated from.
+func runVerify(ctx context.Context, args []string, out io.Writer) error {
+	return serverCheck{
+		Name:      "verify",
+		Usage:     verifyUsage,
+		Run:       "verification",
+		Report:    "methods called",
+		Inspector: true,
+		Step:      inspector.Step,
+		Passed:    "The server in %s matches its " + config.ProjectFile + ".",
+	}.run(ctx, args, out)
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunVerify(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "no project", args: []string{t.TempDir()}, wantErr: "no " + config.ProjectFile},
+		{name: "too many arguments", args: []string{"a", "b"}, wantErr: "at most one directory"},
+		{name: "invalid inspector", args: []string{"--inspector", "telnet"}, wantErr: "invalid --inspector"},
+		{name: "invalid report", args: []string{"--report", "xml"}, wantErr: "invalid --report"},
+		{name: "report file without report", args: []string{"--report-file", "r.json"}, wantErr: "--report-file needs --report"},
+		{name: "negative timeout", args: []string{"--timeout", "-1s"}, wantErr: "invalid --timeout"},
+		{name: "zero ready timeout", args: []string{"--ready-timeout", "0s"}, wantErr: "invalid --ready-timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := runVerify(ctx, tt.args, &bytes.Buffer{})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	t.Run("help", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		require.NoError(t, runVerify(ctx, []string{"--help"}, &out))
+		assert.Contains(t, out.String(), "Usage: mcpgen verify [flags] [dir]")
+	})
+}
@@ -1,35 +1,232 @@
 package checks
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"os"
-	"os/exec"
+	"io"
+	"sync"
+	"time"
 )
 
-func Run(outDir string) error {
-	steps := []struct {
-		label string
-		cmd   []string
-	}{
-		{label: "go mod tidy", cmd: []string{"go", "mod", "tidy"}},
-		{label: "gofmt -w .", cmd: []string{"gofmt", "-w", "."}},
-		{label: "go vet ./...", cmd: []string{"go", "vet", "./..."}},
-		{label: "go test ./...", cmd: []string{"go", "test", "./..."}},
+var (
+	ErrUnknownStep = errors.New("unknown check step")
+	ErrStepTimeout = errors.New("check step timed out")
+	ErrLowCoverage = errors.New("coverage is below the minimum")
+)
+
+// Step is one check of a generated project.
+type Step interface {
+	// Name identifies the step in mcpgen.toml, selectors and errors.
+	Name() string
+	// Run checks the project in dir, streaming its output to out.
+	Run(ctx context.Context, dir string, out io.Writer) error
+}
+
+// skipper is implemented by steps that cannot run everywhere, such as
+// linters that may not be installed. A non-empty reason skips the step.
+type skipper interface {
+	SkipReason() string
+}
+
+// StepError reports the check that failed along with what it printed.
+type StepError struct {
+	Step   string
+	Output string
+	Err    error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("%s failed: %v", e.Step, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// Configured is a step with the settings a pipeline runs it with.
+type Configured struct {
+	Step
+	// Timeout bounds the step; zero means no limit.
+	Timeout time.Duration
+	// ContinueOnError reports a failure of the step without failing the
+	// pipeline, for advisory checks such as vulnerability scans.
+	ContinueOnError bool
+	// Skip, when set, is why the step is not run.
+	Skip string
+}
+
+// Pipeline runs the checks of a generated project in order.
+type Pipeline struct {
+	Steps []Configured
+	// KeepGoing runs the remaining steps after a failure; the pipeline
+	// still fails, with every failure joined.
+	KeepGoing bool
+}
+
+// Names lists the steps of p in order, skipped ones included.
+func (p *Pipeline) Names() []string {
+	names := make([]string, 0, len(p.Steps))
+	for _, s := range p.Steps {
+		names = append(names, s.Name())
 	}
+	return names
+}
+
+// Run runs the steps of p on the project in dir, streaming their output
+// to out. The first failing step stops it with a *StepError unless
+// KeepGoing is set; canceling ctx stops it regardless.
+func (p *Pipeline) Run(ctx context.Context, dir string, out io.Writer) error {
+	_, err := p.RunReport(ctx, dir, out)
+	return err
+}
 
-	for _, step := range steps {
-		fmt.Printf("Running: %s\n", step.label)
+// RunReport runs p like Run and also reports what every step did, the
+// ones skipped or left out after a failure included.
+func (p *Pipeline) RunReport(ctx context.Context, dir string, out io.Writer) (*Report, error) {
+	report := &Report{Dir: dir, Started: time.Now(), Status: StatusPassed}
+
+	var errs []error
+	for _, s := range p.Steps {
+		reason := s.Skip
+		if sk, ok := s.Step.(skipper); ok && reason == "" {
+			reason = sk.SkipReason()
+		}
+		if len(errs) > 0 && !p.KeepGoing {
+			reason = "an earlier step failed"
+		}
+		if ctx.Err() != nil {
+			reason = "canceled"
+		}
 
-		cmd := exec.Command(step.cmd[0], step.cmd[1:]...)
+		if reason != "" {
+			fmt.Fprintf(out, "Skipping: %s (%s)\n", s.Name(), reason)
+			report.Steps = append(report.Steps, Result{Name: s.Name(), Status: StatusSkipped, Reason: reason})
+			continue
+		}
 
-		cmd.Dir = outDir
-		cmd.Env = append(os.Environ(), "GOWORK=off") // TODO(alesr): rm gowork
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+		res, err := runStep(ctx, s, dir, out)
+		report.Steps = append(report.Steps, res)
 
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("%s failed: %w", step.label, err)
+		switch {
+		case err == nil:
+		case s.ContinueOnError:
+			fmt.Fprintf(out, "Warning: %v (continue_on_error)\n", err)
+		default:
+			errs = append(errs, err)
 		}
 	}
-	return nil
+
+	report.Duration = time.Since(report.Started)
+	if len(errs) == 0 {
+		return report, nil
+	}
+
+	report.Status = StatusFailed
+	if len(errs) == 1 {
+		return report, errs[0]
+	}
+	return report, errors.Join(errs...)
+}
+
+func runStep(ctx context.Context, s Configured, dir string, out io.Writer) (Result, error) {
+	label := s.Name()
+	if st, ok := s.Step.(fmt.Stringer); ok {
+		label = fmt.Sprintf("%s (%s)", label, st)
+	}
+	fmt.Fprintf(out, "Running: %s\n", label)
+
+	stepCtx := ctx
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		stepCtx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	var (
+		output, stdout, stderr bytes.Buffer
+		rec                    recorder
+	)
+	w := &streams{
+		stdout: io.MultiWriter(out, &output, &stdout),
+		stderr: io.MultiWriter(Stderr(out), &output, &stderr),
+	}
+
+	start := time.Now()
+	err := s.Run(context.WithValue(stepCtx, recorderKey{}, &rec), dir, w)
+
+	res := Result{
+		Name:     s.Name(),
+		Status:   StatusPassed,
+		Duration: time.Since(start),
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		Cases:    rec.cases,
+	}
+	if err == nil {
+		return res, nil
+	}
+
+	if ctx.Err() == nil && errors.Is(stepCtx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("%w after %s: %w", ErrStepTimeout, s.Timeout, err)
+	}
+	res.Status, res.Error, res.ContinueOnError = StatusFailed, err.Error(), s.ContinueOnError
+	return res, &StepError{Step: s.Name(), Output: output.String(), Err: err}
+}
+
+// streams is the writer steps get from a pipeline. Writes to it and to
+// its Stderr are serialized, as commands copy both outputs concurrently.
+type streams struct {
+	mu     sync.Mutex
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (s *streams) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stdout.Write(p)
+}
+
+func (s *streams) Stderr() io.Writer {
+	return writerFunc(func(p []byte) (int, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.stderr.Write(p)
+	})
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+// Stderr returns where a step writes error output, given the out its Run
+// got. Reports keep it apart from standard output; elsewhere it is out.
+func Stderr(out io.Writer) io.Writer {
+	if s, ok := out.(interface{ Stderr() io.Writer }); ok {
+		return s.Stderr()
+	}
+	return out
+}
+
+type recorderKey struct{}
+
+// recorder collects the cases a step records.
+type recorder struct {
+	mu    sync.Mutex
+	cases []Result
+}
+
+// RecordCase adds r to the report of the running step, for steps made of
+// parts worth reporting on their own, such as inspector methods. It does
+// nothing when ctx does not come from a pipeline.
+func RecordCase(ctx context.Context, r Result) {
+	rec, ok := ctx.Value(recorderKey{}).(*recorder)
+	if !ok {
+		return
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.cases = append(rec.cases, r)
 }
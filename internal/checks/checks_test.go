@@ -0,0 +1,324 @@
+package checks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipeline_Run(t *testing.T) {
+	t.Parallel()
+
+	errFailed := errors.New("failed")
+
+	// step records its name in ran and returns err
+	step := func(ran *[]string, name string, err error) Step {
+		return Func(name, func(_ context.Context, _ string, out io.Writer) error {
+			*ran = append(*ran, name)
+			_, _ = io.WriteString(out, name+" output\n")
+			return err
+		})
+	}
+
+	t.Run("stops at the first failure", func(t *testing.T) {
+		t.Parallel()
+
+		var ran []string
+		p := &Pipeline{Steps: []Configured{
+			{Step: step(&ran, "vet", nil)},
+			{Step: step(&ran, "test", errFailed)},
+			{Step: step(&ran, "race", nil)},
+		}}
+
+		var out bytes.Buffer
+		err := p.Run(context.Background(), t.TempDir(), &out)
+
+		var stepErr *StepError
+		require.ErrorAs(t, err, &stepErr)
+		assert.Equal(t, "test", stepErr.Step)
+		assert.Equal(t, "test output\n", stepErr.Output)
+		assert.ErrorIs(t, err, errFailed)
+		assert.Equal(t, []string{"vet", "test"}, ran)
+		assert.Contains(t, out.String(), "Running: vet\n")
+	})
+
+	t.Run("continue on error", func(t *testing.T) {
+		t.Parallel()
+
+		var ran []string
+		p := &Pipeline{Steps: []Configured{
+			{Step: step(&ran, "govulncheck", errFailed), ContinueOnError: true},
+			{Step: step(&ran, "test", nil)},
+		}}
+
+		var out bytes.Buffer
+		require.NoError(t, p.Run(context.Background(), t.TempDir(), &out))
+		assert.Equal(t, []string{"govulncheck", "test"}, ran)
+		assert.Contains(t, out.String(), "Warning: govulncheck failed: failed")
+	})
+
+	t.Run("keep going joins failures", func(t *testing.T) {
+		t.Parallel()
+
+		var ran []string
+		p := &Pipeline{KeepGoing: true, Steps: []Configured{
+			{Step: step(&ran, "vet", errFailed)},
+			{Step: step(&ran, "test", errFailed)},
+			{Step: step(&ran, "race", nil)},
+		}}
+
+		err := p.Run(context.Background(), t.TempDir(), io.Discard)
+		require.Error(t, err)
+		assert.Equal(t, []string{"vet", "test", "race"}, ran)
+		assert.Contains(t, err.Error(), "vet failed")
+		assert.Contains(t, err.Error(), "test failed")
+	})
+
+	t.Run("skipped steps", func(t *testing.T) {
+		t.Parallel()
+
+		var ran []string
+		p := &Pipeline{Steps: []Configured{
+			{Step: step(&ran, "tidy", nil), Skip: "offline"},
+			{Step: &command{name: "lint", args: []string{"mcpgen-no-such-linter"}, optional: true}},
+		}}
+
+		var out bytes.Buffer
+		require.NoError(t, p.Run(context.Background(), t.TempDir(), &out))
+		assert.Empty(t, ran)
+		assert.Contains(t, out.String(), "Skipping: tidy (offline)")
+		assert.Contains(t, out.String(), "Skipping: lint (mcpgen-no-such-linter is not installed)")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		t.Parallel()
+
+		slow := Func("slow", func(ctx context.Context, _ string, _ io.Writer) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		p := &Pipeline{Steps: []Configured{{Step: slow, Timeout: 10 * time.Millisecond}}}
+
+		err := p.Run(context.Background(), t.TempDir(), io.Discard)
+		assert.ErrorIs(t, err, ErrStepTimeout)
+		assert.Contains(t, err.Error(), "after 10ms")
+	})
+
+	t.Run("canceled stops keep going", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var ran []string
+		interrupted := Func("test", func(ctx context.Context, _ string, _ io.Writer) error {
+			ran = append(ran, "test")
+			cancel()
+			return ctx.Err()
+		})
+		p := &Pipeline{KeepGoing: true, Steps: []Configured{
+			{Step: interrupted},
+			{Step: step(&ran, "race", nil)},
+		}}
+
+		var out bytes.Buffer
+		report, err := p.RunReport(ctx, t.TempDir(), &out)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, []string{"test"}, ran)
+		assert.Contains(t, out.String(), "Skipping: race (canceled)")
+		assert.Equal(t, StatusSkipped, report.Steps[1].Status)
+	})
+
+	t.Run("commands run in the project", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		p := &Pipeline{Steps: []Configured{{Step: &command{name: "env", args: []string{"go", "env", "GOWORK"}}}}}
+
+		var out bytes.Buffer
+		require.NoError(t, p.Run(context.Background(), dir, &out))
+		assert.Contains(t, out.String(), "Running: env (go env GOWORK)\noff\n")
+	})
+}
+
+func TestBuild(t *testing.T) {
+	t.Parallel()
+
+	inspector := Func("inspector", func(context.Context, string, io.Writer) error { return nil })
+
+	// summary lists the steps of p as name, name:skip or name:timeout
+	summary := func(p *Pipeline) []string {
+		var steps []string
+		for _, s := range p.Steps {
+			entry := s.Name()
+			if s.Skip != "" {
+				entry += ":" + s.Skip
+			}
+			if s.Timeout > 0 {
+				entry += ":" + s.Timeout.String()
+			}
+			if s.ContinueOnError {
+				entry += ":continue"
+			}
+			steps = append(steps, entry)
+		}
+		return steps
+	}
+
+	configured := []config.CheckConfig{
+		{Name: "vet"},
+		{Name: "race", Timeout: "5m"},
+		{Name: "govulncheck", ContinueOnError: true},
+		{Name: "lint", Run: []string{"make", "lint"}},
+	}
+
+	tests := []struct {
+		name     string
+		steps    []config.CheckConfig
+		opts     Options
+		expected []string
+	}{
+		{
+			name:     "defaults",
+			expected: []string{"tidy", "fmt", "vet", "test", "inspector"},
+		},
+		{
+			name:     "configured steps",
+			steps:    configured,
+			opts:     Options{Timeout: time.Minute},
+			expected: []string{"vet:1m0s", "race:5m0s", "govulncheck:1m0s:continue", "lint:1m0s", "inspector:1m0s"},
+		},
+		{
+			name:     "config places the inspector",
+			steps:    []config.CheckConfig{{Name: "inspector"}, {Name: "vet"}},
+			expected: []string{"inspector", "vet"},
+		},
+		{
+			name:     "only keeps config order and appends the rest",
+			steps:    configured,
+			opts:     Options{Only: []string{"coverage", "lint", "vet"}},
+			expected: []string{"vet", "lint", "coverage"},
+		},
+		{
+			name:     "skip",
+			opts:     Options{Skip: []string{"tidy", "inspector"}},
+			expected: []string{"fmt", "vet", "test"},
+		},
+		{
+			name:     "offline",
+			steps:    configured,
+			opts:     Options{Offline: true},
+			expected: []string{"vet:offline", "race:offline:5m0s", "govulncheck:offline:continue", "lint", "inspector:offline", "typecheck"},
+		},
+		{
+			name:     "offline with only",
+			opts:     Options{Offline: true, Only: []string{"fmt"}},
+			expected: []string{"fmt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p, err := Build(tt.steps, tt.opts, inspector)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, summary(p))
+		})
+	}
+
+	t.Run("unknown steps", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Build(nil, Options{Only: []string{"fuzz"}, Skip: []string{"bench"}})
+		require.ErrorIs(t, err, ErrUnknownStep)
+		assert.Equal(t, "unknown check step: \"fuzz\"\nunknown check step: \"bench\"", err.Error())
+
+		_, err = Build([]config.CheckConfig{{Name: "lint"}}, Options{})
+		assert.ErrorIs(t, err, ErrUnknownStep, "configured steps need a run command unless they are built in")
+	})
+
+	t.Run("coverage minimum", func(t *testing.T) {
+		t.Parallel()
+
+		p, err := Build([]config.CheckConfig{{Name: "coverage", MinCoverage: 75}}, Options{})
+		require.NoError(t, err)
+		require.Len(t, p.Steps, 1)
+		assert.Equal(t, 75.0, p.Steps[0].Step.(*coverage).min)
+	})
+}
+
+func TestTotalCoverage(t *testing.T) {
+	t.Parallel()
+
+	profile := `mode: set
+example.com/app/a.go:3.20,5.2 2 1
+example.com/app/a.go:7.20,9.2 3 0
+example.com/app/b.go:3.20,5.2 5 0
+example.com/app/b.go:3.20,5.2 5 1
+`
+
+	total, err := totalCoverage(strings.NewReader(profile))
+	require.NoError(t, err)
+	assert.InDelta(t, 70.0, total, 0.01, "blocks listed twice count once, covered if any run covered them")
+
+	total, err = totalCoverage(strings.NewReader("mode: set\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, total)
+
+	_, err = totalCoverage(strings.NewReader("mode: set\nbroken\n"))
+	assert.Error(t, err)
+}
+
+func TestPipeline_RunReport(t *testing.T) {
+	t.Parallel()
+
+	errFailed := errors.New("failed")
+
+	inspector := Func("inspector", func(ctx context.Context, _ string, out io.Writer) error {
+		RecordCase(ctx, Result{Name: "tools/list", Status: StatusPassed})
+		RecordCase(ctx, Result{Name: "prompts/list", Status: StatusFailed, Error: "exit status 1"})
+		return errFailed
+	})
+	noisy := Func("noisy", func(_ context.Context, _ string, out io.Writer) error {
+		_, _ = io.WriteString(out, "to stdout\n")
+		_, _ = io.WriteString(Stderr(out), "to stderr\n")
+		return nil
+	})
+
+	p := &Pipeline{Steps: []Configured{
+		{Step: noisy},
+		{Step: Func("tidy", nil), Skip: "offline"},
+		{Step: inspector},
+		{Step: noisy},
+	}}
+
+	var out bytes.Buffer
+	report, err := p.RunReport(context.Background(), "project", &out)
+	require.ErrorIs(t, err, errFailed)
+
+	assert.Equal(t, "project", report.Dir)
+	assert.Equal(t, StatusFailed, report.Status)
+	require.Len(t, report.Steps, 4)
+
+	assert.Equal(t, StatusPassed, report.Steps[0].Status)
+	assert.Equal(t, "to stdout\n", report.Steps[0].Stdout)
+	assert.Equal(t, "to stderr\n", report.Steps[0].Stderr)
+	assert.Contains(t, out.String(), "to stdout\nto stderr\n", "both streams still reach out")
+
+	assert.Equal(t, Result{Name: "tidy", Status: StatusSkipped, Reason: "offline"}, report.Steps[1])
+
+	assert.Equal(t, StatusFailed, report.Steps[2].Status)
+	assert.Equal(t, "failed", report.Steps[2].Error)
+	assert.Equal(t, []string{"tools/list", "prompts/list"}, []string{report.Steps[2].Cases[0].Name, report.Steps[2].Cases[1].Name})
+
+	assert.Equal(t, Result{Name: "noisy", Status: StatusSkipped, Reason: "an earlier step failed"}, report.Steps[3])
+}
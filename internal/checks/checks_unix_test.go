@@ -0,0 +1,28 @@
+//go:build unix
+
+package checks
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/alesr/mcpgen/internal/pkg/proc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommand_TimeoutStopsChildren(t *testing.T) {
+	t.Parallel()
+
+	// the shell waits on a child that keeps the output open, as go test
+	// does with its test binaries
+	hang := &command{name: "hang", args: []string{"sh", "-c", "sleep 60 & wait"}}
+	p := &Pipeline{Steps: []Configured{{Step: hang, Timeout: 100 * time.Millisecond}}}
+
+	start := time.Now()
+	err := p.Run(context.Background(), t.TempDir(), io.Discard)
+
+	assert.ErrorIs(t, err, ErrStepTimeout)
+	assert.Less(t, time.Since(start), proc.WaitDelay, "the child should stop with the shell")
+}
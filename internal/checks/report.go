@@ -0,0 +1,192 @@
+package checks
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrReportFormat means a report was asked for in a format other than
+// those of ReportFormats.
+var ErrReportFormat = errors.New("unknown report format")
+
+// Status is the outcome of a step.
+type Status string
+
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+// ReportFormat is an encoding of a Report.
+type ReportFormat string
+
+const (
+	ReportJSON  ReportFormat = "json"
+	ReportJUnit ReportFormat = "junit"
+)
+
+// ReportFormats lists the formats Report.Write supports.
+var ReportFormats = []ReportFormat{ReportJSON, ReportJUnit}
+
+// Result is what a step, or a case of a step, did.
+type Result struct {
+	Name     string        `json:"name"`
+	Status   Status        `json:"status"`
+	Duration time.Duration `json:"-"`
+	Stdout   string        `json:"stdout,omitempty"`
+	Stderr   string        `json:"stderr,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	// Reason says why a step was skipped.
+	Reason string `json:"reason,omitempty"`
+	// ContinueOnError marks a failure that did not fail the pipeline.
+	ContinueOnError bool `json:"continue_on_error,omitempty"`
+	// Cases are the parts of the step it reported on their own, such as
+	// the methods the inspector called.
+	Cases []Result `json:"cases,omitempty"`
+}
+
+// MarshalJSON encodes Duration as fractional seconds.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type result Result
+	return json.Marshal(struct {
+		result
+		Seconds float64 `json:"seconds"`
+	}{result(r), r.Duration.Seconds()})
+}
+
+// Report is what a pipeline run did, step by step.
+type Report struct {
+	Dir      string        `json:"dir"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"-"`
+	Status   Status        `json:"status"`
+	Steps    []Result      `json:"steps"`
+}
+
+// MarshalJSON encodes Duration as fractional seconds.
+func (r Report) MarshalJSON() ([]byte, error) {
+	type report Report
+	return json.Marshal(struct {
+		report
+		Seconds float64 `json:"seconds"`
+	}{report(r), r.Duration.Seconds()})
+}
+
+// Write encodes r to w as indented JSON or as JUnit XML. In JUnit, steps
+// are the test cases of a "checks" suite, and the cases of a step get a
+// suite named after it.
+func (r *Report) Write(w io.Writer, format ReportFormat) error {
+	switch format {
+	case ReportJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case ReportJUnit:
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+		enc := xml.NewEncoder(w)
+		enc.Indent("", "  ")
+		if err := enc.Encode(r.junit()); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "\n")
+		return err
+	}
+	return fmt.Errorf("%w: %q", ErrReportFormat, format)
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr,omitempty"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+	SystemErr string        `xml:"system-err,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func (r *Report) junit() junitSuites {
+	checks := junitSuite{Name: "checks", Timestamp: r.Started.UTC().Format(time.RFC3339), Time: seconds(r.Duration)}
+	suites := []junitSuite{}
+
+	for _, step := range r.Steps {
+		checks.add(step)
+
+		if len(step.Cases) > 0 {
+			suite := junitSuite{Name: step.Name, Time: seconds(step.Duration)}
+			for _, c := range step.Cases {
+				suite.add(c)
+			}
+			suites = append(suites, suite)
+		}
+	}
+	suites = append([]junitSuite{checks}, suites...)
+
+	all := junitSuites{Name: "mcpgen", Time: seconds(r.Duration), Suites: suites}
+	for _, s := range suites {
+		all.Tests += s.Tests
+		all.Failures += s.Failures
+		all.Skipped += s.Skipped
+	}
+	return all
+}
+
+func (s *junitSuite) add(r Result) {
+	c := junitCase{
+		Name:      r.Name,
+		Classname: s.Name,
+		Time:      seconds(r.Duration),
+		SystemOut: r.Stdout,
+		SystemErr: r.Stderr,
+	}
+
+	s.Tests++
+	switch r.Status {
+	case StatusFailed:
+		s.Failures++
+		message := r.Error
+		if r.ContinueOnError {
+			message += " (continue_on_error)"
+		}
+		c.Failure = &junitMessage{Message: message, Text: r.Error}
+	case StatusSkipped:
+		s.Skipped++
+		c.Skipped = &junitMessage{Message: r.Reason}
+	}
+	s.Cases = append(s.Cases, c)
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,104 @@
+package checks
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport_Write(t *testing.T) {
+	t.Parallel()
+
+	report := &Report{
+		Dir:      "weather",
+		Started:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Duration: 3 * time.Second,
+		Status:   StatusFailed,
+		Steps: []Result{
+			{Name: "tidy", Status: StatusSkipped, Reason: "offline"},
+			{Name: "vet", Status: StatusPassed, Duration: 1500 * time.Millisecond, Stdout: "ok\n"},
+			{Name: "govulncheck", Status: StatusFailed, Error: "exit status 3", ContinueOnError: true},
+			{
+				Name:     "inspector",
+				Status:   StatusFailed,
+				Duration: time.Second,
+				Error:    "prompts/list failed: exit status 1",
+				Cases: []Result{
+					{Name: "tools/list", Status: StatusPassed, Duration: 250 * time.Millisecond},
+					{Name: "prompts/list", Status: StatusFailed, Error: "exit status 1", Stderr: "boom\x1b[0m\n"},
+				},
+			},
+		},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		require.NoError(t, report.Write(&buf, ReportJSON))
+
+		var decoded struct {
+			Status  string  `json:"status"`
+			Seconds float64 `json:"seconds"`
+			Steps   []struct {
+				Name    string  `json:"name"`
+				Status  string  `json:"status"`
+				Seconds float64 `json:"seconds"`
+				Reason  string  `json:"reason"`
+				Cases   []struct {
+					Name   string `json:"name"`
+					Stderr string `json:"stderr"`
+				} `json:"cases"`
+			} `json:"steps"`
+		}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+
+		assert.Equal(t, "failed", decoded.Status)
+		assert.Equal(t, 3.0, decoded.Seconds)
+		require.Len(t, decoded.Steps, 4)
+		assert.Equal(t, "offline", decoded.Steps[0].Reason)
+		assert.Equal(t, 1.5, decoded.Steps[1].Seconds)
+		assert.Equal(t, "boom\x1b[0m\n", decoded.Steps[3].Cases[1].Stderr)
+	})
+
+	t.Run("junit", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		require.NoError(t, report.Write(&buf, ReportJUnit))
+		assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte(xml.Header)))
+
+		var decoded junitSuites
+		require.NoError(t, xml.Unmarshal(buf.Bytes(), &decoded))
+
+		assert.Equal(t, 6, decoded.Tests)
+		assert.Equal(t, 3, decoded.Failures)
+		assert.Equal(t, 1, decoded.Skipped)
+		require.Len(t, decoded.Suites, 2)
+
+		checks := decoded.Suites[0]
+		assert.Equal(t, "checks", checks.Name)
+		assert.Equal(t, "2026-01-02T03:04:05Z", checks.Timestamp)
+		assert.Equal(t, "1.500", checks.Cases[1].Time)
+		assert.Equal(t, "ok\n", checks.Cases[1].SystemOut)
+		assert.Equal(t, "offline", checks.Cases[0].Skipped.Message)
+		assert.Equal(t, "exit status 3 (continue_on_error)", checks.Cases[2].Failure.Message)
+
+		inspector := decoded.Suites[1]
+		assert.Equal(t, "inspector", inspector.Name)
+		assert.Equal(t, []string{"tools/list", "prompts/list"}, []string{inspector.Cases[0].Name, inspector.Cases[1].Name})
+		assert.Equal(t, "inspector", inspector.Cases[1].Classname)
+		assert.Equal(t, "boom�[0m\n", inspector.Cases[1].SystemErr, "control characters are not valid XML")
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		t.Parallel()
+
+		assert.ErrorIs(t, report.Write(&bytes.Buffer{}, "tap"), ErrReportFormat)
+	})
+}
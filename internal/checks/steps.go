@@ -0,0 +1,353 @@
+package checks
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/pkg/proc"
+	"github.com/alesr/mcpgen/internal/typecheck"
+)
+
+// Names of the built-in steps.
+const (
+	StepTidy         = "tidy"
+	StepFmt          = "fmt"
+	StepVet          = "vet"
+	StepTest         = "test"
+	StepRace         = "race"
+	StepStaticcheck  = "staticcheck"
+	StepGolangciLint = "golangci-lint"
+	StepVulncheck    = "govulncheck"
+	StepCoverage     = "coverage"
+	StepTypecheck    = "typecheck"
+)
+
+// DefaultSteps run when the config does not list any.
+var DefaultSteps = []string{StepTidy, StepFmt, StepVet, StepTest}
+
+// Options narrow and tune the configured steps from the command line.
+type Options struct {
+	// Only runs just these steps, in config order; the ones the config
+	// does not list follow in the order given.
+	Only []string
+	// Skip drops these steps.
+	Skip []string
+	// Timeout bounds the steps that do not set their own.
+	Timeout time.Duration
+	// KeepGoing runs every step even after one failed.
+	KeepGoing bool
+	// Offline skips the steps that run the go command or other tools that
+	// may need the network, and type-checks the project in-process
+	// instead, with dependencies read from Vendor or the module cache.
+	Offline bool
+	Vendor  string
+}
+
+// Build returns the pipeline that steps configure, or the default one
+// when steps is empty, narrowed by opts. extra are steps the caller owns,
+// such as the inspector: they run after the configured steps unless the
+// config places them, and are skipped offline.
+func Build(steps []config.CheckConfig, opts Options, extra ...Step) (*Pipeline, error) {
+	extras := make(map[string]Step, len(extra))
+	for _, s := range extra {
+		extras[s.Name()] = s
+	}
+
+	if len(steps) == 0 {
+		for _, name := range DefaultSteps {
+			steps = append(steps, config.CheckConfig{Name: name})
+		}
+	}
+	for _, s := range extra {
+		if !slices.ContainsFunc(steps, func(c config.CheckConfig) bool { return c.Name == s.Name() }) {
+			steps = append(steps, config.CheckConfig{Name: s.Name()})
+		}
+	}
+
+	configured := func(name string) bool {
+		return slices.ContainsFunc(steps, func(c config.CheckConfig) bool { return c.Name == name })
+	}
+
+	var errs []error
+	for _, name := range append(slices.Clone(opts.Only), opts.Skip...) {
+		if !configured(name) && extras[name] == nil && builtin(name, opts) == nil {
+			errs = append(errs, fmt.Errorf("%w: %q", ErrUnknownStep, name))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if len(opts.Only) > 0 {
+		selected := make([]config.CheckConfig, 0, len(opts.Only))
+		for _, c := range steps {
+			if slices.Contains(opts.Only, c.Name) {
+				selected = append(selected, c)
+			}
+		}
+		for _, name := range opts.Only {
+			if !configured(name) {
+				selected = append(selected, config.CheckConfig{Name: name})
+			}
+		}
+		steps = selected
+	} else if opts.Offline && !configured(StepTypecheck) {
+		steps = append(steps, config.CheckConfig{Name: StepTypecheck})
+	}
+
+	steps = slices.DeleteFunc(slices.Clone(steps), func(c config.CheckConfig) bool {
+		return slices.Contains(opts.Skip, c.Name)
+	})
+
+	p := &Pipeline{KeepGoing: opts.KeepGoing}
+	for _, c := range steps {
+		step, network := resolve(c, opts, extras)
+		if step == nil {
+			errs = append(errs, fmt.Errorf("%w: %q", ErrUnknownStep, c.Name))
+			continue
+		}
+
+		timeout := opts.Timeout
+		if c.Timeout != "" {
+			d, err := time.ParseDuration(c.Timeout)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("check %q: %w", c.Name, err))
+				continue
+			}
+			timeout = d
+		}
+
+		cs := Configured{Step: step, Timeout: timeout, ContinueOnError: c.ContinueOnError}
+		if opts.Offline && network {
+			cs.Skip = "offline"
+		}
+		p.Steps = append(p.Steps, cs)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return p, nil
+}
+
+// resolve returns the step c configures and whether it needs the network.
+// Custom commands are run offline too: only their author knows what
+// they need.
+func resolve(c config.CheckConfig, opts Options, extras map[string]Step) (Step, bool) {
+	switch {
+	case len(c.Run) > 0:
+		return &command{name: c.Name, args: c.Run}, false
+	case extras[c.Name] != nil:
+		return extras[c.Name], true
+	}
+
+	step := builtin(c.Name, opts)
+	if step == nil {
+		return nil, false
+	}
+	if cov, ok := step.(*coverage); ok {
+		cov.min = c.MinCoverage
+	}
+	return step, c.Name != StepFmt && c.Name != StepTypecheck
+}
+
+// builtin returns the built-in step called name, or nil.
+func builtin(name string, opts Options) Step {
+	switch name {
+	case StepTidy:
+		return &command{name: name, args: []string{"go", "mod", "tidy"}}
+	case StepFmt:
+		return &command{name: name, args: []string{"gofmt", "-w", "."}}
+	case StepVet:
+		return &command{name: name, args: []string{"go", "vet", "./..."}}
+	case StepTest:
+		return &command{name: name, args: []string{"go", "test", "./..."}}
+	case StepRace:
+		return &command{name: name, args: []string{"go", "test", "-race", "./..."}}
+	case StepStaticcheck:
+		return &command{name: name, args: []string{"staticcheck", "./..."}, optional: true}
+	case StepGolangciLint:
+		return &command{name: name, args: []string{"golangci-lint", "run", "./..."}, optional: true}
+	case StepVulncheck:
+		return &command{name: name, args: []string{"govulncheck", "./..."}, optional: true}
+	case StepCoverage:
+		return &coverage{}
+	case StepTypecheck:
+		return &typecheckStep{opts: typecheck.Options{Vendor: opts.Vendor}}
+	}
+	return nil
+}
+
+// command runs a program in the project directory.
+type command struct {
+	name string
+	args []string
+	// optional commands are skipped when the program is not installed
+	optional bool
+}
+
+func (c *command) Name() string   { return c.name }
+func (c *command) String() string { return strings.Join(c.args, " ") }
+
+func (c *command) SkipReason() string {
+	if !c.optional {
+		return ""
+	}
+	if _, err := exec.LookPath(c.args[0]); err != nil {
+		return c.args[0] + " is not installed"
+	}
+	return ""
+}
+
+func (c *command) Run(ctx context.Context, dir string, out io.Writer) error {
+	// in a group of its own, so a timed-out `go test` takes its test
+	// binaries down with it
+	cmd := proc.Command(ctx, c.args[0], c.args[1:]...)
+
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off") // TODO(alesr): rm gowork
+	cmd.Stdout = out
+	cmd.Stderr = Stderr(out)
+
+	return cmd.Run()
+}
+
+// coverage runs the tests with a coverage profile and fails when the
+// total statement coverage is below min.
+type coverage struct {
+	min float64
+}
+
+func (c *coverage) Name() string   { return StepCoverage }
+func (c *coverage) String() string { return "go test -coverprofile ./..." }
+
+func (c *coverage) Run(ctx context.Context, dir string, out io.Writer) error {
+	profile, err := os.CreateTemp("", "mcpgen-cover-*.out")
+	if err != nil {
+		return err
+	}
+	_ = profile.Close()
+	defer os.Remove(profile.Name())
+
+	test := &command{name: StepCoverage, args: []string{"go", "test", "-coverprofile=" + profile.Name(), "./..."}}
+	if err := test.Run(ctx, dir, out); err != nil {
+		return err
+	}
+
+	f, err := os.Open(profile.Name())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	total, err := totalCoverage(f)
+	if err != nil {
+		return fmt.Errorf("could not read coverage profile: %w", err)
+	}
+
+	fmt.Fprintf(out, "Total coverage: %.1f%% (minimum %.1f%%)\n", total, c.min)
+	if total < c.min {
+		return fmt.Errorf("%w: %.1f%% < %.1f%%", ErrLowCoverage, total, c.min)
+	}
+	return nil
+}
+
+// totalCoverage returns the percentage of statements a profile written
+// by go test -coverprofile covers. A project without statements is fully
+// covered.
+func totalCoverage(r io.Reader) (float64, error) {
+	type block struct {
+		statements int
+		covered    bool
+	}
+
+	// blocks are keyed by position, as a block may be listed once per
+	// test binary that compiled it
+	blocks := make(map[string]block)
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+
+		// file.go:startLine.startCol,endLine.endCol statements count
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			return 0, fmt.Errorf("malformed line %q", line)
+		}
+		statements, err := strconv.Atoi(fields[len(fields)-2])
+		if err != nil {
+			return 0, fmt.Errorf("malformed line %q: %w", line, err)
+		}
+		count, err := strconv.Atoi(fields[len(fields)-1])
+		if err != nil {
+			return 0, fmt.Errorf("malformed line %q: %w", line, err)
+		}
+
+		pos := strings.Join(fields[:len(fields)-2], " ")
+		b := blocks[pos]
+		b.statements = statements
+		b.covered = b.covered || count > 0
+		blocks[pos] = b
+	}
+	if err := sc.Err(); err != nil {
+		return 0, err
+	}
+
+	var total, covered int
+	for _, b := range blocks {
+		total += b.statements
+		if b.covered {
+			covered += b.statements
+		}
+	}
+	if total == 0 {
+		return 100, nil
+	}
+	return 100 * float64(covered) / float64(total), nil
+}
+
+// typecheckStep type-checks the project in-process, without the go
+// command or the network.
+type typecheckStep struct {
+	opts typecheck.Options
+}
+
+func (s *typecheckStep) Name() string   { return StepTypecheck }
+func (s *typecheckStep) String() string { return "in-process, offline" }
+
+func (s *typecheckStep) Run(ctx context.Context, dir string, out io.Writer) error {
+	if err := typecheck.Check(ctx, os.DirFS(dir), s.opts); err != nil {
+		fmt.Fprintln(out, err)
+		return err
+	}
+	return nil
+}
+
+// Func makes a step of fn, for checks that live outside this package.
+func Func(name string, fn func(ctx context.Context, dir string, out io.Writer) error) Step {
+	return &funcStep{name: name, fn: fn}
+}
+
+type funcStep struct {
+	name string
+	fn   func(ctx context.Context, dir string, out io.Writer) error
+}
+
+func (s *funcStep) Name() string { return s.name }
+
+func (s *funcStep) Run(ctx context.Context, dir string, out io.Writer) error {
+	return s.fn(ctx, dir, out)
+}
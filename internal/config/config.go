@@ -7,66 +7,81 @@ import (
 type (
 	ServerConfig struct {
 		Name        string `toml:"name"`
-		Version     string `toml:"version"`
-		Title       string `toml:"title"`
-		Description string `toml:"description"`
-		Module      string `toml:"module"`
+		Version     string `toml:"version,omitempty"`
+		Title       string `toml:"title,omitempty"`
+		Description string `toml:"description,omitempty"`
+		Module      string `toml:"module,omitempty"`
 	}
 
 	TransportConfig struct {
 		Type     string `toml:"type"`
-		HTTPPort int    `toml:"http_port"`
+		HTTPPort int    `toml:"http_port,omitempty"`
 	}
 
 	ToolConfig struct {
 		ID           string `toml:"id"`
-		Title        string `toml:"title"`
-		Description  string `toml:"description"`
-		InputSchema  string `toml:"input_schema"`
-		OutputSchema string `toml:"output_schema"`
+		Title        string `toml:"title,omitempty"`
+		Description  string `toml:"description,omitempty"`
+		InputSchema  string `toml:"input_schema,omitempty"`
+		OutputSchema string `toml:"output_schema,omitempty"`
 	}
 
 	ResourceConfig struct {
 		ID          string `toml:"id"`
-		Title       string `toml:"title"`
-		Description string `toml:"description"`
-		URI         string `toml:"uri"`
-		URITemplate string `toml:"uri_template"`
-		MIMEType    string `toml:"mime_type"`
-		Text        string `toml:"text"`
+		Title       string `toml:"title,omitempty"`
+		Description string `toml:"description,omitempty"`
+		URI         string `toml:"uri,omitempty"`
+		URITemplate string `toml:"uri_template,omitempty"`
+		MIMEType    string `toml:"mime_type,omitempty"`
+		Text        string `toml:"text,omitempty"`
 	}
 
 	PromptConfig struct {
 		ID          string                 `toml:"id"`
-		Title       string                 `toml:"title"`
-		Description string                 `toml:"description"`
-		Role        string                 `toml:"role"`
-		Template    string                 `toml:"template"`
-		Arguments   []PromptArgumentConfig `toml:"argument"`
+		Title       string                 `toml:"title,omitempty"`
+		Description string                 `toml:"description,omitempty"`
+		Role        string                 `toml:"role,omitempty"`
+		Template    string                 `toml:"template,omitempty"`
+		Arguments   []PromptArgumentConfig `toml:"argument,omitempty"`
 	}
 
 	PromptArgumentConfig struct {
 		Name        string `toml:"name"`
-		Title       string `toml:"title"`
-		Description string `toml:"description"`
-		Required    bool   `toml:"required"`
+		Title       string `toml:"title,omitempty"`
+		Description string `toml:"description,omitempty"`
+		Required    bool   `toml:"required,omitempty"`
+	}
+
+	// CheckConfig is one step of the checks run on a generated project.
+	// Name selects a built-in step unless Run gives the command to run.
+	CheckConfig struct {
+		Name            string   `toml:"name"`
+		Run             []string `toml:"run,omitempty"`
+		Timeout         string   `toml:"timeout,omitempty"`
+		ContinueOnError bool     `toml:"continue_on_error,omitempty"`
+		// MinCoverage is the total statement coverage, in percent, the
+		// coverage step requires.
+		MinCoverage float64 `toml:"min_coverage,omitzero"`
 	}
 )
 
 type Config struct {
-	Server    ServerConfig    `toml:"server"`
-	Tool      *ToolConfig     `toml:"tool"`
-	Resource  *ResourceConfig `toml:"resource"`
-	Prompt    *PromptConfig   `toml:"prompt"`
-	Transport TransportConfig `toml:"transport"`
+	Server    ServerConfig     `toml:"server"`
+	Tools     []ToolConfig     `toml:"tool"`
+	Resources []ResourceConfig `toml:"resource"`
+	Prompts   []PromptConfig   `toml:"prompt"`
+	Transport TransportConfig  `toml:"transport"`
+	Checks    []CheckConfig    `toml:"check,omitempty"`
 }
 
 func (c *Config) Validate() error {
 	errs := c.validateServer()
-	errs = append(errs, c.validateTool()...)
-	errs = append(errs, c.validateResource()...)
-	errs = append(errs, c.validatePrompt()...)
+	errs = append(errs, c.validateTools()...)
+	errs = append(errs, c.validateResources()...)
+	errs = append(errs, c.validatePrompts()...)
+	errs = append(errs, c.validateIdentifiers()...)
 	errs = append(errs, c.validateTransport()...)
+	errs = append(errs, c.validateChecks()...)
 
 	if len(errs) > 0 {
 		return errors.Join(errs...)
@@ -49,24 +49,29 @@ func TestConfig_Validate(t *testing.T) {
 	})
 }
 
-func TestValidateTool(t *testing.T) {
+func TestValidateTools(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
 		name      string
-		tool      *ToolConfig
+		tools     []ToolConfig
 		errsCount int
 	}{
 		{
 			"valid tool",
-			&ToolConfig{ID: "my-tool"},
+			[]ToolConfig{{ID: "my-tool"}},
 			0,
 		},
 		{
 			"missing tool ID",
-			&ToolConfig{ID: ""},
+			[]ToolConfig{{ID: ""}},
 			1,
 		},
+		{
+			"missing IDs are reported per tool",
+			[]ToolConfig{{ID: "my-tool"}, {ID: ""}, {ID: " "}},
+			2,
+		},
 		{
 			"no tool",
 			nil,
@@ -78,9 +83,9 @@ func TestValidateTool(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			cfg := &Config{Tool: tt.tool}
+			cfg := &Config{Tools: tt.tools}
 
-			errs := cfg.validateTool()
+			errs := cfg.validateTools()
 			assert.Len(t, errs, tt.errsCount)
 		})
 	}
@@ -20,6 +20,10 @@ const (
 
 	DefaultOutputDir = "./generated"
 
+	// HTTPPortEnv is the environment variable that overrides the port of
+	// a generated HTTP server.
+	HTTPPortEnv = "MCP_HTTP_PORT"
+
 	DefaultToolID     = "greet"
 	DefaultPromptID   = "welcome"
 	DefaultResourceID = "readme"
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Update edits raw, the TOML of a config, so it decodes to cfg. The tables
+// of entities cfg no longer has are cut out and those of entities it adds
+// are appended; comments and formatting of the rest of the file are kept.
+// ok is false when cfg differs from raw in anything else, such as a changed
+// server field or entity, and the file has to be rewritten with Encode.
+// cfg is expected to be validated.
+func Update(raw []byte, cfg *Config) (updated []byte, ok bool, err error) {
+	want, err := Encode(cfg)
+	if err != nil {
+		return nil, false, err
+	}
+
+	prev, err := Decode(string(raw))
+	if err != nil {
+		return nil, false, nil
+	}
+
+	updated = raw
+	for _, kind := range []string{KindTool, KindPrompt, KindResource} {
+		for _, id := range ids(prev, kind) {
+			if cfg.Has(kind, id) {
+				continue
+			}
+			if updated, err = cutTable(updated, kind, id); err != nil {
+				return nil, false, nil
+			}
+		}
+
+		for _, id := range ids(cfg, kind) {
+			if prev.Has(kind, id) {
+				continue
+			}
+			if updated, err = appendTable(updated, kind, cfg.entity(kind, id)); err != nil {
+				return nil, false, err
+			}
+		}
+	}
+
+	got, err := Decode(string(updated))
+	if err != nil || got.Validate() != nil {
+		return nil, false, nil
+	}
+
+	encoded, err := Encode(got)
+	if err != nil || !bytes.Equal(encoded, want) {
+		return nil, false, nil
+	}
+	return updated, true, nil
+}
+
+// ids returns the IDs of the entities of kind, in config order.
+func ids(c *Config, kind string) []string {
+	var out []string
+	switch kind {
+	case KindTool:
+		for _, t := range c.Tools {
+			out = append(out, t.ID)
+		}
+	case KindPrompt:
+		for _, p := range c.Prompts {
+			out = append(out, p.ID)
+		}
+	case KindResource:
+		for _, r := range c.Resources {
+			out = append(out, r.ID)
+		}
+	}
+	return out
+}
+
+// entity returns the config of the entity of kind with id.
+func (c *Config) entity(kind, id string) any {
+	switch kind {
+	case KindTool:
+		return c.Tools[indexOf(c.Tools, id, func(t ToolConfig) string { return t.ID })]
+	case KindPrompt:
+		return c.Prompts[indexOf(c.Prompts, id, func(p PromptConfig) string { return p.ID })]
+	default:
+		return c.Resources[indexOf(c.Resources, id, func(r ResourceConfig) string { return r.ID })]
+	}
+}
+
+// appendTable appends entity to raw as a [[kind]] table.
+func appendTable(raw []byte, kind string, entity any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	if err := enc.Encode(map[string]any{kind: []any{entity}}); err != nil {
+		return nil, fmt.Errorf("could not encode %s: %w", kind, err)
+	}
+
+	out := bytes.TrimRight(raw, "\n")
+	if len(out) > 0 {
+		out = append(out, "\n\n"...)
+	}
+	return append(out, buf.Bytes()...), nil
+}
+
+// cutTable removes the [[kind]] table whose id is id from raw, along with
+// its sub-tables and the comment lines right above it.
+func cutTable(raw []byte, kind, id string) ([]byte, error) {
+	lines := strings.SplitAfter(string(raw), "\n")
+	headers := tableHeaders(lines)
+
+	for i, h := range headers {
+		if tableName(lines[h]) != "[["+kind+"]]" {
+			continue
+		}
+
+		end := len(lines)
+		for _, next := range headers[i+1:] {
+			name := strings.Trim(tableName(lines[next]), "[]")
+			if !strings.HasPrefix(name, kind+".") {
+				end = next
+				break
+			}
+		}
+
+		var table map[string][]map[string]any
+		if _, err := toml.Decode(strings.Join(lines[h:end], ""), &table); err != nil {
+			return nil, fmt.Errorf("could not decode [[%s]] table: %w", kind, err)
+		}
+		if entities := table[kind]; len(entities) != 1 || entities[0]["id"] != id {
+			continue
+		}
+
+		start := h
+		for start > 0 && strings.HasPrefix(strings.TrimSpace(lines[start-1]), "#") {
+			start--
+		}
+		for end < len(lines) && strings.TrimSpace(lines[end]) == "" {
+			end++
+		}
+		// the last table takes the blank lines above it along
+		if end == len(lines) {
+			for start > 0 && strings.TrimSpace(lines[start-1]) == "" {
+				start--
+			}
+		}
+
+		out := strings.Join(lines[:start], "") + strings.Join(lines[end:], "")
+		return []byte(out), nil
+	}
+	return nil, fmt.Errorf("%w: no [[%s]] table with id %q", ErrEntityNotFound, kind, id)
+}
+
+// tableHeaders returns the indexes of the lines that start a table,
+// skipping those inside multi-line strings.
+func tableHeaders(lines []string) []int {
+	var (
+		headers []int
+		inside  string
+	)
+	for i, line := range lines {
+		if inside == "" && strings.HasPrefix(strings.TrimSpace(line), "[") {
+			headers = append(headers, i)
+			continue
+		}
+
+		for _, delim := range []string{`"""`, `'''`} {
+			if inside != "" && inside != delim {
+				continue
+			}
+			if strings.Count(line, delim)%2 == 1 {
+				if inside == "" {
+					inside = delim
+				} else {
+					inside = ""
+				}
+			}
+		}
+	}
+	return headers
+}
+
+// tableName returns the header of a table line without spaces or a
+// trailing comment, such as "[[tool]]".
+func tableName(line string) string {
+	name, _, _ := strings.Cut(line, "#")
+	return strings.ReplaceAll(strings.TrimSpace(name), " ", "")
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	const raw = `# Weather server.
+[server]
+name = "weather" # shown to clients
+
+# The main tool.
+[[tool]]
+id = "forecast"
+input_schema = """
+{
+  "type": "object"
+}
+"""
+
+[[prompt]]
+id = "report"
+
+[[prompt.argument]]
+name = "city"
+required = true
+
+# Kept around for later.
+[[prompt]]
+id = "summary"
+`
+
+	decode := func(t *testing.T, content string) *Config {
+		t.Helper()
+
+		cfg, err := Decode(content)
+		require.NoError(t, err)
+		require.NoError(t, cfg.Validate())
+		return cfg
+	}
+
+	t.Run("adds and removes entities in place", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := decode(t, raw)
+		require.NoError(t, cfg.Remove(KindPrompt, "report"))
+		require.NoError(t, cfg.Remove(KindPrompt, "summary"))
+		cfg.Tools = append(cfg.Tools, ToolConfig{ID: "alerts", Title: "Alerts"})
+		require.NoError(t, cfg.Validate())
+
+		updated, ok, err := Update([]byte(raw), cfg)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		assert.Equal(t, `# Weather server.
+[server]
+name = "weather" # shown to clients
+
+# The main tool.
+[[tool]]
+id = "forecast"
+input_schema = """
+{
+  "type": "object"
+}
+"""
+
+[[tool]]
+id = "alerts"
+title = "Alerts"
+description = "Tool stub for alerts."
+input_schema = "{\"type\":\"object\"}"
+output_schema = "{\"type\":\"object\"}"
+`, string(updated))
+	})
+
+	t.Run("unchanged config", func(t *testing.T) {
+		t.Parallel()
+
+		updated, ok, err := Update([]byte(raw), decode(t, raw))
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, raw, string(updated))
+	})
+
+	t.Run("other changes need a rewrite", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := decode(t, raw)
+		cfg.Server.Title = "Weather"
+
+		_, ok, err := Update([]byte(raw), cfg)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,61 @@
+package config
+
+import "fmt"
+
+// Entity kinds, as used by the config tables and the add/remove commands.
+const (
+	KindTool     = "tool"
+	KindPrompt   = "prompt"
+	KindResource = "resource"
+)
+
+// Has reports whether an entity of kind with id is configured.
+func (c *Config) Has(kind, id string) bool {
+	switch kind {
+	case KindTool:
+		return indexOf(c.Tools, id, func(t ToolConfig) string { return t.ID }) >= 0
+	case KindPrompt:
+		return indexOf(c.Prompts, id, func(p PromptConfig) string { return p.ID }) >= 0
+	case KindResource:
+		return indexOf(c.Resources, id, func(r ResourceConfig) string { return r.ID }) >= 0
+	default:
+		return false
+	}
+}
+
+// Remove deletes the entity of kind with id from the config.
+func (c *Config) Remove(kind, id string) error {
+	var removed bool
+	switch kind {
+	case KindTool:
+		c.Tools, removed = removeByID(c.Tools, id, func(t ToolConfig) string { return t.ID })
+	case KindPrompt:
+		c.Prompts, removed = removeByID(c.Prompts, id, func(p PromptConfig) string { return p.ID })
+	case KindResource:
+		c.Resources, removed = removeByID(c.Resources, id, func(r ResourceConfig) string { return r.ID })
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownKind, kind)
+	}
+
+	if !removed {
+		return fmt.Errorf("%w: %s %q", ErrEntityNotFound, kind, id)
+	}
+	return nil
+}
+
+func indexOf[T any](items []T, id string, idOf func(T) string) int {
+	for i, item := range items {
+		if idOf(item) == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func removeByID[T any](items []T, id string, idOf func(T) string) ([]T, bool) {
+	i := indexOf(items, id, idOf)
+	if i < 0 {
+		return items, false
+	}
+	return append(items[:i:i], items[i+1:]...), true
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Remove(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{
+		Tools:     []ToolConfig{{ID: "search"}, {ID: "fetch"}},
+		Prompts:   []PromptConfig{{ID: "review"}},
+		Resources: []ResourceConfig{{ID: "docs"}},
+	}
+
+	assert.True(t, cfg.Has(KindTool, "fetch"))
+	assert.False(t, cfg.Has(KindPrompt, "fetch"))
+	assert.False(t, cfg.Has("widget", "fetch"))
+
+	require.NoError(t, cfg.Remove(KindTool, "search"))
+	assert.Equal(t, []ToolConfig{{ID: "fetch"}}, cfg.Tools)
+
+	require.NoError(t, cfg.Remove(KindPrompt, "review"))
+	assert.Empty(t, cfg.Prompts)
+
+	require.NoError(t, cfg.Remove(KindResource, "docs"))
+	assert.Empty(t, cfg.Resources)
+
+	err := cfg.Remove(KindTool, "search")
+	assert.ErrorIs(t, err, ErrEntityNotFound)
+
+	err = cfg.Remove("widget", "search")
+	assert.ErrorIs(t, err, ErrUnknownKind)
+}
@@ -8,4 +8,12 @@ var (
 	ErrTransportTypeInvalid = errors.New("transport type is invalid")
 	ErrTransportPortInvalid = errors.New("transport port is out of range")
 	ErrURIMissingScheme     = errors.New("uri is missing scheme")
+	ErrConfigUnknownKeys    = errors.New("config has unknown keys")
+	ErrDuplicateName        = errors.New("duplicate name")
+	ErrIdentifierCollision  = errors.New("generated Go identifiers collide")
+	ErrReservedIdentifier   = errors.New("reserved Go identifier")
+	ErrSchemaInvalid        = errors.New("invalid JSON schema")
+	ErrUnknownKind          = errors.New("unknown entity kind")
+	ErrEntityNotFound       = errors.New("entity not found")
+	ErrCheckInvalid         = errors.New("check is invalid")
 )
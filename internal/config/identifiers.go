@@ -0,0 +1,246 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"go/types"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/alesr/mcpgen/internal/pkg/utils"
+)
+
+// entityRef names a configured entity in error messages.
+type entityRef struct {
+	kind string
+	id   string
+}
+
+func (e entityRef) String() string {
+	return fmt.Sprintf("%s %q", e.kind, e.id)
+}
+
+// validateIdentifiers checks that names are unique per kind and that the
+// Go identifiers derived from them do not clash in the generated packages.
+// It runs after the per-entity checks, so IDs with errors are skipped.
+func (c *Config) validateIdentifiers() []error {
+	errs := make([]error, 0)
+	errs = append(errs, c.validateUniqueNames()...)
+	errs = append(errs, c.validateGoNames()...)
+	errs = append(errs, c.validateFieldNames()...)
+	return errs
+}
+
+func (c *Config) validateUniqueNames() []error {
+	errs := make([]error, 0)
+
+	toolIDs := make([]string, 0, len(c.Tools))
+	for _, t := range c.Tools {
+		toolIDs = append(toolIDs, t.ID)
+	}
+	errs = append(errs, duplicates("tool", "name", toolIDs)...)
+
+	resourceIDs := make([]string, 0, len(c.Resources))
+	uris := make([]string, 0, len(c.Resources))
+	for _, r := range c.Resources {
+		resourceIDs = append(resourceIDs, r.ID)
+		uris = append(uris, utils.DefaultIfEmpty(r.URI, r.URITemplate))
+	}
+	errs = append(errs, duplicates("resource", "name", resourceIDs)...)
+	errs = append(errs, duplicates("resource", "uri", uris)...)
+
+	promptIDs := make([]string, 0, len(c.Prompts))
+	for _, p := range c.Prompts {
+		promptIDs = append(promptIDs, p.ID)
+
+		argNames := make([]string, 0, len(p.Arguments))
+		for _, arg := range p.Arguments {
+			argNames = append(argNames, arg.Name)
+		}
+		errs = append(errs, duplicates(fmt.Sprintf("prompt %q argument", p.ID), "name", argNames)...)
+	}
+	errs = append(errs, duplicates("prompt", "name", promptIDs)...)
+
+	return errs
+}
+
+// duplicates reports each non-blank value that appears more than once.
+func duplicates(kind, field string, values []string) []error {
+	errs := make([]error, 0)
+	seen := make(map[string]int, len(values))
+
+	for _, v := range values {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+
+		seen[v]++
+		if seen[v] == 2 {
+			errs = append(errs, fmt.Errorf("%w: %s %s %q is declared more than once", ErrDuplicateName, kind, field, v))
+		}
+	}
+	return errs
+}
+
+// validateGoNames mirrors the symbols the templates declare for each entity
+// and reports any symbol that two entities would declare in the same package.
+func (c *Config) validateGoNames() []error {
+	errs := make([]error, 0)
+
+	packages := map[string]map[string]entityRef{
+		"tools":     {},
+		"handlers":  {},
+		"prompts":   {},
+		"resources": {},
+	}
+
+	reported := make(map[[2]entityRef]bool)
+
+	declare := func(pkg, symbol string, owner entityRef) {
+		prev, ok := packages[pkg][symbol]
+		if !ok {
+			packages[pkg][symbol] = owner
+			return
+		}
+
+		pair := [2]entityRef{prev, owner}
+		if prev == owner || reported[pair] {
+			return
+		}
+		reported[pair] = true
+
+		errs = append(errs, fmt.Errorf(
+			"%w: %s and %s both generate %s in package %s",
+			ErrIdentifierCollision, prev, owner, symbol, pkg,
+		))
+	}
+
+	// the Go name of an entity only appears with a prefix or suffix, so
+	// unlike a field name it cannot end up a keyword on its own
+	checkIdent := func(owner entityRef) (string, bool) {
+		if strings.TrimSpace(owner.id) == "" {
+			return "", false
+		}
+		return utils.GoIdent(owner.id), true
+	}
+
+	for _, t := range c.Tools {
+		owner := entityRef{kind: "tool", id: t.ID}
+		goName, ok := checkIdent(owner)
+		if !ok {
+			continue
+		}
+
+		declare("tools", "ToolName"+goName, owner)
+		declare("tools", "Tool"+goName, owner)
+		declare("handlers", "Handle"+goName, owner)
+		declare("handlers", "ToolNameFallback"+goName, owner)
+		declare("handlers", goName+"Input", owner)
+		declare("handlers", goName+"Output", owner)
+	}
+
+	for _, p := range c.Prompts {
+		owner := entityRef{kind: "prompt", id: p.ID}
+		goName, ok := checkIdent(owner)
+		if !ok {
+			continue
+		}
+
+		declare("prompts", "PromptName"+goName, owner)
+		declare("prompts", "Prompt"+goName, owner)
+		declare("prompts", "promptTemplate"+goName, owner)
+		declare("prompts", "HandlePrompt"+goName, owner)
+	}
+
+	for _, r := range c.Resources {
+		owner := entityRef{kind: "resource", id: r.ID}
+		goName, ok := checkIdent(owner)
+		if !ok {
+			continue
+		}
+
+		declare("resources", "ResourceName"+goName, owner)
+		if r.URITemplate != "" {
+			declare("resources", "ResourceTemplate"+goName, owner)
+		} else {
+			declare("resources", "Resource"+goName, owner)
+		}
+		declare("resources", "HandleResource"+goName, owner)
+	}
+
+	return errs
+}
+
+// validateFieldNames checks the Go field names the properties of the tool
+// schemas map to. Unlike entity names, which always get a prefix or suffix,
+// a field name stands on its own. Schemas that are not valid JSON were
+// already reported and are skipped.
+func (c *Config) validateFieldNames() []error {
+	errs := make([]error, 0)
+
+	for _, t := range c.Tools {
+		owner := entityRef{kind: "tool", id: t.ID}
+		for _, s := range []struct{ label, raw string }{
+			{"input_schema", t.InputSchema},
+			{"output_schema", t.OutputSchema},
+		} {
+			var schema any
+			if err := json.Unmarshal([]byte(s.raw), &schema); err != nil {
+				continue
+			}
+			for _, msg := range fieldNameProblems(schema) {
+				errs = append(errs, fmt.Errorf("%w: %s %s: %s", ErrReservedIdentifier, owner, s.label, msg))
+			}
+		}
+	}
+	return errs
+}
+
+// fieldNameProblems walks a schema and returns the problems of the names
+// of every "properties" object in it, in a stable order.
+func fieldNameProblems(node any) []string {
+	problems := make([]string, 0)
+
+	switch n := node.(type) {
+	case map[string]any:
+		for _, k := range slices.Sorted(maps.Keys(n)) {
+			props, ok := n[k].(map[string]any)
+			if k != "properties" || !ok {
+				problems = append(problems, fieldNameProblems(n[k])...)
+				continue
+			}
+			// the keys of properties are names, only its values are schemas
+			for _, key := range slices.Sorted(maps.Keys(props)) {
+				if msg := fieldNameProblem(key); msg != "" {
+					problems = append(problems, msg)
+				}
+				problems = append(problems, fieldNameProblems(props[key])...)
+			}
+		}
+	case []any:
+		for _, v := range n {
+			problems = append(problems, fieldNameProblems(v)...)
+		}
+	}
+	return problems
+}
+
+// fieldNameProblem returns why the Go field of the schema property key
+// would not compile or would be skipped by encoding/json, such as "ütype"
+// becoming the keyword type, or "" when the field is usable.
+func fieldNameProblem(key string) string {
+	field := utils.GoIdent(key)
+	switch {
+	case token.IsKeyword(field):
+		return fmt.Sprintf("property %q becomes field %q, a Go keyword", key, field)
+	case !token.IsIdentifier(field):
+		return fmt.Sprintf("property %q becomes field %q, not a valid Go identifier", key, field)
+	case types.Universe.Lookup(field) != nil:
+		return fmt.Sprintf("property %q becomes field %q, a predeclared Go identifier", key, field)
+	case !token.IsExported(field):
+		return fmt.Sprintf("property %q becomes field %q, unexported and skipped by encoding/json", key, field)
+	}
+	return ""
+}
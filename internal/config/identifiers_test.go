@@ -0,0 +1,157 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateIdentifiers(t *testing.T) {
+	t.Parallel()
+
+	base := func() *Config {
+		return &Config{
+			Server:    ServerConfig{Name: "weather", Module: "example.com/weather"},
+			Transport: TransportConfig{Type: "stdio", HTTPPort: DefaultHTTPPort},
+		}
+	}
+
+	t.Run("distinct entities are valid", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := base()
+		cfg.Tools = []ToolConfig{{ID: "search"}, {ID: "fetch"}}
+		cfg.Prompts = []PromptConfig{{ID: "search"}}
+		cfg.Resources = []ResourceConfig{{ID: "search", URI: "file:///search"}}
+
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("schema properties that become reserved field names", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := base()
+		cfg.Tools = []ToolConfig{{
+			ID:          "search",
+			InputSchema: `{"type":"object","properties":{"query":{"type":"string"},"üfunc":{"type":"string"}}}`,
+		}, {
+			ID:           "fetch",
+			OutputSchema: `{"type":"object","$defs":{"page":{"type":"object","properties":{"ülen":{"type":"integer"}}}}}`,
+		}}
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrReservedIdentifier)
+		assert.Contains(t, err.Error(), `reserved Go identifier: tool "search" input_schema: property "üfunc" becomes field "func", a Go keyword`)
+		assert.Contains(t, err.Error(), `tool "fetch" output_schema: property "ülen" becomes field "len", a predeclared Go identifier`)
+	})
+
+	t.Run("duplicate names per kind", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := base()
+		cfg.Tools = []ToolConfig{{ID: "search"}, {ID: "search"}, {ID: "search"}}
+		cfg.Resources = []ResourceConfig{
+			{ID: "docs", URI: "file:///docs"},
+			{ID: "more-docs", URI: "file:///docs"},
+		}
+		cfg.Prompts = []PromptConfig{{
+			ID:        "review",
+			Arguments: []PromptArgumentConfig{{Name: "code"}, {Name: "code"}},
+		}}
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrDuplicateName)
+		assert.NotErrorIs(t, err, ErrIdentifierCollision)
+		assert.Contains(t, err.Error(), `tool name "search" is declared more than once`)
+		assert.Contains(t, err.Error(), `resource uri "file:///docs" is declared more than once`)
+		assert.Contains(t, err.Error(), `prompt "review" argument name "code" is declared more than once`)
+		assert.Equal(t, 1, strings.Count(err.Error(), `tool name "search"`))
+	})
+
+	t.Run("ids mapping to the same go name", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := base()
+		cfg.Tools = []ToolConfig{{ID: "get-user"}, {ID: "get_user"}, {ID: "GetUser"}}
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrIdentifierCollision)
+		assert.Contains(t, err.Error(), `tool "get-user" and tool "get_user" both generate ToolNameGetUser in package tools`)
+		assert.Contains(t, err.Error(), `tool "get-user" and tool "GetUser" both generate`)
+	})
+
+	t.Run("prefixed symbols collide across entities", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := base()
+		cfg.Prompts = []PromptConfig{{ID: "x"}, {ID: "name-x"}}
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrIdentifierCollision)
+		assert.Contains(t, err.Error(), `prompt "x" and prompt "name-x" both generate PromptNameX in package prompts`)
+	})
+
+	t.Run("numeric prefix collision", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := base()
+		cfg.Resources = []ResourceConfig{
+			{ID: "1", URI: "file:///one"},
+			{ID: "n1", URI: "file:///n1"},
+		}
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrIdentifierCollision)
+	})
+
+	t.Run("all problems are reported together", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := base()
+		cfg.Server.Name = ""
+		cfg.Tools = []ToolConfig{{ID: "a-b"}, {ID: "a_b"}}
+		cfg.Prompts = []PromptConfig{{ID: "p"}, {ID: "p"}}
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrServerNameRequired)
+		assert.ErrorIs(t, err, ErrIdentifierCollision)
+		assert.ErrorIs(t, err, ErrDuplicateName)
+	})
+}
+
+func TestFieldNameProblem(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		given   string
+		wantErr string
+	}{
+		{"exported field", "user_id", ""},
+		{"keyword", "ütype", "a Go keyword"},
+		{"predeclared type", "üstring", "a predeclared Go identifier"},
+		{"predeclared function", "ülen", "a predeclared Go identifier"},
+		{"unexported field", "über", "unexported"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := fieldNameProblem(tt.given)
+			if tt.wantErr == "" {
+				assert.Empty(t, got)
+				return
+			}
+			assert.Contains(t, got, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ProjectFile is where a generated project keeps the config it was
+// generated from, relative to the output directory.
+const ProjectFile = "mcpgen.toml"
+
+// Load reads an mcpgen.toml file into a Config.
+// Defaults are not applied here; callers run Validate after any overrides.
+func Load(path string) (*Config, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file: %w", err)
+	}
+	return Decode(string(raw))
+}
+
+// Decode parses TOML config content and rejects unknown keys,
+// so typos in the file surface instead of being silently ignored.
+func Decode(content string) (*Config, error) {
+	var cfg Config
+
+	md, err := toml.Decode(content, &cfg)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode config: %w", err)
+	}
+
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, 0, len(undecoded))
+		for _, k := range undecoded {
+			keys = append(keys, k.String())
+		}
+		return nil, fmt.Errorf("%w: %s", ErrConfigUnknownKeys, strings.Join(keys, ", "))
+	}
+	return &cfg, nil
+}
+
+// Encode renders a Config as TOML that Decode reads back unchanged.
+// Empty entity lists are left out rather than written as empty arrays, so
+// tables appended later do not clash with them.
+func Encode(cfg *Config) ([]byte, error) {
+	var buf bytes.Buffer
+
+	c := *cfg
+	if len(c.Tools) == 0 {
+		c.Tools = nil
+	}
+	if len(c.Resources) == 0 {
+		c.Resources = nil
+	}
+	if len(c.Prompts) == 0 {
+		c.Prompts = nil
+	}
+
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	if err := enc.Encode(&c); err != nil {
+		return nil, fmt.Errorf("could not encode config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
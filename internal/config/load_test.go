@@ -0,0 +1,120 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	t.Run("decodes file and validates with defaults", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "mcpgen.toml")
+		content := `
+[server]
+name = "weather"
+
+[transport]
+type = "http"
+http_port = 9090
+
+[[tool]]
+id = "forecast"
+input_schema = '{"type":"object","properties":{"city":{"type":"string"}}}'
+
+[[tool]]
+id = "alerts"
+
+[[resource]]
+id = "docs"
+uri_template = "docs://{id}"
+
+[[prompt]]
+id = "summary"
+template = "Summarize {{.topic}}"
+
+[[prompt.argument]]
+name = "topic"
+required = true
+`
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+		cfg, err := Load(path)
+		require.NoError(t, err)
+		require.NoError(t, cfg.Validate())
+
+		assert.Equal(t, "weather", cfg.Server.Name)
+		assert.Equal(t, "example.com/weather", cfg.Server.Module)
+		assert.Equal(t, "http", cfg.Transport.Type)
+		assert.Equal(t, 9090, cfg.Transport.HTTPPort)
+
+		require.Len(t, cfg.Tools, 2)
+		assert.Equal(t, "forecast", cfg.Tools[0].ID)
+		assert.Equal(t, "Forecast", cfg.Tools[0].Title)
+		assert.Equal(t, defaultJSONSchemaObject, cfg.Tools[0].OutputSchema)
+		assert.Equal(t, "alerts", cfg.Tools[1].ID)
+
+		require.Len(t, cfg.Resources, 1)
+		assert.Equal(t, "docs://{id}", cfg.Resources[0].URITemplate)
+
+		require.Len(t, cfg.Prompts, 1)
+		require.Len(t, cfg.Prompts[0].Arguments, 1)
+		assert.True(t, cfg.Prompts[0].Arguments[0].Required)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Load(filepath.Join(t.TempDir(), "missing.toml"))
+		require.Error(t, err)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("unknown keys are rejected", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Decode("[server]\nnmae = \"typo\"\n")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrConfigUnknownKeys)
+		assert.Contains(t, err.Error(), "server.nmae")
+	})
+
+	t.Run("invalid toml", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Decode("[server\n")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "could not decode config")
+	})
+
+	t.Run("encode round trips", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := &Config{
+			Server: ServerConfig{Name: "weather"},
+			Tools:  []ToolConfig{{ID: "forecast", InputSchema: `{"type":"object","properties":{"city":{"type":"string"}}}`}},
+			Prompts: []PromptConfig{{
+				ID:        "summary",
+				Template:  "Summarize {{.topic}}\nBriefly.",
+				Arguments: []PromptArgumentConfig{{Name: "topic", Required: true}},
+			}},
+			Checks: []CheckConfig{{Name: "vet", Timeout: "1m"}, {Name: "coverage", MinCoverage: 60}},
+		}
+		require.NoError(t, cfg.Validate())
+
+		raw, err := Encode(cfg)
+		require.NoError(t, err)
+		assert.NotContains(t, string(raw), `uri = ""`, "empty values are left out")
+		assert.NotContains(t, string(raw), `min_coverage = 0`, "empty values are left out")
+
+		decoded, err := Decode(string(raw))
+		require.NoError(t, err)
+		assert.Equal(t, cfg, decoded)
+	})
+}
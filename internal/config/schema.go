@@ -0,0 +1,342 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// schemaURL is the in-memory location tool schemas are compiled from.
+// Only local references resolve against it; there is no URL loader.
+const schemaURL = "mcpgen:///schema.json"
+
+var schemaPrinter = message.NewPrinter(language.English)
+
+// knownSchemaKeywords lists the JSON Schema 2020-12 vocabularies
+// plus "definitions", which is still widely used in tool schemas.
+var knownSchemaKeywords = map[string]bool{
+	// core
+	"$schema": true, "$id": true, "$ref": true, "$anchor": true, "$dynamicRef": true,
+	"$dynamicAnchor": true, "$vocabulary": true, "$comment": true, "$defs": true,
+	"definitions": true,
+	// applicator
+	"prefixItems": true, "items": true, "contains": true, "additionalProperties": true,
+	"properties": true, "patternProperties": true, "dependentSchemas": true,
+	"propertyNames": true, "if": true, "then": true, "else": true,
+	"allOf": true, "anyOf": true, "oneOf": true, "not": true,
+	// unevaluated
+	"unevaluatedItems": true, "unevaluatedProperties": true,
+	// validation
+	"type": true, "const": true, "enum": true, "multipleOf": true, "maximum": true,
+	"exclusiveMaximum": true, "minimum": true, "exclusiveMinimum": true,
+	"maxLength": true, "minLength": true, "pattern": true, "maxItems": true,
+	"minItems": true, "uniqueItems": true, "maxContains": true, "minContains": true,
+	"maxProperties": true, "minProperties": true, "required": true, "dependentRequired": true,
+	// meta-data
+	"title": true, "description": true, "default": true, "deprecated": true,
+	"readOnly": true, "writeOnly": true, "examples": true,
+	// format and content
+	"format": true, "contentEncoding": true, "contentMediaType": true, "contentSchema": true,
+}
+
+var (
+	schemaMapKeywords    = []string{"properties", "patternProperties", "$defs", "definitions", "dependentSchemas"}
+	schemaArrayKeywords  = []string{"prefixItems", "allOf", "anyOf", "oneOf"}
+	schemaSingleKeywords = []string{
+		"items", "contains", "additionalProperties", "propertyNames", "if", "then", "else",
+		"not", "unevaluatedItems", "unevaluatedProperties", "contentSchema",
+	}
+)
+
+// validateSchemaObject checks a tool schema against the JSON Schema 2020-12
+// meta-schema, resolves its references and reports unknown keywords.
+// Every problem names the JSON pointer it was found at.
+func validateSchemaObject(raw string, label string) error {
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(raw), &obj); err != nil {
+		return fmt.Errorf("%s must be valid JSON: %w", label, err)
+	}
+
+	if t, ok := obj["type"]; ok && t != "object" {
+		return fmt.Errorf("%s must have type=object", label)
+	}
+
+	problems := lintSchema(obj)
+	problems = append(problems, compileSchema(raw, obj)...)
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	errs := make([]error, 0, len(problems))
+	for _, p := range problems {
+		errs = append(errs, fmt.Errorf("%w: %s at %s: %s", ErrSchemaInvalid, label, p.pointer(), p.message))
+	}
+	return errors.Join(errs...)
+}
+
+type schemaProblem struct {
+	location []string
+	message  string
+}
+
+// pointer renders the location as a URI fragment JSON pointer, e.g. "#/properties/a".
+func (p schemaProblem) pointer() string {
+	var b strings.Builder
+	b.WriteString("#")
+	for _, tok := range p.location {
+		tok = strings.ReplaceAll(tok, "~", "~0")
+		tok = strings.ReplaceAll(tok, "/", "~1")
+		b.WriteString("/" + tok)
+	}
+	return b.String()
+}
+
+// lintSchema walks subschemas looking for what the meta-schema allows but is
+// almost always a mistake: unknown (often misspelled) keywords and required
+// properties missing from "properties".
+func lintSchema(node any, path ...string) []schemaProblem {
+	obj, ok := node.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	problems := make([]schemaProblem, 0)
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if !knownSchemaKeywords[k] && !strings.HasPrefix(k, "x-") {
+			problems = append(problems, schemaProblem{
+				location: appendPath(path, k),
+				message:  fmt.Sprintf("unknown keyword %q", k),
+			})
+		}
+	}
+
+	if props, ok := obj["properties"].(map[string]any); ok {
+		for _, key := range slices.Sorted(maps.Keys(props)) {
+			if !validTagName(key) {
+				problems = append(problems, schemaProblem{
+					location: appendPath(path, "properties", key),
+					message:  fmt.Sprintf("property %q cannot be a json tag name (empty, or a comma, quote, backslash or backtick in it)", key),
+				})
+			}
+		}
+
+		if required, ok := obj["required"].([]any); ok {
+			for i, r := range required {
+				name, ok := r.(string)
+				if !ok {
+					continue
+				}
+				if _, declared := props[name]; !declared {
+					problems = append(problems, schemaProblem{
+						location: appendPath(path, "required", fmt.Sprint(i)),
+						message:  fmt.Sprintf("required property %q is not declared in properties", name),
+					})
+				}
+			}
+		}
+	}
+
+	for _, kw := range schemaMapKeywords {
+		sub, ok := obj[kw].(map[string]any)
+		if !ok {
+			continue
+		}
+		names := make([]string, 0, len(sub))
+		for name := range sub {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			problems = append(problems, lintSchema(sub[name], appendPath(path, kw, name)...)...)
+		}
+	}
+
+	for _, kw := range schemaArrayKeywords {
+		sub, ok := obj[kw].([]any)
+		if !ok {
+			continue
+		}
+		for i, s := range sub {
+			problems = append(problems, lintSchema(s, appendPath(path, kw, fmt.Sprint(i))...)...)
+		}
+	}
+
+	for _, kw := range schemaSingleKeywords {
+		if sub, ok := obj[kw]; ok {
+			problems = append(problems, lintSchema(sub, appendPath(path, kw)...)...)
+		}
+	}
+
+	return problems
+}
+
+// validTagName reports whether encoding/json accepts key as the name in
+// the json tag of the generated struct field, mirroring its isValidTag.
+// A comma would end the name, and quotes and backticks the tag itself.
+func validTagName(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, c := range key {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
+
+// compileSchema validates the document against the 2020-12 meta-schema
+// and resolves every $ref. Remote references are rejected.
+func compileSchema(raw string, doc map[string]any) []schemaProblem {
+	inst, err := jsonschema.UnmarshalJSON(strings.NewReader(raw))
+	if err != nil {
+		return []schemaProblem{{message: err.Error()}}
+	}
+
+	c := jsonschema.NewCompiler()
+	c.DefaultDraft(jsonschema.Draft2020)
+	c.UseLoader(nil)
+
+	if err := c.AddResource(schemaURL, inst); err != nil {
+		return []schemaProblem{{message: err.Error()}}
+	}
+
+	_, err = c.Compile(schemaURL)
+	if err == nil {
+		return nil
+	}
+
+	var (
+		metaErr     *jsonschema.SchemaValidationError
+		ptrErr      *jsonschema.JSONPointerNotFoundError
+		anchorErr   *jsonschema.AnchorNotFoundError
+		loadErr     *jsonschema.LoadURLError
+		validateErr *jsonschema.ValidationError
+	)
+
+	switch {
+	case errors.As(err, &metaErr) && errors.As(metaErr.Err, &validateErr):
+		return metaSchemaProblems(validateErr)
+	case errors.As(err, &ptrErr):
+		return refProblems(doc, ptrErr.URL, "does not resolve")
+	case errors.As(err, &anchorErr):
+		return refProblems(doc, anchorErr.Reference, "does not resolve")
+	case errors.As(err, &loadErr):
+		return refProblems(doc, loadErr.URL, "is remote; only local references are supported")
+	default:
+		return []schemaProblem{{message: err.Error()}}
+	}
+}
+
+// metaSchemaProblems flattens the meta-schema error tree into one message
+// per instance location, keeping the first (most specific) leaf.
+func metaSchemaProblems(root *jsonschema.ValidationError) []schemaProblem {
+	problems := make([]schemaProblem, 0)
+	seen := make(map[string]bool)
+
+	var walk func(e *jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			key := strings.Join(e.InstanceLocation, "\x00")
+			if seen[key] {
+				return
+			}
+			seen[key] = true
+			problems = append(problems, schemaProblem{
+				location: e.InstanceLocation,
+				message:  e.ErrorKind.LocalizedString(schemaPrinter),
+			})
+			return
+		}
+		for _, c := range e.Causes {
+			walk(c)
+		}
+	}
+	walk(root)
+	return problems
+}
+
+// refProblems locates the $ref keywords that point at target.
+func refProblems(doc map[string]any, target, reason string) []schemaProblem {
+	ref := strings.TrimPrefix(target, schemaURL)
+	locations := findRefs(doc, resolveRef(target), nil)
+	if len(locations) == 0 {
+		return []schemaProblem{{message: fmt.Sprintf("$ref %q %s", ref, reason)}}
+	}
+
+	problems := make([]schemaProblem, 0, len(locations))
+	for _, loc := range locations {
+		problems = append(problems, schemaProblem{
+			location: loc,
+			message:  fmt.Sprintf("$ref %q %s", ref, reason),
+		})
+	}
+	return problems
+}
+
+// findRefs returns the paths of the $ref keywords under node that resolve
+// to target, a reference as returned by resolveRef.
+func findRefs(node any, target string, path []string) [][]string {
+	found := make([][]string, 0)
+
+	switch v := node.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if k == "$ref" {
+				if s, ok := v[k].(string); ok && resolveRef(s) == target {
+					found = append(found, appendPath(path, k))
+				}
+				continue
+			}
+			found = append(found, findRefs(v[k], target, appendPath(path, k))...)
+		}
+	case []any:
+		for i, item := range v {
+			found = append(found, findRefs(item, target, appendPath(path, fmt.Sprint(i)))...)
+		}
+	}
+	return found
+}
+
+// resolveRef resolves ref against schemaURL, with its fragment escaped
+// one way, so references to the same JSON pointer or anchor compare
+// equal. A ref that does not parse is returned as is.
+func resolveRef(ref string) string {
+	base, _ := url.Parse(schemaURL)
+	u, err := base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	u.RawFragment = ""
+	return u.String()
+}
+
+func appendPath(path []string, elem ...string) []string {
+	return append(slices.Clone(path), elem...)
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSchemaObject(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		schema   string
+		contains []string
+	}{
+		{
+			name:   "empty object schema",
+			schema: `{"type":"object"}`,
+		},
+		{
+			name: "nested definitions and local refs",
+			schema: `{
+				"type": "object",
+				"properties": {
+					"city": {"type": "string", "minLength": 1},
+					"unit": {"$ref": "#/$defs/unit"},
+					"tags": {"type": "array", "items": {"type": "string"}}
+				},
+				"required": ["city"],
+				"$defs": {"unit": {"enum": ["c", "f"]}},
+				"x-vendor": true
+			}`,
+		},
+		{
+			name:   "invalid json",
+			schema: `{"type":`,
+			contains: []string{
+				"must be valid JSON",
+			},
+		},
+		{
+			name:   "non object root",
+			schema: `{"type":"string"}`,
+			contains: []string{
+				"must have type=object",
+			},
+		},
+		{
+			name:   "bad property type",
+			schema: `{"type":"object","properties":{"city":{"type":"strng"}}}`,
+			contains: []string{
+				"at #/properties/city/type: value must be one of",
+			},
+		},
+		{
+			name:   "required is not an array",
+			schema: `{"type":"object","properties":{"city":{"type":"string"}},"required":"city"}`,
+			contains: []string{
+				"at #/required: got string, want array",
+			},
+		},
+		{
+			name:   "required property not declared",
+			schema: `{"type":"object","properties":{"city":{"type":"string"}},"required":["city","country"]}`,
+			contains: []string{
+				`at #/required/1: required property "country" is not declared in properties`,
+			},
+		},
+		{
+			name:   "misspelled keyword",
+			schema: `{"type":"object","propertes":{"city":{"type":"string","maxLenght":3}}}`,
+			contains: []string{
+				`at #/propertes: unknown keyword "propertes"`,
+			},
+		},
+		{
+			name:   "misspelled nested keyword",
+			schema: `{"type":"object","properties":{"city":{"type":"string","maxLenght":3}}}`,
+			contains: []string{
+				`at #/properties/city/maxLenght: unknown keyword "maxLenght"`,
+			},
+		},
+		{
+			name:   "broken local ref",
+			schema: `{"type":"object","properties":{"unit":{"$ref":"#/$defs/missing"}}}`,
+			contains: []string{
+				`at #/properties/unit/$ref: $ref "#/$defs/missing" does not resolve`,
+			},
+		},
+		{
+			name:   "broken anchor ref",
+			schema: `{"type":"object","properties":{"unit":{"$ref":"#unit"}}}`,
+			contains: []string{
+				`at #/properties/unit/$ref: $ref "#unit" does not resolve`,
+			},
+		},
+		{
+			name:   "property names that break json tags",
+			schema: "{\"type\":\"object\",\"properties\":{\"a,b\":{},\"say \\\"hi\\\"\":{},\"x`y\":{},\"\":{}}}",
+			contains: []string{
+				`at #/properties/a,b: property "a,b" cannot be a json tag name`,
+				`at #/properties/say "hi": property "say \"hi\"" cannot be a json tag name`,
+				"at #/properties/x`y: property \"x`y\" cannot be a json tag name",
+				`at #/properties/: property "" cannot be a json tag name`,
+			},
+		},
+		{
+			name:   "remote ref",
+			schema: `{"type":"object","properties":{"unit":{"$ref":"https://example.com/unit.json"}}}`,
+			contains: []string{
+				`at #/properties/unit/$ref: $ref "https://example.com/unit.json" is remote`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateSchemaObject(tt.schema, "tool search input_schema")
+			if len(tt.contains) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			for _, s := range tt.contains {
+				assert.Contains(t, err.Error(), s)
+			}
+		})
+	}
+}
+
+func TestValidateTool_SchemaErrorsAreReported(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{
+		Server:    ServerConfig{Name: "weather", Module: "example.com/weather"},
+		Transport: TransportConfig{Type: "stdio", HTTPPort: DefaultHTTPPort},
+		Tools: []ToolConfig{{
+			ID:           "search",
+			InputSchema:  `{"type":"object","properties":{"q":{"type":"text"}}}`,
+			OutputSchema: `{"type":"object","requird":["q"]}`,
+		}},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrSchemaInvalid)
+	assert.Contains(t, err.Error(), "tool search input_schema at #/properties/q/type")
+	assert.Contains(t, err.Error(), `tool search output_schema at #/requird: unknown keyword "requird"`)
+}
+
+func TestFindRefs(t *testing.T) {
+	t.Parallel()
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"properties": {
+			"a": {"$ref": "#/$defs/unit"},
+			"b": {"$ref": "unit"},
+			"c": {"$ref": "#/$defs/other/$defs/unit"},
+			"d": {"items": [{"$ref": "#/%24defs/unit"}]},
+			"e": {"$ref": "mcpgen:///schema.json#/$defs/unit"}
+		}
+	}`), &doc))
+
+	got := findRefs(doc, resolveRef(schemaURL+"#/$defs/unit"), nil)
+	assert.Equal(t, [][]string{
+		{"properties", "a", "$ref"},
+		{"properties", "d", "items", "0", "$ref"},
+		{"properties", "e", "$ref"},
+	}, got)
+
+	got = findRefs(doc, resolveRef("mcpgen:///unit"), nil)
+	assert.Equal(t, [][]string{{"properties", "b", "$ref"}}, got)
+}
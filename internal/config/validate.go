@@ -1,11 +1,10 @@
 package config
 
 import (
-	"encoding/json"
-	"errors"
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"golang.org/x/mod/module"
 )
@@ -36,15 +35,18 @@ func (c *Config) validateServer() []error {
 	return errs
 }
 
-func (c *Config) validateTool() []error {
-	if c.Tool == nil {
-		return nil
+func (c *Config) validateTools() []error {
+	errs := make([]error, 0)
+	for i := range c.Tools {
+		errs = append(errs, validateTool(i, &c.Tools[i])...)
 	}
+	return errs
+}
 
+func validateTool(i int, t *ToolConfig) []error {
 	err := make([]error, 0)
-	t := c.Tool
 	if strings.TrimSpace(t.ID) == "" {
-		return []error{errors.New("tool.id is required")}
+		return []error{fmt.Errorf("tool[%d].id is required", i)}
 	}
 
 	if strings.TrimSpace(t.Title) == "" {
@@ -74,15 +76,18 @@ func (c *Config) validateTool() []error {
 	return err
 }
 
-func (c *Config) validateResource() []error {
-	if c.Resource == nil {
-		return nil
+func (c *Config) validateResources() []error {
+	errs := make([]error, 0)
+	for i := range c.Resources {
+		errs = append(errs, validateResource(i, &c.Resources[i])...)
 	}
+	return errs
+}
 
+func validateResource(i int, r *ResourceConfig) []error {
 	err := make([]error, 0)
-	r := c.Resource
 	if strings.TrimSpace(r.ID) == "" {
-		return []error{errors.New("resource.id is required")}
+		return []error{fmt.Errorf("resource[%d].id is required", i)}
 	}
 
 	if strings.TrimSpace(r.Title) == "" {
@@ -114,15 +119,18 @@ func (c *Config) validateResource() []error {
 	return err
 }
 
-func (c *Config) validatePrompt() []error {
-	if c.Prompt == nil {
-		return nil
+func (c *Config) validatePrompts() []error {
+	errs := make([]error, 0)
+	for i := range c.Prompts {
+		errs = append(errs, validatePrompt(i, &c.Prompts[i])...)
 	}
+	return errs
+}
 
+func validatePrompt(i int, p *PromptConfig) []error {
 	err := make([]error, 0)
-	p := c.Prompt
 	if strings.TrimSpace(p.ID) == "" {
-		return []error{errors.New("prompt.id is required")}
+		return []error{fmt.Errorf("prompt[%d].id is required", i)}
 	}
 
 	if strings.TrimSpace(p.Title) == "" {
@@ -171,16 +179,37 @@ func (c *Config) validateTransport() []error {
 	return errs
 }
 
-func validateSchemaObject(raw string, label string) error {
-	var obj map[string]any
-	if err := json.Unmarshal([]byte(raw), &obj); err != nil {
-		return fmt.Errorf("%s must be valid JSON: %w", label, err)
+func (c *Config) validateChecks() []error {
+	errs := make([]error, 0)
+	seen := make(map[string]bool, len(c.Checks))
+	for i := range c.Checks {
+		errs = append(errs, validateCheck(i, &c.Checks[i], seen)...)
+	}
+	return errs
+}
+
+func validateCheck(i int, ch *CheckConfig, seen map[string]bool) []error {
+	ch.Name = strings.TrimSpace(ch.Name)
+	if ch.Name == "" {
+		return []error{fmt.Errorf("%w: check[%d].name is required", ErrCheckInvalid, i)}
 	}
 
-	if t, ok := obj["type"]; ok && t != "object" {
-		return fmt.Errorf("%s must have type=object", label)
+	errs := make([]error, 0)
+	if seen[ch.Name] {
+		errs = append(errs, fmt.Errorf("%w: check name %q is declared more than once", ErrDuplicateName, ch.Name))
 	}
-	return nil
+	seen[ch.Name] = true
+
+	if ch.Timeout != "" {
+		if d, err := time.ParseDuration(ch.Timeout); err != nil || d <= 0 {
+			errs = append(errs, fmt.Errorf("%w: check %q timeout %q is not a positive duration", ErrCheckInvalid, ch.Name, ch.Timeout))
+		}
+	}
+
+	if ch.MinCoverage < 0 || ch.MinCoverage > 100 {
+		errs = append(errs, fmt.Errorf("%w: check %q min_coverage %v is not a percentage", ErrCheckInvalid, ch.Name, ch.MinCoverage))
+	}
+	return errs
 }
 
 func validateURI(raw string) error {
@@ -85,16 +85,16 @@ func TestValidateResource_DefaultsAndURISentinel(t *testing.T) {
 		cfg := &Config{
 			Server:    ServerConfig{Name: "weather", Module: "example.com/weather"},
 			Transport: TransportConfig{Type: "stdio", HTTPPort: DefaultHTTPPort},
-			Resource:  &ResourceConfig{ID: DefaultResourceID, URI: "file://readme"},
+			Resources: []ResourceConfig{{ID: DefaultResourceID, URI: "file://readme"}},
 		}
 
 		err := cfg.Validate()
 		require.NoError(t, err)
 
-		require.NotNil(t, cfg.Resource)
-		assert.Equal(t, "Readme", cfg.Resource.Title)
-		assert.Equal(t, defaultReadmeDescription, cfg.Resource.Description)
-		assert.Equal(t, DefaultResourceText, cfg.Resource.Text)
+		require.Len(t, cfg.Resources, 1)
+		assert.Equal(t, "Readme", cfg.Resources[0].Title)
+		assert.Equal(t, defaultReadmeDescription, cfg.Resources[0].Description)
+		assert.Equal(t, DefaultResourceText, cfg.Resources[0].Text)
 	})
 
 	t.Run("uri without scheme returns sentinel", func(t *testing.T) {
@@ -103,7 +103,7 @@ func TestValidateResource_DefaultsAndURISentinel(t *testing.T) {
 		cfg := &Config{
 			Server:    ServerConfig{Name: "weather", Module: "example.com/weather"},
 			Transport: TransportConfig{Type: "stdio", HTTPPort: DefaultHTTPPort},
-			Resource:  &ResourceConfig{ID: "docs", URI: "relative/path"},
+			Resources: []ResourceConfig{{ID: "docs", URI: "relative/path"}},
 		}
 
 		err := cfg.Validate()
@@ -118,20 +118,20 @@ func TestValidatePrompt_DefaultsAndArgumentValidation(t *testing.T) {
 	cfg := &Config{
 		Server:    ServerConfig{Name: "weather", Module: "example.com/weather"},
 		Transport: TransportConfig{Type: "stdio", HTTPPort: DefaultHTTPPort},
-		Prompt: &PromptConfig{
+		Prompts: []PromptConfig{{
 			ID:        "onboarding",
 			Arguments: []PromptArgumentConfig{{Name: ""}},
-		},
+		}},
 	}
 
 	err := cfg.Validate()
 	require.Error(t, err)
 
-	require.NotNil(t, cfg.Prompt)
-	assert.Equal(t, "Onboarding", cfg.Prompt.Title)
-	assert.Equal(t, "Prompt stub for onboarding.", cfg.Prompt.Description)
-	assert.Equal(t, "Prompt onboarding stub", cfg.Prompt.Template)
-	assert.Equal(t, defaultPromptRole, cfg.Prompt.Role)
+	require.Len(t, cfg.Prompts, 1)
+	assert.Equal(t, "Onboarding", cfg.Prompts[0].Title)
+	assert.Equal(t, "Prompt stub for onboarding.", cfg.Prompts[0].Description)
+	assert.Equal(t, "Prompt onboarding stub", cfg.Prompts[0].Template)
+	assert.Equal(t, defaultPromptRole, cfg.Prompts[0].Role)
 	assert.Contains(t, err.Error(), "argument[0].name is required")
 }
 
@@ -141,15 +141,15 @@ func TestValidateTool_DefaultSchemas(t *testing.T) {
 	cfg := &Config{
 		Server:    ServerConfig{Name: "weather", Module: "example.com/weather"},
 		Transport: TransportConfig{Type: "stdio", HTTPPort: DefaultHTTPPort},
-		Tool:      &ToolConfig{ID: "search"},
+		Tools:     []ToolConfig{{ID: "search"}},
 	}
 
 	err := cfg.Validate()
 	require.NoError(t, err)
 
-	require.NotNil(t, cfg.Tool)
-	assert.Equal(t, defaultJSONSchemaObject, cfg.Tool.InputSchema)
-	assert.Equal(t, defaultJSONSchemaObject, cfg.Tool.OutputSchema)
+	require.Len(t, cfg.Tools, 1)
+	assert.Equal(t, defaultJSONSchemaObject, cfg.Tools[0].InputSchema)
+	assert.Equal(t, defaultJSONSchemaObject, cfg.Tools[0].OutputSchema)
 }
 
 func TestValidate_AccumulatesSentinelErrors(t *testing.T) {
@@ -161,7 +161,7 @@ func TestValidate_AccumulatesSentinelErrors(t *testing.T) {
 			Module: "invalid module",
 		},
 		Transport: TransportConfig{Type: "tcp", HTTPPort: 99999},
-		Resource:  &ResourceConfig{ID: "docs", URI: "docs/path"},
+		Resources: []ResourceConfig{{ID: "docs", URI: "docs/path"}},
 	}
 
 	err := cfg.Validate()
@@ -173,3 +173,61 @@ func TestValidate_AccumulatesSentinelErrors(t *testing.T) {
 	assert.ErrorIs(t, err, ErrTransportPortInvalid)
 	assert.ErrorIs(t, err, ErrURIMissingScheme)
 }
+
+func TestValidate_MultipleEntities(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{
+		Server:    ServerConfig{Name: "weather", Module: "example.com/weather"},
+		Transport: TransportConfig{Type: "stdio", HTTPPort: DefaultHTTPPort},
+		Tools:     []ToolConfig{{ID: "search"}, {ID: "fetch"}, {ID: ""}},
+		Resources: []ResourceConfig{{ID: "docs", URI: "file:///docs"}, {ID: "page", URITemplate: "page://{id}"}},
+		Prompts:   []PromptConfig{{ID: "summary"}, {ID: "review"}},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tool[2].id is required")
+
+	assert.Equal(t, "Search", cfg.Tools[0].Title)
+	assert.Equal(t, "Fetch", cfg.Tools[1].Title)
+	assert.Equal(t, "Docs", cfg.Resources[0].Title)
+	assert.Equal(t, "This is the page stub.", cfg.Resources[1].Text)
+	assert.Equal(t, "Summary", cfg.Prompts[0].Title)
+	assert.Equal(t, "Review", cfg.Prompts[1].Title)
+}
+
+func TestValidateChecks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		checks   []CheckConfig
+		expected error
+	}{
+		{
+			name:   "valid steps",
+			checks: []CheckConfig{{Name: "vet"}, {Name: "coverage", Timeout: "2m", MinCoverage: 60}, {Name: "lint", Run: []string{"make", "lint"}}},
+		},
+		{name: "missing name", checks: []CheckConfig{{Timeout: "1m"}}, expected: ErrCheckInvalid},
+		{name: "duplicate name", checks: []CheckConfig{{Name: "vet"}, {Name: "vet"}}, expected: ErrDuplicateName},
+		{name: "invalid timeout", checks: []CheckConfig{{Name: "vet", Timeout: "soon"}}, expected: ErrCheckInvalid},
+		{name: "negative timeout", checks: []CheckConfig{{Name: "vet", Timeout: "-1m"}}, expected: ErrCheckInvalid},
+		{name: "coverage above 100", checks: []CheckConfig{{Name: "coverage", MinCoverage: 120}}, expected: ErrCheckInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &Config{Server: ServerConfig{Name: "weather"}, Checks: tt.checks}
+
+			err := cfg.Validate()
+			if tt.expected == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
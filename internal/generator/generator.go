@@ -1,14 +1,23 @@
 package generator
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"go/format"
+	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/manifest"
+	"github.com/alesr/mcpgen/internal/pkg/outfs"
 	"github.com/alesr/mcpgen/internal/pkg/utils"
+	"golang.org/x/mod/modfile"
 )
 
 var (
@@ -17,37 +26,307 @@ var (
 	errOutDirUnsafe = errors.New("out dir must not be current directory or filesystem root")
 )
 
+// ErrModifiedFiles is returned when generator-owned files were edited
+// since the last run and Force is not set.
+var ErrModifiedFiles = errors.New("generated files were modified by hand")
+
+// ErrLegacyProject is returned when OutDir holds a project generated by an
+// mcpgen without a manifest and Force is not set. Its files may hold
+// handler code the run would overwrite. It wraps ErrModifiedFiles.
+var ErrLegacyProject = fmt.Errorf("%w, or may have been: the project was generated before mcpgen kept a manifest", ErrModifiedFiles)
+
 type Generator struct {
 	Config *config.Config
 	OutDir string
+	// Force overwrites generator-owned files even when the manifest
+	// shows they were edited since the last run.
+	Force bool
+	// Log receives the notes of an applied plan: stubs added to user-owned
+	// files and declarations that no longer match the config. Nil discards them.
+	Log io.Writer
+	// Removed lists entities dropped from the config on purpose. Their stubs
+	// are deleted from user-owned files instead of being reported as orphans.
+	Removed []Entity
+
+	// FS receives the project instead of the OutDir directory when set.
+	// Writes to it are not staged, and OutDir only names it in messages.
+	FS outfs.FS
+	// Templates is a custom template directory. Its .gotmpl files replace
+	// the embedded templates of the same name, and the extra templates its
+	// templates.toml declares add files to the project. Nil uses the
+	// embedded templates only. It is read on the first Plan; later changes
+	// are ignored.
+	Templates fs.FS
+
+	templatesOnce sync.Once
+	templates     *templateSet
+	templatesErr  error
+}
+
+// Entity identifies a tool, prompt or resource by its config kind and ID.
+type Entity struct {
+	Kind string
+	ID   string
 }
 
+// Run plans the generation and applies it to OutDir.
 func (g *Generator) Run() error {
+	plan, err := g.Plan()
+	if err != nil {
+		return err
+	}
+	return g.Apply(plan)
+}
+
+// Plan renders every file in memory and compares it with OutDir.
+// Nothing is written; the plan is what Apply writes.
+func (g *Generator) Plan() (*Plan, error) {
 	if err := g.validate(); err != nil {
-		return fmt.Errorf("could not validate config: %w", err)
+		return nil, fmt.Errorf("could not validate config: %w", err)
+	}
+	return g.plan()
+}
+
+// Render renders every file of a new project for cfg in memory, keyed by
+// slash-separated path, as a first run into an empty directory would.
+func Render(cfg *config.Config) (map[string][]byte, error) {
+	if cfg == nil {
+		return nil, errConfigIsNil
 	}
 
-	if err := g.cleanupGenerated(); err != nil {
-		return fmt.Errorf("could not cleanup generated files: %w", err)
+	mem := outfs.NewMemory()
+	gen := &Generator{Config: cfg, FS: mem}
+
+	plan, err := gen.plan()
+	if err != nil {
+		return nil, err
 	}
+	if err := writePlan(mem, plan); err != nil {
+		return nil, err
+	}
+	return mem.Files(), nil
+}
+
+func (g *Generator) plan() (*Plan, error) {
+	plan := &Plan{OutDir: g.OutDir}
+
+	modified, legacy, err := g.modifiedFiles()
+	if err != nil {
+		return nil, err
+	}
+	plan.Modified, plan.Legacy = modified, legacy
+
+	set, err := g.templateSet()
+	if err != nil {
+		return nil, err
+	}
+	plan.Overridden = set.overridden
 
 	serverName := utils.DefaultServerName(g.Config.Server.Name)
 	data := buildTemplateData(g.Config, serverName)
 
-	if err := g.ensureOutputDirs(serverName, data); err != nil {
-		return fmt.Errorf("could not ensure output dirs: %w", err)
+	extra, err := set.jobs(data)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := append(coreJobs(serverName), optionalJobs(data, g.exists)...)
+	if err := checkDests(jobs, extra); err != nil {
+		return nil, err
+	}
+
+	jobs = append(jobs, extra...)
+	rendered, err := renderJobs(set, jobs, data)
+	if err != nil {
+		return nil, err
+	}
+
+	m := manifest.New()
+	for i, j := range jobs {
+		content, err := g.planJob(plan, j, rendered[i])
+		if err != nil {
+			return nil, fmt.Errorf("could not plan %s: %w", j.dest, err)
+		}
+
+		if err := g.planWrite(plan, j.dest, content); err != nil {
+			return nil, err
+		}
+		m.Add(j.dest, j.src, j.owner.String(), content)
+	}
+
+	// the project keeps its own config, so add and remove can edit it later
+	projectConfig, err := g.projectConfig(plan)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.planWrite(plan, config.ProjectFile, projectConfig); err != nil {
+		return nil, err
+	}
+	m.Add(config.ProjectFile, "", manifest.OwnerUser, projectConfig)
+
+	if err := g.planCleanup(plan); err != nil {
+		return nil, fmt.Errorf("could not plan cleanup: %w", err)
+	}
+
+	raw, err := m.Encode()
+	if err != nil {
+		return nil, err
+	}
+	if err := g.planWrite(plan, manifest.Path, raw); err != nil {
+		return nil, err
+	}
+
+	plan.sort()
+	return plan, nil
+}
+
+// projectConfig returns the mcpgen.toml the project keeps. An existing file
+// is the user's: only the tables of added and removed entities change, so
+// its comments and layout stay. It is rewritten from the config, with a
+// note, when anything else changed.
+func (g *Generator) projectConfig(plan *Plan) ([]byte, error) {
+	existing, err := fs.ReadFile(g.src(), config.ProjectFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config.Encode(g.Config)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", config.ProjectFile, err)
+	}
+
+	updated, ok, err := config.Update(existing, g.Config)
+	if err != nil {
+		return nil, err
 	}
+	if ok {
+		return updated, nil
+	}
+
+	plan.notef("Rewrote %s from the config; comments and layout in it were not kept", config.ProjectFile)
+	return config.Encode(g.Config)
+}
 
-	if err := g.writeCoreTemplates(serverName, data); err != nil {
-		return fmt.Errorf("could not write core templates: %w", err)
+// Apply writes a plan and keeps the result. Runs into OutDir are staged,
+// see Begin; runs into FS write to it directly.
+func (g *Generator) Apply(plan *Plan) error {
+	if g.FS != nil {
+		if err := g.preflight(plan); err != nil {
+			return err
+		}
+		return writePlan(g.FS, plan)
 	}
-	return g.writeOptionalTemplates(data)
+
+	tx, err := g.Begin(plan)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// writePlan writes the changes of a plan to w.
+func writePlan(w outfs.FS, plan *Plan) error {
+	// the manifest goes last, so it only describes a complete run
+	var manifestChange *FileChange
+	for i, c := range plan.Changes {
+		if c.Path == manifest.Path {
+			manifestChange = &plan.Changes[i]
+			continue
+		}
+		if err := applyChange(w, c); err != nil {
+			return err
+		}
+	}
+
+	if manifestChange != nil {
+		return applyChange(w, *manifestChange)
+	}
+	return nil
+}
+
+func applyChange(w outfs.FS, c FileChange) error {
+	switch c.Action {
+	case ActionCreate, ActionModify:
+		if err := w.WriteFile(c.Path, c.After); err != nil {
+			return fmt.Errorf("could not write %s: %w", c.Path, err)
+		}
+	case ActionDelete:
+		if err := w.Remove(c.Path); err != nil {
+			return fmt.Errorf("could not delete %s: %w", c.Path, err)
+		}
+	}
+	return nil
+}
+
+// modifiedFiles compares generator-owned files with the manifest of the
+// previous run. Projects without a manifest report the files an earlier
+// mcpgen wrote, recognized by their header, and legacy is set when there
+// are any.
+func (g *Generator) modifiedFiles() (modified []string, legacy bool, err error) {
+	prev, err := manifest.Read(g.src())
+	if errors.Is(err, manifest.ErrNotFound) {
+		modified, err = g.legacyFiles()
+		return modified, len(modified) > 0, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	modified = make([]string, 0)
+	for _, e := range prev.Files {
+		if e.Owner != manifest.OwnerGenerator {
+			continue
+		}
+
+		state, err := e.CheckFS(g.src())
+		if err != nil {
+			return nil, false, fmt.Errorf("could not check %s: %w", e.Path, err)
+		}
+		if state == manifest.StateModified {
+			modified = append(modified, e.Path)
+		}
+	}
+	return modified, false, nil
+}
+
+// legacyFiles lists the files under cmd and internal/mcpapp written by an
+// mcpgen without a manifest. Nothing tells whether they were edited, so they all count
+// as modified.
+func (g *Generator) legacyFiles() ([]string, error) {
+	legacy := make([]string, 0)
+	for _, root := range []string{"cmd", "internal/mcpapp"} {
+		err := fs.WalkDir(g.src(), root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+
+			content, err := fs.ReadFile(g.src(), p)
+			if err != nil {
+				return err
+			}
+			if isLegacy(content) {
+				legacy = append(legacy, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not look for files of an earlier mcpgen: %w", err)
+		}
+	}
+	return legacy, nil
 }
 
 func (g *Generator) validate() error {
 	if g.Config == nil {
 		return errConfigIsNil
 	}
+	if g.FS != nil {
+		return nil
+	}
 	if g.OutDir == "" {
 		return errOutDirEmpty
 	}
@@ -61,8 +340,8 @@ func (g *Generator) validate() error {
 	return nil
 }
 
-// isUnsafeOutDir blocks locations that would make cleanupGenerated() dangerous,
-// since cleanup removes "cmd" and "internal/mcpapp" under OutDir via RemoveAll.
+// isUnsafeOutDir blocks locations that would make the cleanup dangerous,
+// since it deletes generated files under "cmd" and "internal/mcpapp" in OutDir.
 func isUnsafeOutDir(outDir string) (bool, error) {
 	cleaned := filepath.Clean(outDir)
 	if cleaned == "." {
@@ -85,127 +364,361 @@ func isUnsafeOutDir(outDir string) (bool, error) {
 	return absOutDir == cwd, nil
 }
 
-func (g *Generator) ensureOutputDirs(serverName string, data TemplateData) error {
-	paths := []string{
-		g.outPath("cmd", serverName),
-		g.outPath("internal", "mcpapp"),
+// fileOwner tells who owns a generated file once it exists.
+type fileOwner int
+
+const (
+	// ownedByGenerator files are rewritten from the config on every run.
+	ownedByGenerator fileOwner = iota
+	// ownedByUser files are created when missing and otherwise only
+	// receive stubs for entities they do not declare yet.
+	ownedByUser
+)
+
+func (o fileOwner) String() string {
+	if o == ownedByUser {
+		return manifest.OwnerUser
 	}
+	return manifest.OwnerGenerator
+}
 
-	if len(data.Tools) > 0 {
-		paths = append(paths,
-			g.outPath("internal", "mcpapp", "tools"),
-			g.outPath("internal", "mcpapp", "tools", "handlers"),
-		)
+type templateJob struct {
+	src   string
+	dest  string
+	owner fileOwner
+	// kind and stubPrefixes identify per-entity stubs in user-owned Go
+	// files, so declarations left behind by removed entities are reported.
+	kind         string
+	stubPrefixes []string
+}
+
+func coreJobs(serverName string) []templateJob {
+	return []templateJob{
+		{src: "go.mod.gotmpl", dest: "go.mod", owner: ownedByUser},
+		{src: "README.md.gotmpl", dest: "README.md", owner: ownedByUser},
+		{src: "cmd_main.go.gotmpl", dest: "cmd/" + serverName + "/main.go"},
+		{src: "instructions.go.gotmpl", dest: "internal/mcpapp/instructions.go"},
+		{src: "mcpapp.go.gotmpl", dest: "internal/mcpapp/mcpapp.go"},
 	}
+}
 
-	if len(data.Prompts) > 0 {
-		paths = append(paths, g.outPath("internal", "mcpapp", "prompts"))
+// optionalJobs returns the jobs of enabled features. User-owned files of
+// disabled features are kept in step as well when exists reports them on
+// disk, so stubs of removed entities can be deleted from them.
+func optionalJobs(data TemplateData, exists func(dest string) bool) []templateJob {
+	type optionalJob struct {
+		templateJob
+		shouldWrite bool
 	}
 
-	if len(data.Resources) > 0 {
-		paths = append(paths, g.outPath("internal", "mcpapp", "resources"))
+	hasTools := len(data.Tools) > 0
+	hasPrompts := len(data.Prompts) > 0
+	hasResources := len(data.Resources) > 0
+
+	jobs := []optionalJob{
+		{templateJob{src: "tools.go.gotmpl", dest: "internal/mcpapp/tools/tools.go"}, hasTools},
+		{templateJob{src: "types.go.gotmpl", dest: "internal/mcpapp/tools/handlers/types.go"}, hasTools},
+		{templateJob{
+			src: "handlers.go.gotmpl", dest: "internal/mcpapp/tools/handlers/handlers.go", owner: ownedByUser,
+			kind: config.KindTool, stubPrefixes: []string{"Handle", "ToolNameFallback"},
+		}, hasTools},
+		{templateJob{src: "handlers_test.go.gotmpl", dest: "internal/mcpapp/tools/handlers/handlers_test.go"}, hasTools},
+		{templateJob{src: "prompts.go.gotmpl", dest: "internal/mcpapp/prompts/prompts.go"}, hasPrompts},
+		{templateJob{
+			src: "prompt_handlers.go.gotmpl", dest: "internal/mcpapp/prompts/handlers.go", owner: ownedByUser,
+			kind: config.KindPrompt, stubPrefixes: []string{"HandlePrompt"},
+		}, hasPrompts},
+		{templateJob{src: "prompts_test.go.gotmpl", dest: "internal/mcpapp/prompts/prompts_test.go"}, hasPrompts},
+		{templateJob{src: "resources.go.gotmpl", dest: "internal/mcpapp/resources/resources.go"}, hasResources},
+		{templateJob{
+			src: "resource_handlers.go.gotmpl", dest: "internal/mcpapp/resources/handlers.go", owner: ownedByUser,
+			kind: config.KindResource, stubPrefixes: []string{"HandleResource"},
+		}, hasResources},
+		{templateJob{src: "resources_test.go.gotmpl", dest: "internal/mcpapp/resources/resources_test.go"}, hasResources},
+		{templateJob{src: "stubs.go.gotmpl", dest: "internal/mcpapp/stubs/stubs.go"}, hasTools || hasPrompts || hasResources},
 	}
 
-	if len(data.Tools) > 0 || len(data.Prompts) > 0 || len(data.Resources) > 0 {
-		paths = append(paths, g.outPath("internal", "mcpapp", "stubs"))
+	out := make([]templateJob, 0, len(jobs))
+	for _, j := range jobs {
+		if j.shouldWrite || (j.owner == ownedByUser && exists(j.dest)) {
+			out = append(out, j.templateJob)
+		}
+	}
+	return out
+}
+
+// checkDests rejects extra templates that would write a file another
+// template, the project config or the manifest already writes.
+func checkDests(jobs, extra []templateJob) error {
+	taken := map[string]string{config.ProjectFile: "the project config", manifest.Path: "the manifest"}
+	for _, j := range jobs {
+		taken[j.dest] = j.src
 	}
 
-	for _, p := range paths {
-		if err := os.MkdirAll(p, 0o755); err != nil {
-			return fmt.Errorf("could not create directory %s: %w", p, err)
+	for _, j := range extra {
+		if by, ok := taken[j.dest]; ok {
+			return fmt.Errorf("%w: %s and %s both write %s", ErrInvalidExtraTemplate, j.src, by, j.dest)
 		}
+		taken[j.dest] = j.src
 	}
 	return nil
 }
 
-func (g *Generator) writeCoreTemplates(serverName string, data TemplateData) error {
-	type v struct {
-		src       string
-		dest      string
-		overwrite bool
+// templateSet parses the templates of g once, however many plans it makes.
+func (g *Generator) templateSet() (*templateSet, error) {
+	g.templatesOnce.Do(func() {
+		g.templates, g.templatesErr = loadTemplates(g.Templates)
+	})
+	return g.templates, g.templatesErr
+}
+
+// renderJobs renders and gofmts every job concurrently. The jobs are
+// independent; errors are joined in job order, so the same config always
+// reports the same error.
+func renderJobs(set *templateSet, jobs []templateJob, data TemplateData) ([][]byte, error) {
+	rendered := make([][]byte, len(jobs))
+	errs := make([]error, len(jobs))
+
+	var wg sync.WaitGroup
+	for i, j := range jobs {
+		wg.Go(func() {
+			content, err := renderFile(set, j.src, j.dest, data)
+			if err != nil {
+				errs[i] = fmt.Errorf("could not render template %s: %w", j.src, err)
+				return
+			}
+			rendered[i] = content
+		})
 	}
+	wg.Wait()
+
+	return rendered, errors.Join(errs...)
+}
 
-	jobs := []v{
-		{"go.mod.gotmpl", "go.mod", false},
-		{"README.md.gotmpl", "README.md", false},
-		{"cmd_main.go.gotmpl", filepath.Join("cmd", serverName, "main.go"), true},
-		{"instructions.go.gotmpl", filepath.Join("internal", "mcpapp", "instructions.go"), true},
-		{"mcpapp.go.gotmpl", filepath.Join("internal", "mcpapp", "mcpapp.go"), true},
+// planJob returns the content a job should leave on disk. Generator-owned
+// files are rendered; existing user-owned files are merged with the render,
+// unless an earlier mcpgen wrote them: their declarations no longer match
+// the generated code, so they are replaced, which Force has to allow.
+func (g *Generator) planJob(plan *Plan, j templateJob, rendered []byte) ([]byte, error) {
+	if j.owner == ownedByGenerator {
+		return rendered, nil
 	}
 
-	for _, j := range jobs {
-		fullPath := g.outPath(j.dest)
-		if err := g.writeTemplate(j.src, fullPath, data, j.overwrite); err != nil {
-			return fmt.Errorf("could not write template %s: %w", j.src, err)
-		}
+	existing, err := fs.ReadFile(g.src(), j.dest)
+	if errors.Is(err, fs.ErrNotExist) {
+		return rendered, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if isLegacy(existing) {
+		return rendered, nil
+	}
+
+	// only the embedded handler files have stubs to keep in step
+	switch {
+	case path.Ext(j.dest) == ".go" && len(j.stubPrefixes) > 0:
+		return mergeGoFile(plan, j, existing, rendered, g.Removed)
+	case path.Ext(j.dest) == ".mod":
+		return syncGoMod(plan, j, existing, rendered)
+	default:
+		return existing, nil
+	}
+}
+
+// planWrite records the change that writing content to rel would make.
+func (g *Generator) planWrite(plan *Plan, rel string, content []byte) error {
+	existing, err := fs.ReadFile(g.src(), rel)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		plan.add(FileChange{Path: rel, Action: ActionCreate, After: content})
+	case err != nil:
+		return fmt.Errorf("could not read %s: %w", rel, err)
+	case bytes.Equal(existing, content):
+		plan.add(FileChange{Path: rel, Action: ActionUnchanged, Before: existing, After: content})
+	default:
+		plan.add(FileChange{Path: rel, Action: ActionModify, Before: existing, After: content})
 	}
 	return nil
 }
 
-func (g *Generator) writeOptionalTemplates(data TemplateData) error {
-	type optionalJob struct {
-		src         string
-		dest        string
-		shouldWrite bool
+// planCleanup deletes the generator-owned files under cmd and
+// internal/mcpapp, recognized by their header, that this run no longer
+// writes, so entities dropped from the config disappear. Generator-owned
+// files the previous manifest lists and this run no longer writes, such as
+// those of extra templates whose condition stopped holding, go as well. User-owned
+// files are left alone.
+func (g *Generator) planCleanup(plan *Plan) error {
+	prev, err := manifest.Read(g.src())
+	if err != nil && !errors.Is(err, manifest.ErrNotFound) {
+		return err
+	}
+	if prev != nil {
+		for _, e := range prev.Files {
+			if e.Owner != manifest.OwnerGenerator || plan.has(e.Path) {
+				continue
+			}
+
+			content, err := fs.ReadFile(g.src(), e.Path)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			plan.add(FileChange{Path: e.Path, Action: ActionDelete, Before: content})
+		}
 	}
 
-	jobs := []optionalJob{
-		{"tools.go.gotmpl", "internal/mcpapp/tools/tools.go", len(data.Tools) > 0},
-		{"handlers.go.gotmpl", "internal/mcpapp/tools/handlers/handlers.go", len(data.Tools) > 0},
-		{"handlers_test.go.gotmpl", "internal/mcpapp/tools/handlers/handlers_test.go", len(data.Tools) > 0},
-		{"prompts.go.gotmpl", "internal/mcpapp/prompts/prompts.go", len(data.Prompts) > 0},
-		{"prompts_test.go.gotmpl", "internal/mcpapp/prompts/prompts_test.go", len(data.Prompts) > 0},
-		{"resources.go.gotmpl", "internal/mcpapp/resources/resources.go", len(data.Resources) > 0},
-		{"resources_test.go.gotmpl", "internal/mcpapp/resources/resources_test.go", len(data.Resources) > 0},
-		{"stubs.go.gotmpl", "internal/mcpapp/stubs/stubs.go", len(data.Tools) > 0 || len(data.Prompts) > 0 || len(data.Resources) > 0},
+	for _, root := range []string{"cmd", "internal/mcpapp"} {
+		err := fs.WalkDir(g.src(), root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+
+			if plan.has(p) {
+				return nil
+			}
+
+			content, err := fs.ReadFile(g.src(), p)
+			if err != nil {
+				return err
+			}
+			if bytes.HasPrefix(content, []byte(generatedHeader)) {
+				plan.add(FileChange{Path: p, Action: ActionDelete, Before: content})
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	for _, j := range jobs {
-		if !j.shouldWrite {
+// mergeGoFile deletes the stubs of removed entities from an existing
+// user-owned Go file and appends the stubs it is missing.
+func mergeGoFile(plan *Plan, j templateJob, existing, rendered []byte, removed []Entity) ([]byte, error) {
+	names := make(map[string]bool)
+	for _, e := range removed {
+		if e.Kind != j.kind {
 			continue
 		}
+		for _, prefix := range j.stubPrefixes {
+			names[prefix+utils.GoIdent(e.ID)] = true
+		}
+	}
 
-		fullPath := g.outPath(j.dest)
-		if err := g.writeTemplate(j.src, fullPath, data, true); err != nil {
-			return fmt.Errorf("could not write template %s: %w", j.src, err)
+	if len(names) > 0 {
+		pruned, dropped, err := removeDecls(existing, names)
+		if err != nil {
+			return nil, fmt.Errorf("could not remove stubs from %s: %w", j.dest, err)
+		}
+		if len(dropped) > 0 {
+			plan.notef("Removed %s from %s", strings.Join(dropped, ", "), j.dest)
 		}
+		existing = pruned
 	}
-	return nil
+
+	res, err := mergeUserFile(existing, rendered, j.stubPrefixes)
+	if err != nil {
+		return nil, fmt.Errorf("could not merge %s: %w", j.dest, err)
+	}
+
+	for _, name := range res.orphans {
+		plan.notef("Kept %s in %s: no configured %s matches it. Delete it if the %s was removed on purpose; it may use generated code that no longer exists.", name, j.dest, j.kind, j.kind)
+	}
+
+	if len(res.added) > 0 {
+		plan.notef("Added stubs to %s: %s", j.dest, strings.Join(res.added, ", "))
+	}
+	return res.content, nil
 }
 
-func (g *Generator) cleanupGenerated() error {
-	paths := []string{
-		g.outPath("cmd"),
-		g.outPath("internal", "mcpapp"),
+// syncGoMod keeps a user-owned go.mod in step with the config: the module
+// path follows the config and requirements of the template are added when
+// missing. Anything else in the file is preserved.
+func syncGoMod(plan *Plan, j templateJob, existing, rendered []byte) ([]byte, error) {
+	current, err := modfile.Parse(j.dest, existing, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", j.dest, err)
+	}
+
+	want, err := modfile.Parse(j.dest, rendered, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse rendered %s: %w", j.dest, err)
 	}
 
-	for _, p := range paths {
-		if err := os.RemoveAll(p); err != nil {
-			return fmt.Errorf("cleanup %s: %w", p, err)
+	changed := false
+	if current.Module == nil || current.Module.Mod.Path != want.Module.Mod.Path {
+		if err := current.AddModuleStmt(want.Module.Mod.Path); err != nil {
+			return nil, err
 		}
+		plan.notef("Updated module path in %s to %s", j.dest, want.Module.Mod.Path)
+		changed = true
 	}
-	return nil
+
+	required := make(map[string]bool, len(current.Require))
+	for _, r := range current.Require {
+		required[r.Mod.Path] = true
+	}
+	for _, r := range want.Require {
+		if required[r.Mod.Path] {
+			continue
+		}
+		if err := current.AddRequire(r.Mod.Path, r.Mod.Version); err != nil {
+			return nil, err
+		}
+		plan.notef("Added %s %s to %s", r.Mod.Path, r.Mod.Version, j.dest)
+		changed = true
+	}
+
+	if !changed {
+		return existing, nil
+	}
+
+	current.Cleanup()
+	return current.Format()
+}
+
+func (g *Generator) logf(format string, args ...any) {
+	if g.Log == nil {
+		return
+	}
+	fmt.Fprintf(g.Log, format, args...)
 }
 
-func (g *Generator) writeTemplate(name string, path string, data TemplateData, gofmt bool) error {
-	content, err := RenderTemplate(name, data)
+// renderFile renders a template for path, gofmt'ing Go sources.
+func renderFile(set *templateSet, name string, dest string, data TemplateData) ([]byte, error) {
+	content, err := set.render(name, data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if gofmt && filepath.Ext(path) == ".go" {
+	if path.Ext(dest) == ".go" {
 		formatted, err := format.Source(content)
 		if err != nil {
-			return fmt.Errorf("format %s: %w", path, err)
+			return nil, fmt.Errorf("format %s: %w", dest, err)
 		}
 		content = formatted
 	}
-	return os.WriteFile(path, content, 0o644)
+	return content, nil
 }
 
-func (g *Generator) outPath(elem ...string) string {
-	parts := make([]string, 0, len(elem)+1)
-	parts = append(parts, g.OutDir)
-	parts = append(parts, elem...)
-	return filepath.Join(parts...)
+func (g *Generator) exists(rel string) bool {
+	_, err := fs.Stat(g.src(), rel)
+	return err == nil
+}
+
+func (g *Generator) src() fs.FS {
+	if g.FS != nil {
+		return g.FS
+	}
+	return os.DirFS(g.OutDir)
 }
@@ -3,12 +3,15 @@ package generator
 import (
 	"errors"
 	"go/format"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/manifest"
+	"github.com/alesr/mcpgen/internal/pkg/outfs"
 	"github.com/alesr/mcpgen/internal/pkg/utils"
 	"github.com/alesr/mcpgen/internal/scaffold"
 	"github.com/stretchr/testify/assert"
@@ -95,8 +98,11 @@ func TestRenderTemplate_Handlers(t *testing.T) {
 	data := TemplateData{
 		Module: "example.com/test",
 		Tools: []ToolData{{
-			ID:     "greet",
-			GoName: "Greet",
+			ID:           "greet",
+			GoName:       "Greet",
+			InputType:    "GreetInput",
+			OutputType:   "GreetOutput",
+			OutputSample: `{"greeting":""}`,
 		}},
 	}
 
@@ -109,6 +115,8 @@ func TestRenderTemplate_Handlers(t *testing.T) {
 	out := string(content)
 	assert.NotContains(t, out, "req.Session.Elicit")
 	assert.True(t, strings.Contains(out, "ToolNameFallbackGreet"))
+	assert.Contains(t, out, "in GreetInput) (*mcp.CallToolResult, GreetOutput, error)")
+	assert.Contains(t, out, `stubs.ToolResult[GreetOutput](name, "{\"greeting\":\"\"}")`)
 }
 
 func TestGenerator_Run_ConditionalFeatureFiles(t *testing.T) {
@@ -219,3 +227,295 @@ func TestGenerator_Run_ConditionalFeatureFiles(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerator_Run_MultipleEntities(t *testing.T) {
+	t.Parallel()
+
+	outDir := filepath.Join(t.TempDir(), "generated")
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "multi"},
+		Tools:  []config.ToolConfig{{ID: "search"}, {ID: "fetch-page"}, {ID: "summarize"}},
+		Resources: []config.ResourceConfig{
+			{ID: "docs", URI: "file:///docs"},
+			{ID: "page", URITemplate: "page://{id}"},
+		},
+		Prompts: []config.PromptConfig{
+			{ID: "review", Arguments: []config.PromptArgumentConfig{{Name: "code", Required: true}}},
+			{ID: "welcome"},
+		},
+	}
+	require.NoError(t, cfg.Validate())
+
+	gen := Generator{Config: cfg, OutDir: outDir}
+	require.NoError(t, gen.Run())
+
+	read := func(rel string) string {
+		content, err := os.ReadFile(filepath.Join(outDir, rel))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	tools := read("internal/mcpapp/tools/tools.go")
+	for _, goName := range []string{"Search", "FetchPage", "Summarize"} {
+		assert.Contains(t, tools, "mcp.AddTool(server, Tool"+goName+", h.Handle"+goName+")")
+	}
+	assert.Contains(t, read("internal/mcpapp/tools/handlers/handlers_test.go"), "h.HandleFetchPage(ctx, req, handlers.FetchPageInput{})")
+	assert.Contains(t, read("internal/mcpapp/tools/handlers/types.go"), "type FetchPageOutput map[string]any")
+
+	resources := read("internal/mcpapp/resources/resources.go")
+	assert.Contains(t, resources, "server.AddResource(ResourceDocs, HandleResourceDocs)")
+	assert.Contains(t, resources, "server.AddResourceTemplate(ResourceTemplatePage, HandleResourcePage)")
+	assert.Contains(t, read("internal/mcpapp/resources/resources_test.go"), `"page://page"`)
+
+	prompts := read("internal/mcpapp/prompts/prompts.go")
+	assert.Contains(t, prompts, "server.AddPrompt(PromptReview, HandlePromptReview)")
+	assert.Contains(t, prompts, "server.AddPrompt(PromptWelcome, HandlePromptWelcome)")
+	assert.Contains(t, read("internal/mcpapp/prompts/prompts_test.go"), `"code": "test"`)
+}
+
+func TestGenerator_Run_PreservesUserCode(t *testing.T) {
+	t.Parallel()
+
+	outDir := filepath.Join(t.TempDir(), "generated")
+	cfg := &config.Config{
+		Server:    config.ServerConfig{Name: "keep"},
+		Tools:     []config.ToolConfig{{ID: "search"}, {ID: "old"}},
+		Resources: []config.ResourceConfig{{ID: "docs", URI: "file:///docs"}},
+		Prompts:   []config.PromptConfig{{ID: "review"}},
+	}
+	require.NoError(t, cfg.Validate())
+	require.NoError(t, (&Generator{Config: cfg, OutDir: outDir}).Run())
+
+	handlersPath := filepath.Join(outDir, "internal", "mcpapp", "tools", "handlers", "handlers.go")
+	promptsPath := filepath.Join(outDir, "internal", "mcpapp", "prompts", "handlers.go")
+	goModPath := filepath.Join(outDir, "go.mod")
+
+	edit := func(path, old, replacement string) {
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Contains(t, string(content), old)
+		require.NoError(t, os.WriteFile(path, []byte(strings.Replace(string(content), old, replacement, 1)), 0o644))
+	}
+	edit(handlersPath, "_ = in\n", "_ = in\n\th.logger.Info(\"custom search logic\")\n")
+	edit(promptsPath, "_ = ctx\n", "_ = ctx // custom prompt logic\n")
+	edit(goModPath, "go 1.25.6", "go 1.25.6\n\nreplace example.com/dep => ../dep")
+
+	cfg.Tools = []config.ToolConfig{{ID: "search"}, {ID: "fresh"}}
+	cfg.Server.Module = "example.com/renamed"
+	require.NoError(t, cfg.Validate())
+
+	var log strings.Builder
+	require.NoError(t, (&Generator{Config: cfg, OutDir: outDir, Log: &log}).Run())
+
+	read := func(path string) string {
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	handlers := read(handlersPath)
+	assert.Contains(t, handlers, `h.logger.Info("custom search logic")`)
+	assert.Contains(t, handlers, "func (h *Handlers) HandleFresh(")
+	assert.Contains(t, handlers, "func (h *Handlers) HandleOld(", "orphans are kept")
+	assert.Contains(t, read(promptsPath), "// custom prompt logic")
+
+	tools := read(filepath.Join(outDir, "internal", "mcpapp", "tools", "tools.go"))
+	assert.Contains(t, tools, "ToolFresh")
+	assert.NotContains(t, tools, "ToolOld")
+	assert.Contains(t, tools, `"example.com/renamed/internal/mcpapp/tools/handlers"`)
+
+	goMod := read(goModPath)
+	assert.Contains(t, goMod, "module example.com/renamed")
+	assert.Contains(t, goMod, "replace example.com/dep => ../dep")
+
+	assert.Contains(t, log.String(), "Added stubs to internal/mcpapp/tools/handlers/handlers.go: Handlers.HandleFresh, ToolNameFallbackFresh")
+	assert.Contains(t, log.String(), "Kept Handlers.HandleOld in internal/mcpapp/tools/handlers/handlers.go: no configured tool matches it")
+	assert.Contains(t, log.String(), "Kept ToolNameFallbackOld")
+	assert.Contains(t, log.String(), "Updated module path in go.mod to example.com/renamed")
+}
+
+func TestGenerator_Run_Manifest(t *testing.T) {
+	t.Parallel()
+
+	outDir := filepath.Join(t.TempDir(), "generated")
+	cfg, _ := scaffold.DefaultConfig(outDir, config.DefaultTransport, config.DefaultHTTPPort, true, true, true)
+	require.NoError(t, cfg.Validate())
+	require.NoError(t, (&Generator{Config: cfg, OutDir: outDir}).Run())
+
+	m, err := manifest.Load(outDir)
+	require.NoError(t, err)
+
+	entries := make(map[string]manifest.Entry, len(m.Files))
+	for _, e := range m.Files {
+		entries[e.Path] = e
+	}
+
+	tools := entries["internal/mcpapp/tools/tools.go"]
+	assert.Equal(t, "tools.go.gotmpl", tools.Template)
+	assert.Equal(t, manifest.OwnerGenerator, tools.Owner)
+	assert.Equal(t, manifest.OwnerUser, entries["internal/mcpapp/tools/handlers/handlers.go"].Owner)
+
+	for _, e := range m.Files {
+		state, err := e.Check(outDir)
+		require.NoError(t, err)
+		assert.Equal(t, manifest.StatePristine, state, e.Path)
+	}
+
+	// user-owned edits never block a run
+	handlersPath := filepath.Join(outDir, "internal", "mcpapp", "tools", "handlers", "handlers.go")
+	appendFile(t, handlersPath, "\n// mine\n")
+	require.NoError(t, (&Generator{Config: cfg, OutDir: outDir}).Run())
+
+	// generator-owned edits do, unless forced
+	toolsPath := filepath.Join(outDir, "internal", "mcpapp", "tools", "tools.go")
+	appendFile(t, toolsPath, "\n// hand edit\n")
+
+	err = (&Generator{Config: cfg, OutDir: outDir}).Run()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrModifiedFiles))
+	assert.Contains(t, err.Error(), "internal/mcpapp/tools/tools.go")
+
+	content, err := os.ReadFile(toolsPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "// hand edit", "refused runs leave files untouched")
+
+	var log strings.Builder
+	require.NoError(t, (&Generator{Config: cfg, OutDir: outDir, Force: true, Log: &log}).Run())
+	assert.Contains(t, log.String(), "Overwriting modified file internal/mcpapp/tools/tools.go")
+
+	content, err = os.ReadFile(toolsPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "// hand edit")
+
+	content, err = os.ReadFile(handlersPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "// mine")
+}
+
+// TestGenerator_Run_LegacyProject regenerates a project made by mcpgen
+// before the manifest existed, kept in testdata/legacy.
+func TestGenerator_Run_LegacyProject(t *testing.T) {
+	t.Parallel()
+
+	outDir := filepath.Join(t.TempDir(), "generated")
+	copyLegacy(t, outDir)
+
+	promptsPath := filepath.Join(outDir, "internal", "mcpapp", "prompts", "prompts.go")
+	content, err := os.ReadFile(promptsPath)
+	require.NoError(t, err)
+	edited := strings.Replace(string(content), "_ = ctx\n", "_ = ctx // custom prompt logic\n", 1)
+	require.NotEqual(t, string(content), edited)
+	require.NoError(t, os.WriteFile(promptsPath, []byte(edited), 0o644))
+
+	cfg, _ := scaffold.DefaultConfig(outDir, config.DefaultTransport, config.DefaultHTTPPort, true, true, true)
+	cfg.Server.Name = "legacy"
+	require.NoError(t, cfg.Validate())
+
+	plan, err := (&Generator{Config: cfg, OutDir: outDir}).Plan()
+	require.NoError(t, err)
+	assert.True(t, plan.Legacy)
+	assert.Contains(t, plan.Modified, "internal/mcpapp/prompts/prompts.go")
+	assert.Contains(t, plan.Modified, "internal/mcpapp/tools/handlers/handlers.go")
+	assert.Contains(t, plan.Modified, "cmd/legacy/main.go")
+
+	err = (&Generator{Config: cfg, OutDir: outDir}).Run()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrLegacyProject))
+	assert.True(t, errors.Is(err, ErrModifiedFiles))
+
+	content, err = os.ReadFile(promptsPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "// custom prompt logic", "refused runs leave files untouched")
+	assert.NoFileExists(t, filepath.Join(outDir, manifest.Path))
+
+	require.NoError(t, (&Generator{Config: cfg, OutDir: outDir, Force: true}).Run())
+
+	// forced runs replace the old handlers, whose signatures no longer
+	// match the generated code, instead of merging stubs into them
+	freshDir := filepath.Join(t.TempDir(), "generated")
+	require.NoError(t, (&Generator{Config: cfg, OutDir: freshDir}).Run())
+	for _, p := range []string{"internal/mcpapp/tools/handlers/handlers.go", "internal/mcpapp/prompts/prompts.go"} {
+		want, err := os.ReadFile(filepath.Join(freshDir, filepath.FromSlash(p)))
+		require.NoError(t, err)
+		got, err := os.ReadFile(filepath.Join(outDir, filepath.FromSlash(p)))
+		require.NoError(t, err)
+		assert.Equal(t, string(want), string(got), p)
+	}
+
+	plan, err = (&Generator{Config: cfg, OutDir: outDir}).Plan()
+	require.NoError(t, err)
+	assert.False(t, plan.Legacy)
+	assert.Empty(t, plan.Modified)
+}
+
+// copyLegacy writes the project in testdata/legacy into dir.
+func copyLegacy(t *testing.T, dir string) {
+	t.Helper()
+
+	root := filepath.Join("testdata", "legacy")
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, strings.TrimSuffix(p, ".golden"))
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		dest := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(dest, content, 0o644)
+	})
+	require.NoError(t, err)
+}
+
+func TestGenerator_Run_MemoryFS(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{
+		Server:  config.ServerConfig{Name: "memory"},
+		Tools:   []config.ToolConfig{{ID: "search"}},
+		Prompts: []config.PromptConfig{{ID: "review"}},
+	}
+	require.NoError(t, cfg.Validate())
+
+	mem := outfs.NewMemory()
+	require.NoError(t, (&Generator{Config: cfg, OutDir: "memory", FS: mem}).Run())
+
+	files := mem.Files()
+	assert.Contains(t, files, "cmd/memory/main.go")
+	assert.Contains(t, files, "internal/mcpapp/prompts/prompts.go")
+	assert.Contains(t, files, manifest.Path)
+
+	rendered, err := Render(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, rendered, files, "Render matches a first run")
+
+	changed := *cfg
+	changed.Prompts = nil
+	require.NoError(t, (&Generator{Config: &changed, OutDir: "memory", FS: mem}).Run())
+
+	files = mem.Files()
+	assert.NotContains(t, files, "internal/mcpapp/prompts/prompts.go")
+	assert.Contains(t, files, "internal/mcpapp/prompts/handlers.go", "user-owned files are never deleted")
+
+	_, err = (&Generator{Config: cfg, FS: mem}).Begin(&Plan{})
+	assert.ErrorIs(t, err, errNotStaged)
+}
+
+func appendFile(t *testing.T, path, s string) {
+	t.Helper()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString(s)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
@@ -0,0 +1,155 @@
+package generator
+
+import (
+	"flag"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+const (
+	goldenDir = "testdata/golden"
+	// goldenExt keeps snapshots out of reach of the go tool and gofmt.
+	goldenExt = ".golden"
+)
+
+// TestGolden renders every testdata/golden/<case>.toml and compares the
+// project byte for byte with the files under testdata/golden/<case>.
+// Run with -update to rewrite them after an intended template change.
+func TestGolden(t *testing.T) {
+	t.Parallel()
+
+	inputs, err := filepath.Glob(filepath.Join(goldenDir, "*.toml"))
+	require.NoError(t, err)
+	require.NotEmpty(t, inputs)
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".toml")
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := config.Load(input)
+			require.NoError(t, err)
+			require.NoError(t, cfg.Validate())
+
+			got, err := Render(cfg)
+			require.NoError(t, err)
+			got[manifest.Path] = normalizeManifest(got[manifest.Path])
+
+			dir := filepath.Join(goldenDir, name)
+			if *update {
+				writeGolden(t, dir, got)
+				return
+			}
+
+			want := readGolden(t, dir)
+			assert.Equal(t, sortedKeys(want), sortedKeys(got), "rendered files differ; run go test ./internal/generator -run TestGolden -update")
+
+			for p, content := range got {
+				if expected, ok := want[p]; ok {
+					assert.Equal(t, string(expected), string(content), p)
+				}
+			}
+		})
+	}
+}
+
+// TestGolden_Build builds and tests the snapshots of the cases that cover
+// every feature, so a snapshot that fails its own generated tests is
+// caught and not only one that changes.
+func TestGolden_Build(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds generated projects")
+	}
+	t.Parallel()
+
+	for _, name := range []string{"stdio_full", "http_full", "prompts_only"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			for p, content := range readGolden(t, filepath.Join(goldenDir, name)) {
+				dest := filepath.Join(dir, filepath.FromSlash(p))
+				require.NoError(t, os.MkdirAll(filepath.Dir(dest), 0o755))
+				require.NoError(t, os.WriteFile(dest, content, 0o644))
+			}
+
+			env := append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod", "GOPROXY=off")
+			run := func(args ...string) ([]byte, error) {
+				cmd := exec.Command("go", args...)
+				cmd.Dir = dir
+				cmd.Env = env
+				return cmd.CombinedOutput()
+			}
+
+			if output, err := run("mod", "tidy"); err != nil {
+				t.Skipf("dependencies of the generated project are not in the module cache: %s", output)
+			}
+			for _, args := range [][]string{{"build", "./..."}, {"vet", "./..."}, {"test", "./..."}} {
+				output, err := run(args...)
+				require.NoError(t, err, "go %s:\n%s", strings.Join(args, " "), output)
+			}
+		})
+	}
+}
+
+// normalizeManifest replaces the mcpgen version, which depends on how the
+// test binary was built.
+func normalizeManifest(raw []byte) []byte {
+	version := `"version": ` + strconv.Quote(manifest.Version())
+	return []byte(strings.Replace(string(raw), version, `"version": "(devel)"`, 1))
+}
+
+func writeGolden(t *testing.T, dir string, files map[string][]byte) {
+	t.Helper()
+
+	require.NoError(t, os.RemoveAll(dir))
+	for p, content := range files {
+		dest := filepath.Join(dir, filepath.FromSlash(p)+goldenExt)
+		require.NoError(t, os.MkdirAll(filepath.Dir(dest), 0o755))
+		require.NoError(t, os.WriteFile(dest, content, 0o644))
+	}
+}
+
+func readGolden(t *testing.T, dir string) map[string][]byte {
+	t.Helper()
+
+	files := make(map[string][]byte)
+	err := fs.WalkDir(os.DirFS(dir), ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(p) != goldenExt {
+			return err
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(p)))
+		if err != nil {
+			return err
+		}
+		files[strings.TrimSuffix(p, goldenExt)] = content
+		return nil
+	})
+	require.NoError(t, err, "missing golden files; run go test ./internal/generator -run TestGolden -update")
+	return files
+}
+
+func sortedKeys(files map[string][]byte) []string {
+	keys := make([]string, 0, len(files))
+	for k := range files {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
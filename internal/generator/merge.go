@@ -0,0 +1,376 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// generatedHeader marks files mcpgen owns and rewrites on every run.
+// It follows the Go convention for generated code, so tools skip them too.
+const generatedHeader = "// Code generated by mcpgen. DO NOT EDIT."
+
+// legacyHeader starts the files of projects generated before the manifest
+// existed, such as "// Code generated by mcpgen. Edit if needed.". Every
+// one of them was meant to be edited, handlers included.
+const legacyHeader = "// Code generated by mcpgen."
+
+// isLegacy reports whether content was written by an mcpgen that kept no manifest.
+func isLegacy(content []byte) bool {
+	return bytes.HasPrefix(content, []byte(legacyHeader)) && !bytes.HasPrefix(content, []byte(generatedHeader))
+}
+
+// mergeResult describes how an existing user-owned file was brought up to date.
+type mergeResult struct {
+	content []byte
+	added   []string
+	orphans []string
+}
+
+// mergeUserFile appends to existing every top-level declaration of rendered
+// that it does not declare yet, along with the imports they need. Existing
+// declarations are never changed. Declarations named with one of
+// stubPrefixes that rendered no longer declares are reported as orphans.
+func mergeUserFile(existing, rendered []byte, stubPrefixes []string) (mergeResult, error) {
+	fset := token.NewFileSet()
+
+	oldFile, err := parser.ParseFile(fset, "existing.go", existing, parser.ParseComments)
+	if err != nil {
+		return mergeResult{}, fmt.Errorf("could not parse existing file: %w", err)
+	}
+
+	newFile, err := parser.ParseFile(fset, "rendered.go", rendered, parser.ParseComments)
+	if err != nil {
+		return mergeResult{}, fmt.Errorf("could not parse rendered file: %w", err)
+	}
+
+	have := make(map[string]bool)
+	for _, decl := range oldFile.Decls {
+		for _, name := range declNames(decl) {
+			have[name] = true
+		}
+	}
+
+	want := make(map[string]bool)
+	var (
+		res     mergeResult
+		missing []ast.Decl
+	)
+	for _, decl := range newFile.Decls {
+		names := declNames(decl)
+		for _, name := range names {
+			want[name] = true
+		}
+		if len(names) == 0 || hasAny(have, names) {
+			continue
+		}
+		missing = append(missing, decl)
+		res.added = append(res.added, names...)
+	}
+
+	for _, decl := range oldFile.Decls {
+		for _, name := range declNames(decl) {
+			if !want[name] && hasStubPrefix(name, stubPrefixes) {
+				res.orphans = append(res.orphans, name)
+			}
+		}
+	}
+
+	if len(missing) == 0 {
+		res.content = existing
+		return res, nil
+	}
+
+	var appended bytes.Buffer
+	appended.Write(bytes.TrimRight(existing, "\n"))
+	for _, decl := range missing {
+		start := decl.Pos()
+		if doc := declDoc(decl); doc != nil {
+			start = doc.Pos()
+		}
+		appended.WriteString("\n\n")
+		appended.Write(rendered[fset.Position(start).Offset:fset.Position(decl.End()).Offset])
+	}
+	appended.WriteString("\n")
+
+	content := addImports(appended.Bytes(), fset, oldFile, missingImports(oldFile, newFile, missing))
+
+	formatted, err := format.Source(content)
+	if err != nil {
+		return mergeResult{}, fmt.Errorf("could not format merged file: %w", err)
+	}
+	res.content = formatted
+	return res, nil
+}
+
+// declNames returns the names a top-level declaration introduces.
+// Methods are qualified with their receiver type, e.g. "Handlers.HandleSearch".
+func declNames(decl ast.Decl) []string {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Recv != nil && len(d.Recv.List) > 0 {
+			return []string{receiverName(d.Recv.List[0].Type) + "." + d.Name.Name}
+		}
+		return []string{d.Name.Name}
+	case *ast.GenDecl:
+		names := make([]string, 0)
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				names = append(names, s.Name.Name)
+			case *ast.ValueSpec:
+				for _, n := range s.Names {
+					if n.Name != "_" {
+						names = append(names, n.Name)
+					}
+				}
+			}
+		}
+		return names
+	default:
+		return nil
+	}
+}
+
+func receiverName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(e.X)
+	case *ast.IndexExpr:
+		return receiverName(e.X)
+	case *ast.Ident:
+		return e.Name
+	default:
+		return ""
+	}
+}
+
+func declDoc(decl ast.Decl) *ast.CommentGroup {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		return d.Doc
+	case *ast.GenDecl:
+		return d.Doc
+	default:
+		return nil
+	}
+}
+
+// hasStubPrefix reports whether name, or the method part of a qualified
+// name, starts with one of prefixes.
+func hasStubPrefix(name string, prefixes []string) bool {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAny(set map[string]bool, names []string) bool {
+	for _, n := range names {
+		if set[n] {
+			return true
+		}
+	}
+	return false
+}
+
+// missingImports returns the import specs of rendered that the missing
+// declarations reference and that existing does not import yet.
+func missingImports(existing, rendered *ast.File, missing []ast.Decl) []*ast.ImportSpec {
+	used := selectorNames(missing)
+
+	imported := make(map[string]bool)
+	for _, spec := range existing.Imports {
+		imported[spec.Path.Value] = true
+	}
+
+	specs := make([]*ast.ImportSpec, 0)
+	for _, spec := range rendered.Imports {
+		if imported[spec.Path.Value] || !used[importName(spec)] {
+			continue
+		}
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Path.Value < specs[j].Path.Value })
+	return specs
+}
+
+// importName is the name an import is referenced by in the file.
+// Templates only import packages named after the last path element.
+func importName(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+	p, err := strconv.Unquote(spec.Path.Value)
+	if err != nil {
+		return ""
+	}
+	return path.Base(p)
+}
+
+// addImports inserts specs into the first import declaration of file,
+// or after the package clause when it has none. src must start with the
+// source file was parsed from, so its offsets are still valid.
+func addImports(src []byte, fset *token.FileSet, file *ast.File, specs []*ast.ImportSpec) []byte {
+	if len(specs) == 0 {
+		return src
+	}
+
+	var lines strings.Builder
+	for _, spec := range specs {
+		lines.WriteString("\t")
+		if spec.Name != nil {
+			lines.WriteString(spec.Name.Name + " ")
+		}
+		lines.WriteString(spec.Path.Value + "\n")
+	}
+
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+
+		if gen.Rparen.IsValid() {
+			at := fset.Position(gen.Rparen).Offset
+			return splice(src, at, lines.String())
+		}
+		at := fset.Position(gen.End()).Offset
+		return splice(src, at, "\n\nimport (\n"+lines.String()+")")
+	}
+
+	at := fset.Position(file.Name.End()).Offset
+	return splice(src, at, "\n\nimport (\n"+lines.String()+")")
+}
+
+func splice(src []byte, at int, insert string) []byte {
+	out := make([]byte, 0, len(src)+len(insert))
+	out = append(out, src[:at]...)
+	out = append(out, insert...)
+	return append(out, src[at:]...)
+}
+
+// removeDecls deletes the top-level declarations of src named in names,
+// along with their doc comments and the imports only they used. Methods
+// match by their unqualified name. It returns the names it removed.
+func removeDecls(src []byte, names map[string]bool) ([]byte, []string, error) {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "existing.go", src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not parse existing file: %w", err)
+	}
+
+	type span struct{ start, end token.Pos }
+	var (
+		spans   []span
+		removed []string
+		kept    []ast.Decl
+	)
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			continue
+		}
+
+		matched := false
+		for _, name := range declNames(decl) {
+			short := name
+			if i := strings.LastIndex(name, "."); i >= 0 {
+				short = name[i+1:]
+			}
+			if names[short] {
+				matched = true
+				removed = append(removed, name)
+			}
+		}
+		if !matched {
+			kept = append(kept, decl)
+			continue
+		}
+
+		start := decl.Pos()
+		if doc := declDoc(decl); doc != nil {
+			start = doc.Pos()
+		}
+		spans = append(spans, span{start, decl.End()})
+	}
+
+	if len(removed) == 0 {
+		return src, nil, nil
+	}
+
+	used := selectorNames(kept)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+
+		var unused []span
+		for _, s := range gen.Specs {
+			spec := s.(*ast.ImportSpec)
+			name := importName(spec)
+			if name == "_" || name == "." || used[name] {
+				continue
+			}
+			start := spec.Pos()
+			if spec.Doc != nil {
+				start = spec.Doc.Pos()
+			}
+			unused = append(unused, span{start, spec.End()})
+		}
+
+		if len(unused) == len(gen.Specs) {
+			start := gen.Pos()
+			if gen.Doc != nil {
+				start = gen.Doc.Pos()
+			}
+			spans = append(spans, span{start, gen.End()})
+			continue
+		}
+		spans = append(spans, unused...)
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start > spans[j].start })
+
+	out := append([]byte(nil), src...)
+	for _, s := range spans {
+		start, end := fset.Position(s.start).Offset, fset.Position(s.end).Offset
+		out = append(out[:start], out[end:]...)
+	}
+
+	formatted, err := format.Source(out)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not format file: %w", err)
+	}
+	return formatted, removed, nil
+}
+
+// selectorNames returns the identifiers decls select from, such as
+// package names in fmt.Sprintf.
+func selectorNames(decls []ast.Decl) map[string]bool {
+	used := make(map[string]bool)
+	for _, decl := range decls {
+		ast.Inspect(decl, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok {
+				if id, ok := sel.X.(*ast.Ident); ok {
+					used[id.Name] = true
+				}
+			}
+			return true
+		})
+	}
+	return used
+}
@@ -0,0 +1,170 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeUserFile(t *testing.T) {
+	t.Parallel()
+
+	rendered := []byte(`package prompts
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// HandlePromptReview returns a stub.
+func HandlePromptReview(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return nil, nil
+}
+
+// HandlePromptWelcome returns a stub.
+func HandlePromptWelcome(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+`)
+
+	t.Run("appends missing declarations and their imports", func(t *testing.T) {
+		t.Parallel()
+
+		existing := []byte(`package prompts
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// HandlePromptReview is implemented by hand.
+func HandlePromptReview(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return &mcp.GetPromptResult{Description: "custom"}, nil
+}
+
+func HandlePromptOld(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return nil, nil
+}
+
+func helper() {}
+`)
+
+		res, err := mergeUserFile(existing, rendered, []string{"HandlePrompt"})
+		require.NoError(t, err)
+
+		out := string(res.content)
+		assert.Equal(t, []string{"HandlePromptWelcome"}, res.added)
+		assert.Equal(t, []string{"HandlePromptOld"}, res.orphans)
+		assert.Contains(t, out, `Description: "custom"`)
+		assert.Contains(t, out, "// HandlePromptWelcome returns a stub.\nfunc HandlePromptWelcome(")
+		assert.Contains(t, out, "\t\"fmt\"\n")
+		assert.Contains(t, out, "func HandlePromptOld(")
+		assert.Contains(t, out, "func helper() {}")
+	})
+
+	t.Run("up to date file is untouched", func(t *testing.T) {
+		t.Parallel()
+
+		res, err := mergeUserFile(rendered, rendered, []string{"HandlePrompt"})
+		require.NoError(t, err)
+		assert.Empty(t, res.added)
+		assert.Empty(t, res.orphans)
+		assert.Equal(t, rendered, res.content)
+	})
+
+	t.Run("file without imports gets an import block", func(t *testing.T) {
+		t.Parallel()
+
+		res, err := mergeUserFile([]byte("package prompts\n"), rendered, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"HandlePromptReview", "HandlePromptWelcome"}, res.added)
+		assert.Contains(t, string(res.content), "import (\n\t\"context\"\n\t\"fmt\"\n\t\"github.com/modelcontextprotocol/go-sdk/mcp\"\n)")
+	})
+
+	t.Run("methods are matched by receiver", func(t *testing.T) {
+		t.Parallel()
+
+		existing := []byte("package handlers\n\ntype Handlers struct{}\n\nfunc (h *Handlers) HandleSearch() {}\n")
+		want := []byte("package handlers\n\ntype Handlers struct{}\n\nfunc (h *Handlers) HandleSearch() {}\n\nfunc (h *Handlers) HandleFetch() {}\n")
+
+		res, err := mergeUserFile(existing, want, []string{"Handle"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Handlers.HandleFetch"}, res.added)
+		assert.Empty(t, res.orphans)
+	})
+
+	t.Run("invalid existing file", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := mergeUserFile([]byte("package prompts\nfunc {"), rendered, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "could not parse existing file")
+	})
+}
+
+func TestRemoveDecls(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(`package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type Handlers struct {
+	logger *slog.Logger
+}
+
+// HandleSearch is implemented by hand.
+func (h *Handlers) HandleSearch(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return nil, fmt.Errorf("todo")
+}
+
+// ToolNameFallbackSearch is the default name.
+const ToolNameFallbackSearch = "search"
+
+// HandleFetch is implemented by hand.
+func (h *Handlers) HandleFetch(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return &mcp.CallToolResult{}, nil
+}
+`)
+
+	t.Run("removes declarations and the imports only they used", func(t *testing.T) {
+		t.Parallel()
+
+		out, removed, err := removeDecls(src, map[string]bool{"HandleSearch": true, "ToolNameFallbackSearch": true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Handlers.HandleSearch", "ToolNameFallbackSearch"}, removed)
+
+		got := string(out)
+		assert.NotContains(t, got, "Search")
+		assert.NotContains(t, got, `"fmt"`)
+		assert.Contains(t, got, `"context"`)
+		assert.Contains(t, got, "// HandleFetch is implemented by hand.\nfunc (h *Handlers) HandleFetch(")
+	})
+
+	t.Run("no match leaves the file as is", func(t *testing.T) {
+		t.Parallel()
+
+		out, removed, err := removeDecls(src, map[string]bool{"HandleOther": true})
+		require.NoError(t, err)
+		assert.Empty(t, removed)
+		assert.Equal(t, string(src), string(out))
+	})
+
+	t.Run("drops an import declaration left empty", func(t *testing.T) {
+		t.Parallel()
+
+		out, _, err := removeDecls([]byte("package prompts\n\nimport \"fmt\"\n\nfunc HandlePromptA() { fmt.Println() }\n"), map[string]bool{"HandlePromptA": true})
+		require.NoError(t, err)
+		assert.Equal(t, "package prompts\n", string(out))
+	})
+}
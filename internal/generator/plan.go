@@ -0,0 +1,128 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Action is what applying a plan does to one file.
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionModify    Action = "modify"
+	ActionDelete    Action = "delete"
+	ActionUnchanged Action = "unchanged"
+)
+
+// FileChange is one file of a plan. Path is slash-separated and relative
+// to the output directory. Before is empty for created files and After
+// for deleted ones.
+type FileChange struct {
+	Path   string
+	Action Action
+	Before []byte
+	After  []byte
+}
+
+// Plan is the complete, in-memory result of a generation run.
+type Plan struct {
+	OutDir  string
+	Changes []FileChange
+	// Modified lists generator-owned files edited by hand since the last run.
+	Modified []string
+	// Legacy is set when OutDir holds a project of an earlier mcpgen,
+	// without a manifest. Modified then lists every file it wrote.
+	Legacy bool
+	// Notes describe merges into user-owned files.
+	Notes []string
+	// Overridden lists the embedded templates replaced by custom ones.
+	Overridden []string
+}
+
+func (p *Plan) add(c FileChange) {
+	p.Changes = append(p.Changes, c)
+}
+
+func (p *Plan) has(path string) bool {
+	for _, c := range p.Changes {
+		if c.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Plan) notef(format string, args ...any) {
+	p.Notes = append(p.Notes, fmt.Sprintf(format, args...))
+}
+
+func (p *Plan) sort() {
+	sort.Slice(p.Changes, func(i, j int) bool { return p.Changes[i].Path < p.Changes[j].Path })
+}
+
+// Print writes the planned file tree followed by a unified diff of every
+// file that would change.
+func (p *Plan) Print(w io.Writer) error {
+	fmt.Fprintf(w, "Planned changes in %s:\n", p.OutDir)
+	for _, c := range p.Changes {
+		fmt.Fprintf(w, "  %-10s %s\n", c.Action, c.Path)
+	}
+
+	if len(p.Overridden) > 0 {
+		fmt.Fprintf(w, "\nCustom templates: %s\n", strings.Join(p.Overridden, ", "))
+	}
+
+	if len(p.Modified) > 0 {
+		fmt.Fprintln(w)
+		for _, m := range p.Modified {
+			if p.Legacy {
+				fmt.Fprintf(w, "Warning: %s was written by an earlier mcpgen and may hold your code; generation stops unless forced.\n", m)
+				continue
+			}
+			fmt.Fprintf(w, "Warning: %s was edited by hand; generation stops unless forced.\n", m)
+		}
+	}
+
+	if len(p.Notes) > 0 {
+		fmt.Fprintln(w)
+		for _, n := range p.Notes {
+			fmt.Fprintln(w, n)
+		}
+	}
+
+	for _, c := range p.Changes {
+		if c.Action == ActionUnchanged {
+			continue
+		}
+
+		diff, err := unifiedDiff(c)
+		if err != nil {
+			return fmt.Errorf("could not diff %s: %w", c.Path, err)
+		}
+		fmt.Fprintf(w, "\n%s", diff)
+	}
+	return nil
+}
+
+func unifiedDiff(c FileChange) (string, error) {
+	from, to := "a/"+c.Path, "b/"+c.Path
+	switch c.Action {
+	case ActionCreate:
+		from = "/dev/null"
+	case ActionDelete:
+		to = "/dev/null"
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(c.Before)),
+		B:        difflib.SplitLines(string(c.After)),
+		FromFile: from,
+		ToFile:   to,
+		Context:  3,
+	})
+}
@@ -0,0 +1,97 @@
+package generator
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_Plan(t *testing.T) {
+	t.Parallel()
+
+	outDir := filepath.Join(t.TempDir(), "generated")
+	cfg := &config.Config{
+		Server:  config.ServerConfig{Name: "plan"},
+		Tools:   []config.ToolConfig{{ID: "search"}},
+		Prompts: []config.PromptConfig{{ID: "review"}},
+	}
+	require.NoError(t, cfg.Validate())
+
+	gen := &Generator{Config: cfg, OutDir: outDir}
+
+	actions := func(p *Plan) map[string]Action {
+		out := make(map[string]Action, len(p.Changes))
+		for _, c := range p.Changes {
+			out[c.Path] = c.Action
+		}
+		return out
+	}
+
+	t.Run("fresh directory", func(t *testing.T) {
+		plan, err := gen.Plan()
+		require.NoError(t, err)
+
+		_, err = os.Stat(outDir)
+		assert.True(t, os.IsNotExist(err), "planning writes nothing")
+
+		got := actions(plan)
+		assert.Equal(t, ActionCreate, got["internal/mcpapp/tools/tools.go"])
+		assert.Equal(t, ActionCreate, got["internal/mcpapp/prompts/handlers.go"])
+		assert.Equal(t, ActionCreate, got[manifest.Path])
+
+		var out bytes.Buffer
+		require.NoError(t, plan.Print(&out))
+		assert.Contains(t, out.String(), "Planned changes in "+outDir+":\n")
+		assert.Contains(t, out.String(), "  create     internal/mcpapp/tools/tools.go\n")
+		assert.Contains(t, out.String(), "--- /dev/null\n+++ b/internal/mcpapp/tools/tools.go\n")
+
+		require.NoError(t, gen.Apply(plan))
+		for _, c := range plan.Changes {
+			content, err := os.ReadFile(filepath.Join(outDir, filepath.FromSlash(c.Path)))
+			require.NoError(t, err)
+			assert.Equal(t, string(c.After), string(content), "applied %s differs from the plan", c.Path)
+		}
+	})
+
+	t.Run("regenerating the same config changes nothing", func(t *testing.T) {
+		plan, err := gen.Plan()
+		require.NoError(t, err)
+
+		for _, c := range plan.Changes {
+			assert.Equal(t, ActionUnchanged, c.Action, c.Path)
+		}
+	})
+
+	t.Run("config changes modify and delete files", func(t *testing.T) {
+		changed := *cfg
+		changed.Tools = []config.ToolConfig{{ID: "search"}, {ID: "fetch"}}
+		changed.Prompts = nil
+		require.NoError(t, changed.Validate())
+
+		plan, err := (&Generator{Config: &changed, OutDir: outDir}).Plan()
+		require.NoError(t, err)
+
+		got := actions(plan)
+		assert.Equal(t, ActionModify, got["internal/mcpapp/tools/tools.go"])
+		assert.Equal(t, ActionModify, got["internal/mcpapp/tools/handlers/handlers.go"])
+		assert.Equal(t, ActionDelete, got["internal/mcpapp/prompts/prompts.go"])
+		assert.Equal(t, ActionUnchanged, got["internal/mcpapp/prompts/handlers.go"], "user-owned files are never deleted")
+		assert.Equal(t, ActionUnchanged, got["go.mod"])
+		assert.Contains(t, plan.Notes, "Added stubs to internal/mcpapp/tools/handlers/handlers.go: Handlers.HandleFetch, ToolNameFallbackFetch")
+
+		var out bytes.Buffer
+		require.NoError(t, plan.Print(&out))
+		assert.Contains(t, out.String(), "--- a/internal/mcpapp/tools/tools.go\n+++ b/internal/mcpapp/tools/tools.go\n")
+		assert.Contains(t, out.String(), "+const ToolNameFetch = \"fetch\"\n")
+		assert.Contains(t, out.String(), "--- a/internal/mcpapp/prompts/prompts.go\n+++ /dev/null\n")
+
+		_, err = os.Stat(filepath.Join(outDir, "internal", "mcpapp", "prompts", "prompts.go"))
+		require.NoError(t, err, "planning deletes nothing")
+	})
+}
@@ -0,0 +1,346 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alesr/mcpgen/internal/pkg/schema"
+	"github.com/alesr/mcpgen/internal/pkg/utils"
+)
+
+// typeGen turns tool JSON schemas into Go type declarations.
+// One typeGen is shared by every tool so that the names it picks
+// are unique within the generated handlers package.
+type typeGen struct {
+	taken    map[string]bool
+	building map[string]bool
+	decls    []string
+}
+
+// schemaDoc is one root schema being converted, with the named types
+// already assigned to its local references.
+type schemaDoc struct {
+	name string
+	root any
+	refs map[string]string
+}
+
+func newTypeGen(reserved ...string) *typeGen {
+	g := &typeGen{taken: make(map[string]bool), building: make(map[string]bool)}
+	for _, name := range reserved {
+		g.taken[name] = true
+	}
+	return g
+}
+
+// rootType declares name for a tool input or output schema.
+// The name itself must have been reserved by the caller.
+func (g *typeGen) rootType(name, doc, raw string) {
+	var root any
+	if err := json.Unmarshal([]byte(raw), &root); err != nil {
+		root = map[string]any{}
+	}
+
+	d := &schemaDoc{name: name, root: root, refs: make(map[string]string)}
+
+	obj, _ := root.(map[string]any)
+	if _, ok := obj["properties"].(map[string]any); ok {
+		g.declareStruct(d, name, doc, obj)
+		return
+	}
+
+	typ := "map[string]any"
+	switch ap := obj["additionalProperties"].(type) {
+	case bool:
+		if !ap {
+			typ = "struct{}"
+		}
+	case map[string]any:
+		typ = "map[string]" + g.typeFor(d, ap, name+"Value")
+	}
+	g.emit(fmt.Sprintf("%s\ntype %s %s\n", comment(doc, description(obj)), name, typ))
+}
+
+// source returns every declaration gofmt'ed, in declaration order.
+func (g *typeGen) source() string {
+	src := strings.Join(g.decls, "\n")
+	formatted, err := format.Source([]byte("package p\n\n" + src))
+	if err != nil {
+		return src
+	}
+	return strings.TrimPrefix(string(formatted), "package p\n\n")
+}
+
+func (g *typeGen) emit(decl string) {
+	g.decls = append(g.decls, decl)
+}
+
+// reserveSlot keeps a declaration's place before its nested types are
+// emitted, so parents read before their children in the output.
+func (g *typeGen) reserveSlot() int {
+	g.decls = append(g.decls, "")
+	return len(g.decls) - 1
+}
+
+// uniqueName reserves hint, or hint with a numeric suffix when it is taken.
+func (g *typeGen) uniqueName(hint string) string {
+	name := hint
+	for i := 2; g.taken[name]; i++ {
+		name = hint + strconv.Itoa(i)
+	}
+	g.taken[name] = true
+	return name
+}
+
+// typeFor returns the Go type for schema s, declaring named types as needed.
+func (g *typeGen) typeFor(d *schemaDoc, s any, hint string) string {
+	obj, ok := s.(map[string]any)
+	if !ok {
+		return "any"
+	}
+
+	if ref, ok := obj["$ref"].(string); ok {
+		return g.refType(d, ref)
+	}
+
+	if enum, ok := obj["enum"].([]any); ok {
+		return g.enumType(hint, obj, enum)
+	}
+
+	if c, ok := obj["const"]; ok {
+		return literalType(c)
+	}
+
+	typ, nullable := schemaType(obj)
+	goType := g.baseType(d, obj, typ, hint)
+	if nullable && !isNilable(goType) {
+		return "*" + goType
+	}
+	return goType
+}
+
+func (g *typeGen) baseType(d *schemaDoc, obj map[string]any, typ, hint string) string {
+	switch typ {
+	case "string":
+		return "string"
+	case "integer":
+		return "int"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		if items, ok := obj["items"]; ok {
+			return "[]" + g.typeFor(d, items, hint+"Item")
+		}
+		return "[]any"
+	case "object":
+		if _, ok := obj["properties"].(map[string]any); ok {
+			name := g.uniqueName(hint)
+			g.declareStruct(d, name, "", obj)
+			return name
+		}
+		if ap, ok := obj["additionalProperties"].(map[string]any); ok {
+			return "map[string]" + g.typeFor(d, ap, hint+"Value")
+		}
+		return "map[string]any"
+	default:
+		return "any"
+	}
+}
+
+// refType resolves a local reference to a named type, declaring it on first use.
+// Unresolvable references fall back to any.
+func (g *typeGen) refType(d *schemaDoc, ref string) string {
+	if name, ok := d.refs[ref]; ok {
+		return name
+	}
+
+	target, ok := schema.ResolvePointer(d.root, ref)
+	if !ok {
+		return "any"
+	}
+
+	// definitions are named after the root schema they live in, e.g. SearchInputUnit
+	segments := strings.Split(strings.TrimPrefix(ref, "#/"), "/")
+	defHint := d.name + utils.GoIdent(segments[len(segments)-1])
+
+	obj, _ := target.(map[string]any)
+	if _, isStruct := obj["properties"].(map[string]any); isStruct {
+		name := g.uniqueName(defHint)
+		d.refs[ref] = name
+		g.declareStruct(d, name, "", obj)
+		return name
+	}
+
+	typ := g.typeFor(d, target, defHint)
+	d.refs[ref] = typ
+	return typ
+}
+
+func (g *typeGen) enumType(hint string, obj map[string]any, enum []any) string {
+	for _, v := range enum {
+		if _, ok := v.(string); !ok {
+			return "any"
+		}
+	}
+
+	name := g.uniqueName(hint)
+
+	var b strings.Builder
+	b.WriteString(comment(fmt.Sprintf("%s is a string limited to the constants below.", name), description(obj)))
+	fmt.Fprintf(&b, "\ntype %s string\n\n", name)
+
+	b.WriteString("const (\n")
+	for _, v := range enum {
+		value := v.(string)
+		fmt.Fprintf(&b, "\t%s %s = %s\n", g.uniqueName(name+utils.GoIdent(value)), name, strconv.Quote(value))
+	}
+	b.WriteString(")\n")
+
+	g.emit(b.String())
+	return name
+}
+
+func (g *typeGen) declareStruct(d *schemaDoc, name, doc string, obj map[string]any) {
+	props, _ := obj["properties"].(map[string]any)
+
+	required := make(map[string]bool)
+	if list, ok := obj["required"].([]any); ok {
+		for _, r := range list {
+			if s, ok := r.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	slot := g.reserveSlot()
+	g.building[name] = true
+	defer delete(g.building, name)
+
+	var fields strings.Builder
+	usedFields := make(map[string]bool)
+
+	for _, key := range keys {
+		field := utils.GoIdent(key)
+		for i := 2; usedFields[field]; i++ {
+			field = utils.GoIdent(key) + strconv.Itoa(i)
+		}
+		usedFields[field] = true
+
+		prop, _ := props[key].(map[string]any)
+		typ := g.typeFor(d, props[key], name+utils.GoIdent(key))
+		tag := key
+
+		if !required[key] {
+			tag += ",omitempty"
+			if !isNilable(typ) {
+				typ = "*" + typ
+			}
+		} else if g.building[typ] {
+			// a required field of a type still being declared is recursive
+			// and would have infinite size without the indirection
+			typ = "*" + typ
+		}
+
+		if desc := description(prop); desc != "" {
+			fields.WriteString(comment("", desc))
+			fields.WriteString("\n")
+		}
+		fmt.Fprintf(&fields, "\t%s %s `json:%s`\n", field, typ, strconv.Quote(tag))
+	}
+
+	g.decls[slot] = fmt.Sprintf("%s\ntype %s struct {\n%s}\n", comment(doc, description(obj)), name, fields.String())
+}
+
+// schemaType returns the single non-null JSON type of a schema and
+// whether null is also allowed. Schemas with several types report "".
+func schemaType(obj map[string]any) (string, bool) {
+	switch t := obj["type"].(type) {
+	case string:
+		return t, false
+	case []any:
+		var (
+			types    []string
+			nullable bool
+		)
+		for _, v := range t {
+			s, _ := v.(string)
+			if s == "null" {
+				nullable = true
+				continue
+			}
+			types = append(types, s)
+		}
+		if len(types) == 1 {
+			return types[0], nullable
+		}
+		return "", nullable
+	default:
+		if _, ok := obj["properties"]; ok {
+			return "object", false
+		}
+		if _, ok := obj["items"]; ok {
+			return "array", false
+		}
+		return "", false
+	}
+}
+
+func literalType(v any) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case float64:
+		return "float64"
+	default:
+		return "any"
+	}
+}
+
+func isNilable(typ string) bool {
+	return typ == "any" || strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "map[") || strings.HasPrefix(typ, "*")
+}
+
+func description(obj map[string]any) string {
+	if obj == nil {
+		return ""
+	}
+	s, _ := obj["description"].(string)
+	return strings.TrimSpace(s)
+}
+
+// comment renders a doc comment from a summary line and a schema description.
+func comment(summary, desc string) string {
+	var lines []string
+	if summary != "" {
+		lines = append(lines, summary)
+	}
+	if desc != "" {
+		if summary != "" {
+			lines = append(lines, "")
+		}
+		lines = append(lines, strings.Split(desc, "\n")...)
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(strings.TrimRight("// "+line, " "))
+	}
+	return b.String()
+}
@@ -0,0 +1,191 @@
+package generator
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeGen_RootType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		schema   string
+		contains []string
+	}{
+		{
+			name:     "empty object",
+			schema:   `{"type":"object"}`,
+			contains: []string{"type SearchInput map[string]any"},
+		},
+		{
+			name:     "closed empty object",
+			schema:   `{"type":"object","additionalProperties":false}`,
+			contains: []string{"type SearchInput struct{}"},
+		},
+		{
+			name:     "map of strings",
+			schema:   `{"type":"object","additionalProperties":{"type":"string"}}`,
+			contains: []string{"type SearchInput map[string]string"},
+		},
+		{
+			name: "required and optional scalars",
+			schema: `{
+				"type": "object",
+				"description": "Search parameters.",
+				"properties": {
+					"query": {"type": "string", "description": "Text to look for."},
+					"limit": {"type": "integer"},
+					"score": {"type": "number"},
+					"exact": {"type": "boolean"},
+					"cursor": {"type": ["string", "null"]}
+				},
+				"required": ["query", "limit"]
+			}`,
+			contains: []string{
+				"// SearchInput is the input of the search tool.\n//\n// Search parameters.\ntype SearchInput struct {",
+				"// Text to look for.\n\tQuery string `json:\"query\"`",
+				"Limit  int      `json:\"limit\"`",
+				"Score  *float64 `json:\"score,omitempty\"`",
+				"Exact  *bool    `json:\"exact,omitempty\"`",
+				"Cursor *string  `json:\"cursor,omitempty\"`",
+			},
+		},
+		{
+			name: "arrays nested objects and enums",
+			schema: `{
+				"type": "object",
+				"properties": {
+					"tags": {"type": "array", "items": {"type": "string"}},
+					"filters": {
+						"type": "array",
+						"items": {
+							"type": "object",
+							"properties": {"field": {"type": "string"}},
+							"required": ["field"]
+						}
+					},
+					"address": {
+						"type": "object",
+						"properties": {"city": {"type": "string"}}
+					},
+					"unit": {"type": "string", "enum": ["celsius", "fahrenheit"]},
+					"extra": {}
+				},
+				"required": ["address"]
+			}`,
+			contains: []string{
+				"Tags    []string              `json:\"tags,omitempty\"`",
+				"Filters []SearchInputFiltersItem `json:\"filters,omitempty\"`",
+				"Address SearchInputAddress     `json:\"address\"`",
+				"Unit    *SearchInputUnit       `json:\"unit,omitempty\"`",
+				"Extra   any                    `json:\"extra,omitempty\"`",
+				"type SearchInputFiltersItem struct {\n\tField string `json:\"field\"`\n}",
+				"type SearchInputAddress struct {\n\tCity *string `json:\"city,omitempty\"`\n}",
+				"// SearchInputUnit is a string limited to the constants below.\ntype SearchInputUnit string",
+				"SearchInputUnitCelsius    SearchInputUnit = \"celsius\"",
+				"SearchInputUnitFahrenheit SearchInputUnit = \"fahrenheit\"",
+			},
+		},
+		{
+			name: "definitions and recursive references",
+			schema: `{
+				"type": "object",
+				"properties": {
+					"root": {"$ref": "#/$defs/node"},
+					"color": {"$ref": "#/$defs/color"}
+				},
+				"required": ["root"],
+				"$defs": {
+					"node": {
+						"type": "object",
+						"properties": {
+							"name": {"type": "string"},
+							"children": {"type": "array", "items": {"$ref": "#/$defs/node"}},
+							"parent": {"$ref": "#/$defs/node"}
+						},
+						"required": ["name", "parent"]
+					},
+					"color": {"enum": ["red", "green"], "description": "Color of the node."}
+				}
+			}`,
+			contains: []string{
+				"Color *SearchInputColor `json:\"color,omitempty\"`",
+				"Root  SearchInputNode   `json:\"root\"`",
+				"type SearchInputNode struct {",
+				"Children []SearchInputNode `json:\"children,omitempty\"`",
+				"Parent   *SearchInputNode  `json:\"parent\"`",
+				"// SearchInputColor is a string limited to the constants below.\n//\n// Color of the node.\ntype SearchInputColor string",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := newTypeGen("SearchInput")
+			g.rootType("SearchInput", "SearchInput is the input of the search tool.", tt.schema)
+			src := g.source()
+
+			_, err := format.Source([]byte("package p\n\n" + src))
+			require.NoError(t, err, src)
+
+			for _, s := range tt.contains {
+				assert.Contains(t, collapseSpace(src), collapseSpace(s))
+			}
+		})
+	}
+}
+
+// collapseSpace ignores gofmt column alignment in assertions.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func TestBuildToolTypes_UniqueNames(t *testing.T) {
+	t.Parallel()
+
+	schema := `{"type":"object","properties":{"unit":{"enum":["c"]}}}`
+	src := buildToolTypes([]ToolData{
+		{ID: "a", GoName: "A", InputType: "AInput", OutputType: "AOutput", InputSchema: schema, OutputSchema: schema},
+		{ID: "b", GoName: "B", InputType: "BInput", OutputType: "BOutput", InputSchema: `{"type":"object"}`, OutputSchema: `{"type":"object"}`},
+	})
+
+	assert.Contains(t, src, "type AInputUnit string")
+	assert.Contains(t, src, "type AOutputUnit string")
+	assert.Contains(t, src, "type BInput map[string]any")
+	assert.Contains(t, src, "type BOutput map[string]any")
+
+	_, err := format.Source([]byte("package p\n\n" + src))
+	require.NoError(t, err)
+}
+
+func TestOutputSample(t *testing.T) {
+	t.Parallel()
+
+	t.Run("open schema keeps the generic payload", func(t *testing.T) {
+		t.Parallel()
+		assert.Empty(t, outputSample("search", `{"type":"object"}`))
+	})
+
+	t.Run("required properties get placeholders", func(t *testing.T) {
+		t.Parallel()
+
+		got := outputSample("weather", `{
+			"type": "object",
+			"properties": {
+				"temp": {"type": "number", "minimum": 1},
+				"unit": {"enum": ["celsius", "fahrenheit"]},
+				"note": {"type": "string"}
+			},
+			"required": ["temp", "unit"],
+			"additionalProperties": false
+		}`)
+		assert.JSONEq(t, `{"temp": 1, "unit": "celsius"}`, got)
+	})
+}
@@ -0,0 +1,313 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/alesr/mcpgen/internal/pkg/outfs"
+)
+
+var errNotStaged = errors.New("only runs into an output directory can be staged")
+
+// Transaction is a plan applied to OutDir whose previous tree is kept as
+// a backup until Commit, so Rollback can put it back.
+type Transaction struct {
+	outDir string
+	// backup holds the previous tree; empty when OutDir did not exist.
+	backup string
+	// moved lists the directories moved from the backup into the new tree
+	// instead of being copied, see movedDirs.
+	moved []string
+	done  bool
+}
+
+// movedDirs are the top-level directories of OutDir that Begin moves into
+// the new tree rather than copying: they can be large and a plan does not
+// write to them.
+var movedDirs = []string{".git", ".hg", ".svn", ".jj", "vendor", "node_modules"}
+
+// Begin applies a plan atomically. The current OutDir is copied into a
+// temporary sibling directory, the plan is written there and the result
+// is swapped in with renames, so a failure leaves OutDir untouched. The
+// directories of movedDirs are moved over after the swap instead of
+// copied. A symlinked OutDir is resolved first, so the link stays in place.
+// Generator-owned files edited by hand stop it before anything is
+// written, unless Force is set.
+func (g *Generator) Begin(plan *Plan) (*Transaction, error) {
+	if g.FS != nil {
+		return nil, errNotStaged
+	}
+	if err := g.preflight(plan); err != nil {
+		return nil, err
+	}
+
+	outDir, err := resolveOutDir(g.OutDir)
+	if err != nil {
+		return nil, err
+	}
+	parent, base := filepath.Dir(outDir), filepath.Base(outDir)
+
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return nil, fmt.Errorf("could not create %s: %w", parent, err)
+	}
+
+	stage, err := os.MkdirTemp(parent, "."+base+".mcpgen-stage-")
+	if err != nil {
+		return nil, fmt.Errorf("could not create staging directory: %w", err)
+	}
+
+	prev, err := statDir(outDir)
+	exists := prev != nil
+
+	var moved []string
+	if err == nil && exists {
+		moved, err = dirsToMove(outDir, plan)
+	}
+	if err == nil && exists {
+		err = copyTree(outDir, stage, moved)
+	}
+	if err == nil {
+		err = writePlan(outfs.NewDir(stage), plan)
+	}
+	if err == nil {
+		// MkdirTemp made the stage 0700; the tree swapped in keeps the
+		// mode of the one it replaces
+		perm := fs.FileMode(0o755)
+		if exists {
+			perm = prev.Mode().Perm()
+		}
+		err = os.Chmod(stage, perm)
+	}
+	if err != nil {
+		return nil, errors.Join(err, os.RemoveAll(stage))
+	}
+
+	tx := &Transaction{outDir: outDir}
+	if exists {
+		tx.backup, err = reserveName(parent, "."+base+".mcpgen-backup-")
+		if err == nil {
+			err = os.Rename(outDir, tx.backup)
+		}
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("could not back up %s: %w", outDir, err), os.RemoveAll(stage))
+		}
+	}
+
+	if err := os.Rename(stage, outDir); err != nil {
+		err = fmt.Errorf("could not move generated files into %s: %w", outDir, err)
+		if tx.backup != "" {
+			err = errors.Join(err, os.Rename(tx.backup, outDir))
+		}
+		return nil, errors.Join(err, os.RemoveAll(stage))
+	}
+
+	for _, dir := range moved {
+		if err := os.Rename(filepath.Join(tx.backup, dir), filepath.Join(outDir, dir)); err != nil {
+			err = fmt.Errorf("could not move %s into %s: %w", dir, outDir, err)
+			return nil, errors.Join(err, tx.Rollback())
+		}
+		tx.moved = append(tx.moved, dir)
+	}
+	return tx, nil
+}
+
+// resolveOutDir returns outDir with symlinks resolved, or cleaned when it
+// does not exist yet.
+func resolveOutDir(outDir string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(outDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return filepath.Clean(outDir), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not resolve %s: %w", outDir, err)
+	}
+	return resolved, nil
+}
+
+// dirsToMove returns the directories of movedDirs present in outDir that
+// no change of plan writes into.
+func dirsToMove(outDir string, plan *Plan) ([]string, error) {
+	var dirs []string
+	for _, dir := range movedDirs {
+		info, err := os.Lstat(filepath.Join(outDir, dir))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			continue
+		}
+
+		touched := slices.ContainsFunc(plan.Changes, func(c FileChange) bool {
+			return c.Action != ActionUnchanged && strings.HasPrefix(c.Path, dir+"/")
+		})
+		if !touched {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs, nil
+}
+
+// preflight stops plans that would overwrite generator-owned files edited
+// by hand, unless Force is set, and logs the custom templates and notes of
+// the plan.
+func (g *Generator) preflight(plan *Plan) error {
+	if plan.Legacy && !g.Force {
+		return fmt.Errorf("%w: %s", ErrLegacyProject, strings.Join(plan.Modified, ", "))
+	}
+	if len(plan.Modified) > 0 && !g.Force {
+		return fmt.Errorf("%w: %s", ErrModifiedFiles, strings.Join(plan.Modified, ", "))
+	}
+
+	if len(plan.Overridden) > 0 {
+		g.logf("Using custom templates: %s\n", strings.Join(plan.Overridden, ", "))
+	}
+	for _, p := range plan.Modified {
+		g.logf("Overwriting modified file %s\n", p)
+	}
+	for _, note := range plan.Notes {
+		g.logf("%s\n", note)
+	}
+	return nil
+}
+
+// Backup returns where the previous tree is kept until Commit or
+// Rollback, or "" when OutDir did not exist before.
+func (t *Transaction) Backup() string {
+	return t.backup
+}
+
+// Commit keeps the new tree and deletes the backup.
+func (t *Transaction) Commit() error {
+	if t.done {
+		return nil
+	}
+	t.done = true
+
+	if t.backup == "" {
+		return nil
+	}
+	if err := os.RemoveAll(t.backup); err != nil {
+		return fmt.Errorf("could not remove backup %s: %w", t.backup, err)
+	}
+	return nil
+}
+
+// Rollback restores the tree OutDir had before Begin. When it did not
+// exist there is nothing to restore, and the generated tree is kept so
+// what failed can be looked into.
+func (t *Transaction) Rollback() error {
+	if t.done {
+		return nil
+	}
+	t.done = true
+
+	if t.backup == "" {
+		return nil
+	}
+	for _, dir := range t.moved {
+		if err := os.Rename(filepath.Join(t.outDir, dir), filepath.Join(t.backup, dir)); err != nil {
+			return fmt.Errorf("could not move %s back into %s: %w", dir, t.backup, err)
+		}
+	}
+	if err := os.RemoveAll(t.outDir); err != nil {
+		return fmt.Errorf("could not remove %s: %w", t.outDir, err)
+	}
+	if err := os.Rename(t.backup, t.outDir); err != nil {
+		return fmt.Errorf("could not restore %s from %s: %w", t.outDir, t.backup, err)
+	}
+	return nil
+}
+
+// statDir returns the info of the directory at path, or nil when there
+// is nothing there.
+func statDir(path string) (fs.FileInfo, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", path)
+	}
+	return info, nil
+}
+
+// reserveName returns an unused path in dir starting with prefix.
+func reserveName(dir, prefix string) (string, error) {
+	name, err := os.MkdirTemp(dir, prefix)
+	if err != nil {
+		return "", err
+	}
+	return name, os.Remove(name)
+}
+
+// copyTree copies the files, directories and symlinks under src into the
+// existing directory dst, keeping their permissions. The top-level
+// directories named in skip are left out.
+func copyTree(src, dst string, skip []string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			if rel == "." {
+				return os.Chmod(dst, info.Mode().Perm())
+			}
+			if slices.Contains(skip, rel) {
+				return filepath.SkipDir
+			}
+			return os.Mkdir(target, info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return copyFile(p, target, info.Mode().Perm())
+		default:
+			return fmt.Errorf("could not copy %s: unsupported file type", p)
+		}
+	})
+}
+
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		return errors.Join(err, out.Close())
+	}
+	return out.Close()
+}
@@ -0,0 +1,226 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_Begin(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "stage"},
+		Tools:  []config.ToolConfig{{ID: "search"}},
+	}
+	require.NoError(t, cfg.Validate())
+
+	changed := *cfg
+	changed.Tools = []config.ToolConfig{{ID: "fetch"}}
+	require.NoError(t, changed.Validate())
+
+	toolsPath := filepath.Join("internal", "mcpapp", "tools", "tools.go")
+
+	setup := func(t *testing.T) (string, []byte) {
+		t.Helper()
+
+		outDir := filepath.Join(t.TempDir(), "generated")
+		require.NoError(t, (&Generator{Config: cfg, OutDir: outDir}).Run())
+		require.NoError(t, os.WriteFile(filepath.Join(outDir, "notes.txt"), []byte("mine"), 0o600))
+
+		before, err := os.ReadFile(filepath.Join(outDir, toolsPath))
+		require.NoError(t, err)
+		return outDir, before
+	}
+
+	siblings := func(t *testing.T, outDir string) []string {
+		t.Helper()
+
+		entries, err := os.ReadDir(filepath.Dir(outDir))
+		require.NoError(t, err)
+
+		names := make([]string, 0, len(entries))
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		return names
+	}
+
+	t.Run("commit keeps the new tree and drops the backup", func(t *testing.T) {
+		t.Parallel()
+
+		outDir, before := setup(t)
+		gen := &Generator{Config: &changed, OutDir: outDir}
+
+		plan, err := gen.Plan()
+		require.NoError(t, err)
+
+		tx, err := gen.Begin(plan)
+		require.NoError(t, err)
+
+		backup, err := os.ReadFile(filepath.Join(tx.Backup(), toolsPath))
+		require.NoError(t, err)
+		assert.Equal(t, string(before), string(backup))
+
+		require.NoError(t, tx.Commit())
+		assert.Equal(t, []string{"generated"}, siblings(t, outDir))
+
+		after, err := os.ReadFile(filepath.Join(outDir, toolsPath))
+		require.NoError(t, err)
+		assert.Contains(t, string(after), "ToolFetch")
+
+		notes, err := os.ReadFile(filepath.Join(outDir, "notes.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "mine", string(notes), "files mcpgen does not know are carried over")
+
+		info, err := os.Stat(filepath.Join(outDir, "notes.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	})
+
+	t.Run("rollback restores the previous tree", func(t *testing.T) {
+		t.Parallel()
+
+		outDir, before := setup(t)
+		gen := &Generator{Config: &changed, OutDir: outDir}
+
+		plan, err := gen.Plan()
+		require.NoError(t, err)
+
+		tx, err := gen.Begin(plan)
+		require.NoError(t, err)
+		require.NoError(t, tx.Rollback())
+		assert.Equal(t, []string{"generated"}, siblings(t, outDir))
+
+		after, err := os.ReadFile(filepath.Join(outDir, toolsPath))
+		require.NoError(t, err)
+		assert.Equal(t, string(before), string(after))
+
+		assert.NoError(t, tx.Commit(), "a finished transaction ignores later calls")
+	})
+
+	t.Run("the output keeps the mode of its directory", func(t *testing.T) {
+		t.Parallel()
+
+		fresh := filepath.Join(t.TempDir(), "generated")
+		require.NoError(t, (&Generator{Config: cfg, OutDir: fresh}).Run())
+
+		info, err := os.Stat(fresh)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o755), info.Mode().Perm(), "a new project is not private to its owner")
+
+		outDir, _ := setup(t)
+		require.NoError(t, os.Chmod(outDir, 0o750))
+		require.NoError(t, (&Generator{Config: &changed, OutDir: outDir}).Run())
+
+		info, err = os.Stat(outDir)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o750), info.Mode().Perm())
+	})
+
+	t.Run("rollback of a fresh directory keeps it", func(t *testing.T) {
+		t.Parallel()
+
+		outDir := filepath.Join(t.TempDir(), "generated")
+		gen := &Generator{Config: cfg, OutDir: outDir}
+
+		plan, err := gen.Plan()
+		require.NoError(t, err)
+
+		tx, err := gen.Begin(plan)
+		require.NoError(t, err)
+		assert.Empty(t, tx.Backup())
+
+		require.NoError(t, tx.Rollback())
+		assert.Equal(t, []string{"generated"}, siblings(t, outDir))
+
+		_, err = os.Stat(filepath.Join(outDir, toolsPath))
+		assert.NoError(t, err)
+	})
+
+	t.Run("version control and vendor directories are moved, not copied", func(t *testing.T) {
+		t.Parallel()
+
+		outDir, before := setup(t)
+		head := filepath.Join(outDir, ".git", "HEAD")
+		require.NoError(t, os.MkdirAll(filepath.Dir(head), 0o755))
+		require.NoError(t, os.WriteFile(head, []byte("ref: refs/heads/main\n"), 0o644))
+
+		headInfo, err := os.Stat(head)
+		require.NoError(t, err)
+
+		gen := &Generator{Config: &changed, OutDir: outDir}
+		plan, err := gen.Plan()
+		require.NoError(t, err)
+
+		tx, err := gen.Begin(plan)
+		require.NoError(t, err)
+
+		_, err = os.Stat(filepath.Join(tx.Backup(), ".git"))
+		assert.True(t, os.IsNotExist(err), ".git left the backup")
+
+		moved, err := os.Stat(head)
+		require.NoError(t, err)
+		assert.True(t, os.SameFile(headInfo, moved), ".git was moved, not copied")
+
+		require.NoError(t, tx.Rollback())
+
+		after, err := os.ReadFile(filepath.Join(outDir, toolsPath))
+		require.NoError(t, err)
+		assert.Equal(t, string(before), string(after))
+
+		restored, err := os.Stat(head)
+		require.NoError(t, err)
+		assert.True(t, os.SameFile(headInfo, restored), "rollback puts .git back")
+	})
+
+	t.Run("a symlinked output directory stays a symlink", func(t *testing.T) {
+		t.Parallel()
+
+		target, _ := setup(t)
+		link := filepath.Join(t.TempDir(), "link")
+		require.NoError(t, os.Symlink(target, link))
+
+		require.NoError(t, (&Generator{Config: &changed, OutDir: link}).Run())
+
+		info, err := os.Lstat(link)
+		require.NoError(t, err)
+		assert.NotZero(t, info.Mode()&os.ModeSymlink)
+
+		after, err := os.ReadFile(filepath.Join(target, toolsPath))
+		require.NoError(t, err)
+		assert.Contains(t, string(after), "ToolFetch")
+		assert.Equal(t, []string{"generated"}, siblings(t, target))
+	})
+
+	t.Run("a failed write leaves the output untouched", func(t *testing.T) {
+		t.Parallel()
+
+		outDir, before := setup(t)
+		gen := &Generator{Config: &changed, OutDir: outDir}
+
+		plan, err := gen.Plan()
+		require.NoError(t, err)
+
+		// a file where a later change needs a directory
+		plan.Changes = append(plan.Changes,
+			FileChange{Path: "blocker", Action: ActionCreate, After: []byte("x")},
+			FileChange{Path: "blocker/file.go", Action: ActionCreate, After: []byte("x")},
+		)
+
+		_, err = gen.Begin(plan)
+		require.Error(t, err)
+		assert.Equal(t, []string{"generated"}, siblings(t, outDir))
+
+		after, err := os.ReadFile(filepath.Join(outDir, toolsPath))
+		require.NoError(t, err)
+		assert.Equal(t, string(before), string(after))
+
+		_, err = os.Stat(filepath.Join(outDir, "blocker"))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
@@ -6,59 +6,96 @@ import (
 	"strings"
 
 	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/pkg/schema"
 	"github.com/alesr/mcpgen/internal/pkg/utils"
 )
 
+// TemplateData is what every template, embedded or custom, is executed
+// with. It is a stable contract for custom templates: fields are only
+// added, never renamed, removed or given a different meaning.
 type TemplateData struct {
-	Module            string
-	ServerName        string
+	// Module is the Go module path of the project.
+	Module string
+	// ServerName is the server name as a directory and binary name.
+	ServerName string
+	// ServerDisplayName is the server name as written in the config.
 	ServerDisplayName string
 	ServerTitle       string
 	ServerVersion     string
-	Instructions      string
-	Transport         TransportData
-	Tools             []ToolData
-	Resources         []ResourceData
-	Prompts           []PromptData
+	// Instructions is the server description sent to clients.
+	Instructions string
+	Transport    TransportData
+	Tools        []ToolData
+	Resources    []ResourceData
+	Prompts      []PromptData
+	// ToolTypes is the Go source declaring the input and output types of
+	// every tool.
+	ToolTypes string
 }
 
+// TransportData is the transport of the server.
 type TransportData struct {
-	Type     string
+	// Type is "stdio" or "http".
+	Type string
+	// HTTPPort is set for the http transport.
 	HTTPPort int
+	// PortEnv is the environment variable that overrides HTTPPort when
+	// the server starts.
+	PortEnv string
 }
 
+// ToolData is one tool of the config.
 type ToolData struct {
-	ID           string
-	GoName       string
-	Title        string
-	Description  string
+	ID string
+	// GoName is ID as an exported Go identifier.
+	GoName      string
+	Title       string
+	Description string
+	// InputSchema and OutputSchema are compact JSON, "{}" when unset.
 	InputSchema  string
 	OutputSchema string
+	// InputType and OutputType name the Go types declared in ToolTypes.
+	InputType  string
+	OutputType string
+	// OutputSample is a JSON value conforming to OutputSchema, or empty
+	// when the generic stub payload already does.
+	OutputSample string
 }
 
+// ResourceData is one resource of the config.
 type ResourceData struct {
-	ID          string
+	ID string
+	// GoName is ID as an exported Go identifier.
 	GoName      string
 	Title       string
 	Description string
+	// Only one of URI and URITemplate is set.
 	URI         string
 	URITemplate string
 	MIMEType    string
 	Text        string
-	TestURI     string
+	// TestURI is a concrete URI the resource answers, with {id} of
+	// URITemplate replaced by ID.
+	TestURI string
 }
 
+// PromptData is one prompt of the config.
 type PromptData struct {
-	ID           string
-	GoName       string
-	Title        string
-	Description  string
-	Template     string
-	Role         string
-	Arguments    []PromptArgData
+	ID string
+	// GoName is ID as an exported Go identifier.
+	GoName      string
+	Title       string
+	Description string
+	Template    string
+	Role        string
+	Arguments   []PromptArgData
+	// RequiredArgs lists the names of the required arguments, sorted.
 	RequiredArgs []string
+	// OptionalArgs lists the names of the other arguments, sorted.
+	OptionalArgs []string
 }
 
+// PromptArgData is one argument of a prompt.
 type PromptArgData struct {
 	Name        string
 	Title       string
@@ -77,23 +114,28 @@ func buildTemplateData(cfg *config.Config, serverName string) TemplateData {
 		Transport: TransportData{
 			Type:     cfg.Transport.Type,
 			HTTPPort: cfg.Transport.HTTPPort,
+			PortEnv:  config.HTTPPortEnv,
 		},
 	}
 
-	if cfg.Tool != nil {
-		tool := *cfg.Tool
+	for _, tool := range cfg.Tools {
+		goName := utils.GoIdent(tool.ID)
+		outputSchema := normalizeJSON(tool.OutputSchema)
 		data.Tools = append(data.Tools, ToolData{
 			ID:           tool.ID,
-			GoName:       utils.GoIdent(tool.ID),
+			GoName:       goName,
 			Title:        tool.Title,
 			Description:  tool.Description,
 			InputSchema:  normalizeJSON(tool.InputSchema),
-			OutputSchema: normalizeJSON(tool.OutputSchema),
+			OutputSchema: outputSchema,
+			InputType:    goName + "Input",
+			OutputType:   goName + "Output",
+			OutputSample: outputSample(tool.ID, outputSchema),
 		})
 	}
+	data.ToolTypes = buildToolTypes(data.Tools)
 
-	if cfg.Resource != nil {
-		res := *cfg.Resource
+	for _, res := range cfg.Resources {
 		testURI := res.URI
 		if res.URITemplate != "" {
 			testURI = strings.ReplaceAll(res.URITemplate, "{id}", res.ID)
@@ -112,8 +154,7 @@ func buildTemplateData(cfg *config.Config, serverName string) TemplateData {
 		})
 	}
 
-	if cfg.Prompt != nil {
-		prompt := *cfg.Prompt
+	for _, prompt := range cfg.Prompts {
 		p := PromptData{
 			ID:          prompt.ID,
 			GoName:      utils.GoIdent(prompt.ID),
@@ -133,14 +174,59 @@ func buildTemplateData(cfg *config.Config, serverName string) TemplateData {
 
 			if arg.Required {
 				p.RequiredArgs = append(p.RequiredArgs, arg.Name)
+			} else {
+				p.OptionalArgs = append(p.OptionalArgs, arg.Name)
 			}
 		}
 		sort.Strings(p.RequiredArgs)
+		sort.Strings(p.OptionalArgs)
 		data.Prompts = append(data.Prompts, p)
 	}
 	return data
 }
 
+// buildToolTypes declares the typed input and output of every tool.
+// Names used by the handlers template are reserved up front, so types
+// generated for nested schemas never shadow them.
+func buildToolTypes(tools []ToolData) string {
+	if len(tools) == 0 {
+		return ""
+	}
+
+	reserved := []string{"Handlers", "New"}
+	for _, t := range tools {
+		reserved = append(reserved, t.InputType, t.OutputType, "ToolNameFallback"+t.GoName)
+	}
+
+	g := newTypeGen(reserved...)
+	for _, t := range tools {
+		g.rootType(t.InputType, t.InputType+" is the input of the "+t.ID+" tool.", t.InputSchema)
+		g.rootType(t.OutputType, t.OutputType+" is the output of the "+t.ID+" tool.", t.OutputSchema)
+	}
+	return g.source()
+}
+
+// outputSample returns the JSON placeholder a stub handler answers with.
+// It is empty when the generic stub payload already satisfies the schema,
+// so open-ended outputs keep reporting the tool name and status.
+func outputSample(id, outputSchema string) string {
+	generic := map[string]any{"tool": id, "status": "ok", "message": "stub response"}
+	if schema.Validate(outputSchema, generic) == nil {
+		return ""
+	}
+
+	sample, err := schema.Sample(outputSchema)
+	if err != nil {
+		return ""
+	}
+
+	data, err := json.Marshal(sample)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
 // normalizeJSON returns a canonical JSON string when possible
 // if raw is blank, it returns "{}". If cannot be parsed or re-marshaled,
 // it returns raw unchanged to preserve the original input.
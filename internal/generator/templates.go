@@ -3,29 +3,278 @@ package generator
 import (
 	"bytes"
 	"embed"
+	"errors"
 	"fmt"
+	"io/fs"
+	"path"
 	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"text/template"
+
+	"github.com/BurntSushi/toml"
+	"github.com/alesr/mcpgen/internal/manifest"
 )
 
 //go:embed templates/*.gotmpl
 var templateFS embed.FS
 
-func RenderTemplate(name string, data TemplateData) ([]byte, error) {
-	tmpl, err := template.New(name).Funcs(templateFuncs()).ParseFS(templateFS, "templates/*.gotmpl")
+// TemplatesFile declares the extra templates of a custom template directory.
+const TemplatesFile = "templates.toml"
+
+const templateExt = ".gotmpl"
+
+var (
+	// ErrUnknownTemplate is returned for a .gotmpl file in a custom template
+	// directory that neither overrides an embedded template nor is declared
+	// in its templates.toml.
+	ErrUnknownTemplate = errors.New("unknown template")
+	// ErrInvalidExtraTemplate is returned for a templates.toml entry that
+	// cannot be rendered.
+	ErrInvalidExtraTemplate = errors.New("invalid extra template")
+)
+
+// ExtraTemplate is a template of a custom directory that renders a file
+// the embedded templates do not, declared as a [[file]] of templates.toml.
+type ExtraTemplate struct {
+	// Template is the name of the .gotmpl file in the directory.
+	Template string `toml:"template"`
+	// Dest is the slash-separated path of the file in the project. It is
+	// itself a template executed with TemplateData, e.g.
+	// "cmd/{{.ServerName}}/version.go".
+	Dest string `toml:"dest"`
+	// When is a template pipeline executed with TemplateData, e.g. ".Tools"
+	// or "eq .Transport.Type \"http\"". The file is rendered when it is true
+	// in the sense of {{if}}. Empty means always.
+	When string `toml:"when"`
+	// Owner is "generator" (the default) for files rewritten on every run,
+	// or "user" for files created once and then left to the user.
+	Owner string `toml:"owner"`
+}
+
+// templateSet is the embedded templates with the overrides and extra
+// templates of a custom directory applied.
+type templateSet struct {
+	tmpl *template.Template
+	// overridden lists the embedded templates replaced by the directory.
+	overridden []string
+	extra      []ExtraTemplate
+}
+
+// embeddedTemplates parses the embedded templates once per process. The
+// result is only executed, never modified: custom sets parse into a clone.
+var embeddedTemplates = sync.OnceValues(func() (*template.Template, error) {
+	return template.New("").Funcs(templateFuncs()).ParseFS(templateFS, "templates/*"+templateExt)
+})
+
+// loadTemplates returns the embedded templates with the .gotmpl files of
+// dir parsed over them. A nil dir leaves the embedded templates as they are.
+// The set is safe for concurrent rendering.
+func loadTemplates(dir fs.FS) (*templateSet, error) {
+	embedded, err := embeddedTemplates()
 	if err != nil {
 		return nil, err
 	}
+	if dir == nil {
+		return &templateSet{tmpl: embedded}, nil
+	}
+
+	tmpl, err := embedded.Clone()
+	if err != nil {
+		return nil, err
+	}
+	set := &templateSet{tmpl: tmpl}
+
+	if set.extra, err = readExtraTemplates(dir); err != nil {
+		return nil, err
+	}
 
+	declared := make(map[string]bool, len(set.extra))
+	for _, e := range set.extra {
+		declared[e.Template] = true
+	}
+
+	entries, err := fs.ReadDir(dir, ".")
+	if err != nil {
+		return nil, fmt.Errorf("could not read template directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || path.Ext(name) != templateExt {
+			continue
+		}
+
+		embedded := tmpl.Lookup(name) != nil
+		if !embedded && !declared[name] {
+			return nil, fmt.Errorf("%w: %s overrides no embedded template and is not declared in %s", ErrUnknownTemplate, name, TemplatesFile)
+		}
+
+		content, err := fs.ReadFile(dir, name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tmpl.New(name).Parse(string(content)); err != nil {
+			return nil, fmt.Errorf("could not parse template %s: %w", name, err)
+		}
+
+		if embedded {
+			set.overridden = append(set.overridden, name)
+		}
+	}
+
+	for _, e := range set.extra {
+		if tmpl.Lookup(e.Template) == nil {
+			return nil, fmt.Errorf("%w: %s declares %s, which does not exist", ErrInvalidExtraTemplate, TemplatesFile, e.Template)
+		}
+	}
+
+	sort.Strings(set.overridden)
+	return set, nil
+}
+
+// readExtraTemplates decodes the templates.toml of dir, if any.
+func readExtraTemplates(dir fs.FS) ([]ExtraTemplate, error) {
+	raw, err := fs.ReadFile(dir, TemplatesFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded struct {
+		Files []ExtraTemplate `toml:"file"`
+	}
+	md, err := toml.Decode(string(raw), &decoded)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode %s: %w", TemplatesFile, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, 0, len(undecoded))
+		for _, k := range undecoded {
+			keys = append(keys, k.String())
+		}
+		return nil, fmt.Errorf("%w: unknown keys in %s: %s", ErrInvalidExtraTemplate, TemplatesFile, strings.Join(keys, ", "))
+	}
+
+	var errs []error
+	seen := make(map[string]bool, len(decoded.Files))
+	for i, e := range decoded.Files {
+		switch {
+		case path.Ext(e.Template) != templateExt || strings.Contains(e.Template, "/"):
+			errs = append(errs, fmt.Errorf("%w: file %d: template must name a %s file of the directory", ErrInvalidExtraTemplate, i+1, templateExt))
+		case isEmbeddedTemplate(e.Template):
+			errs = append(errs, fmt.Errorf("%w: %s is an embedded template; override it without declaring it", ErrInvalidExtraTemplate, e.Template))
+		case seen[e.Template]:
+			errs = append(errs, fmt.Errorf("%w: %s is declared twice", ErrInvalidExtraTemplate, e.Template))
+		}
+		seen[e.Template] = true
+
+		if strings.TrimSpace(e.Dest) == "" {
+			errs = append(errs, fmt.Errorf("%w: %s has no dest", ErrInvalidExtraTemplate, e.Template))
+		}
+		switch e.Owner {
+		case "", manifest.OwnerGenerator, manifest.OwnerUser:
+		default:
+			errs = append(errs, fmt.Errorf("%w: %s has owner %q (expected generator or user)", ErrInvalidExtraTemplate, e.Template, e.Owner))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return decoded.Files, nil
+}
+
+func isEmbeddedTemplate(name string) bool {
+	_, err := fs.Stat(templateFS, "templates/"+name)
+	return err == nil
+}
+
+// jobs returns the jobs of the extra templates whose condition holds.
+func (s *templateSet) jobs(data TemplateData) ([]templateJob, error) {
+	out := make([]templateJob, 0, len(s.extra))
+	for _, e := range s.extra {
+		ok, err := condition(e.When, data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: when of %s: %v", ErrInvalidExtraTemplate, e.Template, err)
+		}
+		if !ok {
+			continue
+		}
+
+		dest, err := execute(e.Dest, data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: dest of %s: %v", ErrInvalidExtraTemplate, e.Template, err)
+		}
+		if !fs.ValidPath(dest) || dest == "." {
+			return nil, fmt.Errorf("%w: dest of %s is %q, not a relative slash-separated path", ErrInvalidExtraTemplate, e.Template, dest)
+		}
+
+		owner := ownedByGenerator
+		if e.Owner == manifest.OwnerUser {
+			owner = ownedByUser
+		}
+		out = append(out, templateJob{src: e.Template, dest: dest, owner: owner})
+	}
+	return out, nil
+}
+
+// condition reports whether the pipeline when is true for data in the
+// sense of {{if}}. An empty pipeline is always true.
+func condition(when string, data TemplateData) (bool, error) {
+	if strings.TrimSpace(when) == "" {
+		return true, nil
+	}
+
+	out, err := execute("{{if "+when+"}}true{{end}}", data)
+	if err != nil {
+		return false, err
+	}
+	return out == "true", nil
+}
+
+// execute runs an inline template with the template functions.
+func execute(text string, data TemplateData) (string, error) {
+	t, err := template.New("").Funcs(templateFuncs()).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf strings.Builder
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (s *templateSet) render(name string, data TemplateData) ([]byte, error) {
 	var buf bytes.Buffer
-	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+	if err := s.tmpl.ExecuteTemplate(&buf, name, data); err != nil {
 		return nil, fmt.Errorf("render %s: %w", name, err)
 	}
 	return buf.Bytes(), nil
 }
 
+// RenderTemplate renders one of the embedded templates.
+func RenderTemplate(name string, data TemplateData) ([]byte, error) {
+	set, err := loadTemplates(nil)
+	if err != nil {
+		return nil, err
+	}
+	return set.render(name, data)
+}
+
+// templateFuncs are the functions every template, embedded or custom, can
+// call. Like TemplateData they are a stable contract: functions are only
+// added, never renamed, removed or changed.
+//
+//   - quote returns a Go string literal for its argument (strconv.Quote).
+//   - join joins a []string with a separator (strings.Join).
+//   - hasRequiredArgs reports whether any of the given prompts has a
+//     required argument.
 func templateFuncs() template.FuncMap {
 	return template.FuncMap{
 		"quote":           strconv.Quote,
@@ -0,0 +1,192 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/pkg/outfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_Run_CustomTemplates(t *testing.T) {
+	t.Parallel()
+
+	newConfig := func() *config.Config {
+		cfg := &config.Config{
+			Server: config.ServerConfig{Name: "weather"},
+			Tools:  []config.ToolConfig{{ID: "forecast"}},
+		}
+		require.NoError(t, cfg.Validate())
+		return cfg
+	}
+
+	templates := fstest.MapFS{
+		"instructions.go.gotmpl": {Data: []byte("// Copyright ACME.\n\n// Code generated by mcpgen. DO NOT EDIT.\npackage mcpapp\n\nconst Instructions = {{ quote .Instructions }}\n")},
+		"license.gotmpl":         {Data: []byte("Copyright ACME. {{ .ServerDisplayName }}\n")},
+		"tools.md.gotmpl":        {Data: []byte("{{ range .Tools }}- {{ .ID }}\n{{ end }}")},
+		"http.go.gotmpl":         {Data: []byte("package mcpapp\n")},
+		TemplatesFile: {Data: []byte(`
+[[file]]
+template = "license.gotmpl"
+dest = "LICENSE"
+owner = "user"
+
+[[file]]
+template = "tools.md.gotmpl"
+dest = "docs/{{ .ServerName }}-tools.md"
+when = ".Tools"
+
+[[file]]
+template = "http.go.gotmpl"
+dest = "internal/mcpapp/http.go"
+when = "eq .Transport.Type \"http\""
+`)},
+	}
+
+	mem := outfs.NewMemory()
+	gen := &Generator{Config: newConfig(), OutDir: "weather", FS: mem, Templates: templates}
+
+	plan, err := gen.Plan()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"instructions.go.gotmpl"}, plan.Overridden)
+	require.NoError(t, gen.Apply(plan))
+
+	files := mem.Files()
+	assert.Contains(t, string(files["internal/mcpapp/instructions.go"]), "// Copyright ACME.")
+	assert.Equal(t, "Copyright ACME. weather\n", string(files["LICENSE"]))
+	assert.Equal(t, "- forecast\n", string(files["docs/weather-tools.md"]))
+	assert.NotContains(t, files, "internal/mcpapp/http.go", "the condition does not hold for stdio")
+
+	require.NoError(t, mem.WriteFile("LICENSE", []byte("edited\n")))
+
+	noTools := newConfig()
+	noTools.Tools = nil
+	require.NoError(t, (&Generator{Config: noTools, OutDir: "weather", FS: mem, Templates: templates}).Run())
+
+	files = mem.Files()
+	assert.NotContains(t, files, "docs/weather-tools.md", "generator-owned extra files go with their condition")
+	assert.Equal(t, "edited\n", string(files["LICENSE"]), "user-owned extra files are kept")
+
+	t.Run("invalid directories", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name      string
+			templates fstest.MapFS
+			expected  error
+		}{
+			{
+				name:      "undeclared template",
+				templates: fstest.MapFS{"extra.gotmpl": {Data: []byte("x")}},
+				expected:  ErrUnknownTemplate,
+			},
+			{
+				name: "declared template is missing",
+				templates: fstest.MapFS{TemplatesFile: {Data: []byte(`
+[[file]]
+template = "missing.gotmpl"
+dest = "MISSING"
+`)}},
+				expected: ErrInvalidExtraTemplate,
+			},
+			{
+				name: "unknown owner",
+				templates: fstest.MapFS{
+					"extra.gotmpl": {Data: []byte("x")},
+					TemplatesFile: {Data: []byte(`
+[[file]]
+template = "extra.gotmpl"
+dest = "EXTRA"
+owner = "nobody"
+`)},
+				},
+				expected: ErrInvalidExtraTemplate,
+			},
+			{
+				name: "dest taken by an embedded template",
+				templates: fstest.MapFS{
+					"extra.gotmpl": {Data: []byte("x")},
+					TemplatesFile: {Data: []byte(`
+[[file]]
+template = "extra.gotmpl"
+dest = "go.mod"
+`)},
+				},
+				expected: ErrInvalidExtraTemplate,
+			},
+			{
+				name: "dest outside the project",
+				templates: fstest.MapFS{
+					"extra.gotmpl": {Data: []byte("x")},
+					TemplatesFile: {Data: []byte(`
+[[file]]
+template = "extra.gotmpl"
+dest = "../EXTRA"
+`)},
+				},
+				expected: ErrInvalidExtraTemplate,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				gen := &Generator{Config: newConfig(), FS: outfs.NewMemory(), Templates: tt.templates}
+				_, err := gen.Plan()
+				assert.ErrorIs(t, err, tt.expected)
+			})
+		}
+	})
+}
+
+func TestGenerator_Plan_RenderErrors(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{Server: config.ServerConfig{Name: "weather"}}
+	require.NoError(t, cfg.Validate())
+
+	templates := fstest.MapFS{
+		"mcpapp.go.gotmpl":       {Data: []byte("{{ .NoSuchField }}")},
+		"instructions.go.gotmpl": {Data: []byte("{{ .NoSuchField }}")},
+	}
+
+	var messages []string
+	for range 5 {
+		_, err := (&Generator{Config: cfg, FS: outfs.NewMemory(), Templates: templates}).Plan()
+		require.Error(t, err)
+		messages = append(messages, err.Error())
+	}
+
+	assert.Contains(t, messages[0], "instructions.go.gotmpl")
+	assert.Contains(t, messages[0], "mcpapp.go.gotmpl")
+	assert.Less(t, strings.Index(messages[0], "instructions.go.gotmpl"), strings.Index(messages[0], "mcpapp.go.gotmpl"), "errors follow job order")
+	for _, m := range messages[1:] {
+		assert.Equal(t, messages[0], m)
+	}
+}
+
+func BenchmarkGenerator_Plan(b *testing.B) {
+	cfg := &config.Config{Server: config.ServerConfig{Name: "bench"}}
+	for i := range 300 {
+		cfg.Tools = append(cfg.Tools, config.ToolConfig{
+			ID:           fmt.Sprintf("tool_%d", i),
+			InputSchema:  `{"type":"object","properties":{"query":{"type":"string"},"limit":{"type":"integer"}},"required":["query"]}`,
+			OutputSchema: `{"type":"object","properties":{"results":{"type":"array","items":{"type":"string"}}}}`,
+		})
+	}
+	require.NoError(b, cfg.Validate())
+
+	gen := &Generator{Config: cfg, FS: outfs.NewMemory()}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := gen.Plan(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package inspector
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/pkg/schema"
+	"github.com/yosida95/uritemplate/v3"
+)
+
+// ErrInvalidResult means a server answered a call with a result that
+// does not match its config.
+var ErrInvalidResult = errors.New("invalid result")
+
+// placeholder is the value given to required prompt arguments and to the
+// variables of URI templates.
+const placeholder = "example"
+
+type inspectorCall struct {
+	method string
+	// target is the tool, prompt or resource URI the call is about.
+	target string
+	// args are the arguments of tools/call and prompts/get.
+	args      map[string]any
+	extraArgs []string
+	// check validates the JSON result of the call.
+	check func(result []byte) error
+}
+
+// name names the call in output and reports.
+func (c inspectorCall) name() string {
+	if c.target == "" {
+		return c.method
+	}
+	return c.method + " " + c.target
+}
+
+// planCalls lists the calls that exercise cfg: the list methods of every
+// enabled feature, whose results must match cfg, then a call of every
+// tool, prompt and resource.
+func planCalls(cfg *config.Config) ([]inspectorCall, error) {
+	calls := make([]inspectorCall, 0)
+
+	if len(cfg.Tools) > 0 {
+		calls = append(calls, inspectorCall{method: "tools/list", check: checkToolList(cfg.Tools)})
+	}
+
+	if len(cfg.Resources) > 0 {
+		calls = append(calls, inspectorCall{method: "resources/list", check: checkResourceList(cfg.Resources, false)})
+	}
+
+	if slices.ContainsFunc(cfg.Resources, func(r config.ResourceConfig) bool { return r.URITemplate != "" }) {
+		calls = append(calls, inspectorCall{method: "resources/templates/list", check: checkResourceList(cfg.Resources, true)})
+	}
+
+	if len(cfg.Prompts) > 0 {
+		calls = append(calls, inspectorCall{method: "prompts/list", check: checkPromptList(cfg.Prompts)})
+	}
+
+	var errs []error
+	for _, t := range cfg.Tools {
+		call, err := toolCall(t)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		calls = append(calls, call)
+	}
+
+	for _, p := range cfg.Prompts {
+		calls = append(calls, promptCall(p))
+	}
+
+	for _, r := range cfg.Resources {
+		call, err := resourceCall(r)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		calls = append(calls, call)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return calls, nil
+}
+
+// toolCall calls t with arguments sampled from its input schema and
+// checks the result against its output schema.
+func toolCall(t config.ToolConfig) (inspectorCall, error) {
+	args := map[string]any{}
+	if t.InputSchema != "" {
+		sample, err := schema.Sample(t.InputSchema)
+		if err != nil {
+			return inspectorCall{}, fmt.Errorf("tool %q input_schema: %w", t.ID, err)
+		}
+		if m, ok := sample.(map[string]any); ok {
+			args = m
+		}
+	}
+
+	extra := []string{"--tool-name", t.ID}
+	if len(args) > 0 {
+		extra = append(extra, "--tool-arg")
+		extra = append(extra, keyValues(args)...)
+	}
+
+	return inspectorCall{
+		method:    "tools/call",
+		target:    t.ID,
+		args:      args,
+		extraArgs: extra,
+		check:     checkToolResult(t),
+	}, nil
+}
+
+// promptCall gets p with every declared argument set, since its template
+// may use optional ones too, and checks that it returns messages.
+func promptCall(p config.PromptConfig) inspectorCall {
+	args := map[string]any{}
+	for _, arg := range p.Arguments {
+		args[arg.Name] = placeholder
+	}
+
+	extra := []string{"--prompt-name", p.ID}
+	if len(args) > 0 {
+		extra = append(extra, "--prompt-args")
+		extra = append(extra, keyValues(args)...)
+	}
+
+	return inspectorCall{
+		method:    "prompts/get",
+		target:    p.ID,
+		args:      args,
+		extraArgs: extra,
+		check:     checkPromptResult,
+	}
+}
+
+// resourceCall reads r, at its URI or at its URI template expanded with
+// placeholders, and checks the URI and MIME type of the contents.
+func resourceCall(r config.ResourceConfig) (inspectorCall, error) {
+	uri := r.URI
+	if r.URITemplate != "" {
+		tmpl, err := uritemplate.New(r.URITemplate)
+		if err != nil {
+			return inspectorCall{}, fmt.Errorf("resource %q uri_template: %w", r.ID, err)
+		}
+
+		values := uritemplate.Values{}
+		for _, name := range tmpl.Varnames() {
+			values.Set(name, uritemplate.String(placeholder))
+		}
+
+		if uri, err = tmpl.Expand(values); err != nil {
+			return inspectorCall{}, fmt.Errorf("resource %q uri_template: %w", r.ID, err)
+		}
+	}
+
+	return inspectorCall{
+		method:    "resources/read",
+		target:    uri,
+		extraArgs: []string{"--uri", uri},
+		check:     checkResourceResult(uri, r.MIMEType),
+	}, nil
+}
+
+// keyValues renders args as the key=value pairs of the inspector CLI,
+// which reads values as JSON when they parse as JSON.
+func keyValues(args map[string]any) []string {
+	pairs := make([]string, 0, len(args))
+	for _, k := range slices.Sorted(maps.Keys(args)) {
+		v, ok := args[k].(string)
+		if !ok || json.Valid([]byte(v)) {
+			raw, _ := json.Marshal(args[k])
+			v = string(raw)
+		}
+		pairs = append(pairs, k+"="+v)
+	}
+	return pairs
+}
+
+func checkToolResult(t config.ToolConfig) func([]byte) error {
+	return func(result []byte) error {
+		var res struct {
+			Content []struct {
+				Text string `json:"text"`
+			} `json:"content"`
+			StructuredContent json.RawMessage `json:"structuredContent"`
+			IsError           bool            `json:"isError"`
+		}
+		if err := json.Unmarshal(result, &res); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidResult, err)
+		}
+
+		if res.IsError {
+			var texts []string
+			for _, c := range res.Content {
+				texts = append(texts, c.Text)
+			}
+			return fmt.Errorf("%w: tool %q returned an error: %s", ErrInvalidResult, t.ID, strings.Join(texts, "; "))
+		}
+
+		if t.OutputSchema == "" {
+			return nil
+		}
+
+		if len(res.StructuredContent) == 0 || string(res.StructuredContent) == "null" {
+			return fmt.Errorf("%w: tool %q has an output schema but returned no structured content", ErrInvalidResult, t.ID)
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(res.StructuredContent))
+		dec.UseNumber()
+
+		var structured any
+		if err := dec.Decode(&structured); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidResult, err)
+		}
+
+		if err := schema.Validate(t.OutputSchema, structured); err != nil {
+			return fmt.Errorf("%w: tool %q structured content does not match its output schema: %v", ErrInvalidResult, t.ID, err)
+		}
+		return nil
+	}
+}
+
+func checkPromptResult(result []byte) error {
+	var res struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(result, &res); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidResult, err)
+	}
+
+	if len(res.Messages) == 0 {
+		return fmt.Errorf("%w: no messages", ErrInvalidResult)
+	}
+
+	for i, m := range res.Messages {
+		if m.Role != "user" && m.Role != "assistant" {
+			return fmt.Errorf("%w: message %d has role %q", ErrInvalidResult, i, m.Role)
+		}
+		if m.Content.Type == "" || m.Content.Type == "text" && strings.TrimSpace(m.Content.Text) == "" {
+			return fmt.Errorf("%w: message %d is empty", ErrInvalidResult, i)
+		}
+	}
+	return nil
+}
+
+func checkResourceResult(uri, mimeType string) func([]byte) error {
+	return func(result []byte) error {
+		var res struct {
+			Contents []struct {
+				URI      string `json:"uri"`
+				MIMEType string `json:"mimeType"`
+			} `json:"contents"`
+		}
+		if err := json.Unmarshal(result, &res); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidResult, err)
+		}
+
+		if len(res.Contents) == 0 {
+			return fmt.Errorf("%w: no contents", ErrInvalidResult)
+		}
+
+		if res.Contents[0].URI != uri {
+			return fmt.Errorf("%w: contents have URI %q, want %q", ErrInvalidResult, res.Contents[0].URI, uri)
+		}
+
+		for _, c := range res.Contents {
+			if mimeType != "" && c.MIMEType != mimeType {
+				return fmt.Errorf("%w: contents of %q have MIME type %q, want %q", ErrInvalidResult, c.URI, c.MIMEType, mimeType)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,198 @@
+package inspector
+
+import (
+	"testing"
+
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanCalls(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{
+		Tools: []config.ToolConfig{{
+			ID:          "forecast",
+			InputSchema: `{"type":"object","properties":{"city":{"type":"string"},"days":{"type":"integer","minimum":2},"units":{"type":"string"}},"required":["city","days"]}`,
+		}},
+		Prompts: []config.PromptConfig{{
+			ID:        "report",
+			Arguments: []config.PromptArgumentConfig{{Name: "city", Required: true}, {Name: "tone"}},
+		}},
+		Resources: []config.ResourceConfig{{ID: "station", URITemplate: "station://{id}/{?units}"}},
+	}
+
+	calls, err := planCalls(cfg)
+	require.NoError(t, err)
+
+	var names []string
+	for _, c := range calls {
+		names = append(names, c.name())
+	}
+	assert.Equal(t, []string{
+		"tools/list",
+		"resources/list",
+		"resources/templates/list",
+		"prompts/list",
+		"tools/call forecast",
+		"prompts/get report",
+		"resources/read station://example/?units=example",
+	}, names)
+
+	assert.Equal(t, map[string]any{"city": "", "days": int64(2)}, calls[4].args)
+	assert.Equal(t, []string{"--tool-name", "forecast", "--tool-arg", `city=`, "days=2"}, calls[4].extraArgs)
+
+	assert.Equal(t, map[string]any{"city": placeholder, "tone": placeholder}, calls[5].args)
+	assert.Equal(t, []string{"--prompt-name", "report", "--prompt-args", "city=example", "tone=example"}, calls[5].extraArgs)
+
+	assert.Equal(t, []string{"--uri", "station://example/?units=example"}, calls[6].extraArgs)
+}
+
+func TestPlanCalls_InvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{
+		Tools:     []config.ToolConfig{{ID: "forecast", InputSchema: `{`}},
+		Resources: []config.ResourceConfig{{ID: "station", URITemplate: "station://{id"}},
+	}
+
+	_, err := planCalls(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `tool "forecast" input_schema`)
+	assert.Contains(t, err.Error(), `resource "station" uri_template`)
+}
+
+func TestKeyValues(t *testing.T) {
+	t.Parallel()
+
+	got := keyValues(map[string]any{"b": "plain", "a": "42", "c": []any{1.0}, "d": true})
+	assert.Equal(t, []string{`a="42"`, "b=plain", "c=[1]", "d=true"}, got)
+}
+
+func TestCheckToolResult(t *testing.T) {
+	t.Parallel()
+
+	tool := config.ToolConfig{
+		ID:           "forecast",
+		OutputSchema: `{"type":"object","properties":{"days":{"type":"integer"}},"required":["days"]}`,
+	}
+
+	tests := []struct {
+		name    string
+		tool    config.ToolConfig
+		result  string
+		wantErr string
+	}{
+		{
+			name:   "matches output schema",
+			tool:   tool,
+			result: `{"content":[{"type":"text","text":"{}"}],"structuredContent":{"days":3}}`,
+		},
+		{
+			name:   "no output schema",
+			tool:   config.ToolConfig{ID: "forecast"},
+			result: `{"content":[{"type":"text","text":"ok"}]}`,
+		},
+		{
+			name:    "tool error",
+			tool:    tool,
+			result:  `{"content":[{"type":"text","text":"boom"}],"isError":true}`,
+			wantErr: `tool "forecast" returned an error: boom`,
+		},
+		{
+			name:    "missing structured content",
+			tool:    tool,
+			result:  `{"content":[]}`,
+			wantErr: "returned no structured content",
+		},
+		{
+			name:    "schema mismatch",
+			tool:    tool,
+			result:  `{"structuredContent":{"days":1.5}}`,
+			wantErr: "does not match its output schema",
+		},
+		{
+			name:    "not JSON",
+			tool:    tool,
+			result:  `Error: connection closed`,
+			wantErr: "invalid result",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := checkToolResult(tt.tool)([]byte(tt.result))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInvalidResult)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestCheckPromptResult(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		result  string
+		wantErr string
+	}{
+		{name: "text message", result: `{"messages":[{"role":"user","content":{"type":"text","text":"hi"}}]}`},
+		{name: "image message", result: `{"messages":[{"role":"assistant","content":{"type":"image","data":"AA==","mimeType":"image/png"}}]}`},
+		{name: "no messages", result: `{"messages":[]}`, wantErr: "no messages"},
+		{name: "empty text", result: `{"messages":[{"role":"user","content":{"type":"text","text":"  "}}]}`, wantErr: "message 0 is empty"},
+		{name: "no content", result: `{"messages":[{"role":"user"}]}`, wantErr: "message 0 is empty"},
+		{name: "bad role", result: `{"messages":[{"role":"system","content":{"type":"text","text":"hi"}}]}`, wantErr: `message 0 has role "system"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := checkPromptResult([]byte(tt.result))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInvalidResult)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestCheckResourceResult(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		mimeType string
+		result   string
+		wantErr  string
+	}{
+		{name: "matches", mimeType: "text/plain", result: `{"contents":[{"uri":"docs://a","mimeType":"text/plain","text":"a"}]}`},
+		{name: "no MIME type configured", result: `{"contents":[{"uri":"docs://a","text":"a"}]}`},
+		{name: "no contents", result: `{"contents":[]}`, wantErr: "no contents"},
+		{name: "other URI", result: `{"contents":[{"uri":"docs://b"}]}`, wantErr: `URI "docs://b", want "docs://a"`},
+		{name: "other MIME type", mimeType: "text/plain", result: `{"contents":[{"uri":"docs://a","mimeType":"text/html"}]}`, wantErr: `MIME type "text/html", want "text/plain"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := checkResourceResult("docs://a", tt.mimeType)([]byte(tt.result))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInvalidResult)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
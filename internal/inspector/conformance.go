@@ -0,0 +1,346 @@
+package inspector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/alesr/mcpgen/internal/checks"
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/pkg/utils"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ErrNonConformant means a server answered a conformance case in a way
+// the MCP or JSON-RPC specification does not allow.
+var ErrNonConformant = errors.New("not conformant")
+
+const (
+	// latestVersion is the newest MCP protocol version the go-sdk speaks.
+	latestVersion = "2025-06-18"
+	// batchVersion is the last protocol version with JSON-RPC batches.
+	batchVersion = "2025-03-26"
+	// oldestVersion is the first MCP protocol version.
+	oldestVersion = "2024-11-05"
+	// unknownVersion is a protocol version no server supports.
+	unknownVersion = "1999-01-01"
+
+	// unknownName names no tool or prompt, and unknownURI no resource.
+	unknownName = "mcpgen-conformance-unknown"
+	unknownURI  = "mcpgen://conformance/unknown"
+)
+
+// caseTimeout bounds a conformance case, so a server that never answers
+// fails the case instead of the run.
+const caseTimeout = 10 * time.Second
+
+// codeNames names the error codes conformance cases expect.
+var codeNames = map[int]string{
+	jsonrpc.CodeInvalidParams: "invalid params",
+	mcp.CodeResourceNotFound:  "resource not found",
+}
+
+// conformanceCase is a JSON-RPC exchange with a server over a session of
+// its own.
+type conformanceCase struct {
+	name string
+	// skip says why the case does not apply to the config, if it does not.
+	skip string
+	run  func(ctx context.Context, s *rpcSession) error
+}
+
+// planConformance lists the conformance cases of cfg. Those about tools,
+// prompts or resources are skipped when cfg has none.
+func planConformance(cfg *config.Config) []conformanceCase {
+	var noTools, noPrompts, noRequiredArgs, noResources string
+	if len(cfg.Tools) == 0 {
+		noTools = "no tools"
+	}
+	if len(cfg.Prompts) == 0 {
+		noPrompts = "no prompts"
+	}
+	if len(cfg.Resources) == 0 {
+		noResources = "no resources"
+	}
+
+	var tool, prompt string
+	if len(cfg.Tools) > 0 {
+		tool = cfg.Tools[0].ID
+	}
+
+	i := slices.IndexFunc(cfg.Prompts, func(p config.PromptConfig) bool {
+		return slices.ContainsFunc(p.Arguments, func(a config.PromptArgumentConfig) bool { return a.Required })
+	})
+	if i < 0 {
+		noRequiredArgs = "no prompt has required arguments"
+	} else {
+		prompt = cfg.Prompts[i].ID
+	}
+
+	return []conformanceCase{
+		{name: "initialize " + latestVersion, run: negotiate(latestVersion)},
+		{name: "initialize " + oldestVersion, run: negotiate(oldestVersion)},
+		{name: "initialize unsupported version", run: negotiateUnsupported},
+		{
+			name: "tools/call unknown tool", skip: noTools,
+			run: expectError("tools/call", map[string]any{"name": unknownName}, jsonrpc.CodeInvalidParams),
+		},
+		{
+			name: "tools/call invalid arguments", skip: noTools,
+			run: expectError("tools/call", map[string]any{"name": tool, "arguments": "not an object"}, jsonrpc.CodeInvalidParams),
+		},
+		{
+			name: "prompts/get unknown prompt", skip: noPrompts,
+			run: expectError("prompts/get", map[string]any{"name": unknownName}, jsonrpc.CodeInvalidParams),
+		},
+		{
+			name: "prompts/get missing argument", skip: noRequiredArgs,
+			run: expectError("prompts/get", map[string]any{"name": prompt}, jsonrpc.CodeInvalidParams),
+		},
+		{
+			name: "resources/read unknown URI", skip: noResources,
+			run: expectError("resources/read", map[string]any{"uri": unknownURI}, mcp.CodeResourceNotFound),
+		},
+		{name: "batch " + batchVersion, run: batchAnswered},
+		{name: "batch " + latestVersion + " rejected", run: batchRejected},
+	}
+}
+
+// negotiate asks for version, which the server supports and must agree to.
+func negotiate(version string) func(context.Context, *rpcSession) error {
+	return func(ctx context.Context, s *rpcSession) error {
+		res, err := s.initialize(ctx, version)
+		if err != nil {
+			return err
+		}
+		if res.ProtocolVersion != version {
+			return fmt.Errorf("%w: asked for protocol version %s, got %q", ErrNonConformant, version, res.ProtocolVersion)
+		}
+		return nil
+	}
+}
+
+// negotiateUnsupported asks for a version no server supports: the server
+// must offer one it does, and keep serving the session in it.
+func negotiateUnsupported(ctx context.Context, s *rpcSession) error {
+	res, err := s.initialize(ctx, unknownVersion)
+	if err != nil {
+		return err
+	}
+	if res.ProtocolVersion == "" || res.ProtocolVersion == unknownVersion {
+		return fmt.Errorf("%w: asked for unsupported protocol version %s, got %q", ErrNonConformant, unknownVersion, res.ProtocolVersion)
+	}
+
+	resp, err := s.call(ctx, "ping", nil)
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("%w: ping in negotiated protocol version %s: %v", ErrNonConformant, res.ProtocolVersion, resp.Error)
+	}
+	return nil
+}
+
+// expectError calls method with params, which the server must answer
+// with an error of code.
+func expectError(method string, params map[string]any, code int) func(context.Context, *rpcSession) error {
+	return func(ctx context.Context, s *rpcSession) error {
+		if _, err := s.initialize(ctx, latestVersion); err != nil {
+			return err
+		}
+
+		resp, err := s.call(ctx, method, params)
+		if err != nil {
+			return err
+		}
+
+		want := fmt.Sprintf("error %d (%s)", code, codeNames[code])
+		switch {
+		case resp.Error == nil:
+			return fmt.Errorf("%w: got a result, want %s", ErrNonConformant, want)
+		case resp.Error.Code != code:
+			return fmt.Errorf("%w: got %v, want %s", ErrNonConformant, resp.Error, want)
+		}
+		return nil
+	}
+}
+
+// batchAnswered sends a batch of two pings in the last protocol version
+// with batches: both must be answered.
+func batchAnswered(ctx context.Context, s *rpcSession) error {
+	if _, err := s.initialize(ctx, batchVersion); err != nil {
+		return err
+	}
+
+	batch := []rpcMessage{s.request("ping", nil), s.request("ping", nil)}
+	resps, err := s.send(ctx, batch, len(batch))
+	if err != nil {
+		return err
+	}
+
+	for _, req := range batch {
+		i := slices.IndexFunc(resps, func(resp rpcMessage) bool { return bytes.Equal(resp.ID, req.ID) })
+		switch {
+		case i < 0:
+			return fmt.Errorf("%w: no response to request %s of the batch", ErrNonConformant, req.ID)
+		case resps[i].Error != nil:
+			return fmt.Errorf("%w: request %s of the batch: %v", ErrNonConformant, req.ID, resps[i].Error)
+		}
+	}
+	return nil
+}
+
+// batchRejected sends a batch in the latest protocol version, which has
+// none: the server must not run it. It may answer with an HTTP or
+// JSON-RPC error, or close a stdio connection.
+func batchRejected(ctx context.Context, s *rpcSession) error {
+	if _, err := s.initialize(ctx, latestVersion); err != nil {
+		return err
+	}
+
+	// one error may answer the whole batch
+	batch := []rpcMessage{s.request("ping", nil), s.request("ping", nil)}
+	resps, err := s.send(ctx, batch, 1)
+
+	var status *statusError
+	switch {
+	case errors.As(err, &status) && status.code < http.StatusInternalServerError:
+		return nil
+	case errors.Is(err, errConnClosed):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	for _, resp := range resps {
+		if resp.Error == nil {
+			return fmt.Errorf("%w: answered request %s of a batch, which protocol version %s does not allow", ErrNonConformant, resp.ID, latestVersion)
+		}
+	}
+	return nil
+}
+
+// caseResult is a row of the conformance matrix.
+type caseResult struct {
+	name   string
+	status checks.Status
+	// detail is why the case failed or was skipped.
+	detail string
+}
+
+// RunConformance runs the conformance cases of cfg against the server
+// generated in outDir, over its configured transport, and prints a
+// matrix of the results to out. Every case gets a session of its own;
+// failed cases do not stop the others. The server is built once; an
+// HTTP server listens on a free port.
+func RunConformance(ctx context.Context, outDir string, cfg *config.Config, opts Options, out io.Writer) error {
+	cases := planConformance(cfg)
+
+	fmt.Fprintf(out, "Running %d conformance cases over %s\n", len(cases), cfg.Transport.Type)
+	fmt.Fprintln(out, "---")
+
+	bin, endpoint, stop, err := launch(ctx, outDir, cfg, opts.readyTimeout(), out)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	dial := func(ctx context.Context, stderr io.Writer) (rpcConn, error) {
+		if endpoint != "" {
+			return &httpConn{endpoint: endpoint}, nil
+		}
+		return dialStdio(ctx, bin, outDir, stderr)
+	}
+
+	results := make([]caseResult, 0, len(cases))
+	for _, c := range cases {
+		if c.skip != "" {
+			checks.RecordCase(ctx, checks.Result{Name: c.name, Status: checks.StatusSkipped, Reason: c.skip})
+			results = append(results, caseResult{name: c.name, status: checks.StatusSkipped, detail: c.skip})
+			continue
+		}
+
+		fmt.Fprintln(out)
+		err := runCall(ctx, inspectorCall{method: c.name}, out, func(stdout, stderr io.Writer) error {
+			ctx, cancel := context.WithTimeout(ctx, caseTimeout)
+			defer cancel()
+
+			conn, err := dial(ctx, stderr)
+			if err != nil {
+				return err
+			}
+			defer conn.close()
+
+			return c.run(ctx, &rpcSession{conn: conn, log: stdout})
+		})
+
+		res := caseResult{name: c.name, status: checks.StatusPassed}
+		if callErr := (*CallError)(nil); errors.As(err, &callErr) {
+			res.status, res.detail = checks.StatusFailed, callErr.Err.Error()
+		}
+		results = append(results, res)
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	failed := printMatrix(out, cfg, results)
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d cases failed", ErrNonConformant, failed, len(results))
+	}
+	return nil
+}
+
+// matrixLabels label the results of the conformance matrix.
+var matrixLabels = map[checks.Status]string{
+	checks.StatusPassed:  "pass",
+	checks.StatusFailed:  "FAIL",
+	checks.StatusSkipped: "skip",
+}
+
+// printMatrix prints a line per case and returns the number of failures.
+func printMatrix(out io.Writer, cfg *config.Config, results []caseResult) (failed int) {
+	width := 0
+	for _, r := range results {
+		width = max(width, len(r.name))
+	}
+
+	fmt.Fprintf(out, "\nConformance of %s over %s:\n\n", utils.DefaultServerName(cfg.Server.Name), cfg.Transport.Type)
+
+	counts := make(map[checks.Status]int)
+	for _, r := range results {
+		counts[r.status]++
+
+		label := matrixLabels[r.status]
+		if r.detail == "" {
+			fmt.Fprintf(out, "  %s  %s\n", label, r.name)
+			continue
+		}
+		fmt.Fprintf(out, "  %s  %-*s  %s\n", label, width, r.name, r.detail)
+	}
+
+	fmt.Fprintf(out, "\n%d passed, %d failed, %d skipped\n",
+		counts[checks.StatusPassed],
+		counts[checks.StatusFailed],
+		counts[checks.StatusSkipped],
+	)
+	return counts[checks.StatusFailed]
+}
+
+// ConformanceStepName names the conformance run in a check pipeline.
+const ConformanceStepName = "conformance"
+
+// ConformanceStep runs RunConformance for cfg as a step of a check
+// pipeline.
+func ConformanceStep(cfg *config.Config, opts Options) checks.Step {
+	return checks.Func(ConformanceStepName, func(ctx context.Context, dir string, out io.Writer) error {
+		return RunConformance(ctx, dir, cfg, opts, out)
+	})
+}
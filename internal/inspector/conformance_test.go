@@ -0,0 +1,182 @@
+package inspector
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alesr/mcpgen/internal/checks"
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanConformance(t *testing.T) {
+	t.Parallel()
+
+	skips := func(cfg *config.Config) map[string]string {
+		m := make(map[string]string)
+		for _, c := range planConformance(cfg) {
+			m[c.name] = c.skip
+		}
+		return m
+	}
+
+	t.Run("no features", func(t *testing.T) {
+		t.Parallel()
+
+		got := skips(&config.Config{Server: config.ServerConfig{Name: "weather"}})
+		assert.Equal(t, map[string]string{
+			"initialize 2025-06-18":          "",
+			"initialize 2024-11-05":          "",
+			"initialize unsupported version": "",
+			"tools/call unknown tool":        "no tools",
+			"tools/call invalid arguments":   "no tools",
+			"prompts/get unknown prompt":     "no prompts",
+			"prompts/get missing argument":   "no prompt has required arguments",
+			"resources/read unknown URI":     "no resources",
+			"batch 2025-03-26":               "",
+			"batch 2025-06-18 rejected":      "",
+		}, got)
+	})
+
+	t.Run("optional prompt arguments", func(t *testing.T) {
+		t.Parallel()
+
+		got := skips(&config.Config{Prompts: []config.PromptConfig{{
+			ID:        "welcome",
+			Arguments: []config.PromptArgumentConfig{{Name: "name"}},
+		}}})
+		assert.Empty(t, got["prompts/get unknown prompt"])
+		assert.Equal(t, "no prompt has required arguments", got["prompts/get missing argument"])
+	})
+}
+
+func TestReadEvents(t *testing.T) {
+	t.Parallel()
+
+	stream := "event: message\n" +
+		`data: {"jsonrpc":"2.0","method":"notifications/message","params":{}}` + "\n\n" +
+		"event: message\n" +
+		`data: {"jsonrpc":"2.0","id":1,"result":{}}` + "\n\n" +
+		"event: message\n" +
+		`data: {"jsonrpc":"2.0","id":2,"error":{"code":-32602,"message":"unknown tool"}}` + "\n"
+
+	t.Run("responses", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := readEvents(strings.NewReader(stream), 2)
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.JSONEq(t, "1", string(got[0].ID))
+		assert.Equal(t, &rpcError{Code: -32602, Message: "unknown tool"}, got[1].Error)
+	})
+
+	t.Run("stream ends early", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := readEvents(strings.NewReader(stream), 3)
+		assert.ErrorIs(t, err, errConnClosed)
+	})
+}
+
+func TestDecodeMessages(t *testing.T) {
+	t.Parallel()
+
+	got, err := decodeMessages([]byte(` [{"jsonrpc":"2.0","id":1,"result":{}},{"jsonrpc":"2.0","id":2,"result":{}}]` + "\n"))
+	require.NoError(t, err)
+	assert.Len(t, got, 2)
+
+	got, err = decodeMessages([]byte(`{"jsonrpc":"2.0","id":"a","result":{}}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `"a"`, string(got[0].ID))
+
+	_, err = decodeMessages([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestRunConformance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds generated servers")
+	}
+	t.Parallel()
+
+	for _, transport := range []string{"stdio", "http"} {
+		t.Run(transport, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &config.Config{
+				Server:    config.ServerConfig{Name: "weather"},
+				Transport: config.TransportConfig{Type: transport},
+				Tools: []config.ToolConfig{{
+					ID:          "forecast",
+					InputSchema: `{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}`,
+				}},
+				Prompts: []config.PromptConfig{{
+					ID:        "report",
+					Template:  "Report on {{.city}}",
+					Arguments: []config.PromptArgumentConfig{{Name: "city", Required: true}},
+				}},
+				Resources: []config.ResourceConfig{{ID: "station", URITemplate: "station://{id}"}},
+			}
+			require.NoError(t, cfg.Validate())
+
+			dir := generateProject(t, cfg)
+
+			var out bytes.Buffer
+			pipeline := &checks.Pipeline{Steps: []checks.Configured{{Step: ConformanceStep(cfg, Options{})}}}
+			report, err := pipeline.RunReport(context.Background(), dir, &out)
+			require.NoError(t, err, out.String())
+
+			cases := report.Steps[0].Cases
+			require.Len(t, cases, 10)
+			for _, c := range cases {
+				assert.Equal(t, checks.StatusPassed, c.Status, "%s: %s", c.Name, c.Error)
+			}
+			assert.Contains(t, cases[0].Stdout, `"method":"initialize"`)
+			assert.Contains(t, out.String(), "Conformance of weather over "+transport)
+			assert.Contains(t, out.String(), "10 passed, 0 failed, 0 skipped")
+		})
+	}
+}
+
+func TestRunConformance_PlainPromptError(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds generated servers")
+	}
+	t.Parallel()
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "weather"},
+		Prompts: []config.PromptConfig{{
+			ID:        "report",
+			Template:  "Report on {{.city}}",
+			Arguments: []config.PromptArgumentConfig{{Name: "city", Required: true}},
+		}},
+	}
+	require.NoError(t, cfg.Validate())
+
+	dir := generateProject(t, cfg)
+
+	// a handler that reports a missing argument with a plain error, which
+	// the SDK sends with code 0
+	handlersPath := filepath.Join(dir, "internal", "mcpapp", "prompts", "handlers.go")
+	src, err := os.ReadFile(handlersPath)
+	require.NoError(t, err)
+	rpcErr := `&jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: fmt.Sprintf("missing argument %q", "city")}`
+	require.Contains(t, string(src), rpcErr)
+	src = bytes.Replace(src, []byte(rpcErr), []byte(`fmt.Errorf("missing argument %q", "city")`), 1)
+	src = bytes.Replace(src, []byte(`"github.com/modelcontextprotocol/go-sdk/jsonrpc"`), nil, 1)
+	require.NoError(t, os.WriteFile(handlersPath, src, 0o644))
+
+	var out bytes.Buffer
+	err = RunConformance(context.Background(), dir, cfg, Options{}, &out)
+
+	require.ErrorIs(t, err, ErrNonConformant)
+	assert.ErrorContains(t, err, "1 of 10 cases failed")
+	assert.Contains(t, out.String(), `FAIL  prompts/get missing argument    not conformant: got error 0: missing argument "city", want error -32602 (invalid params)`)
+	assert.Contains(t, out.String(), "6 passed, 1 failed, 3 skipped")
+}
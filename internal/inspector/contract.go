@@ -0,0 +1,253 @@
+package inspector
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"github.com/alesr/mcpgen/internal/config"
+)
+
+// ErrContractMismatch means a server advertises something other than
+// what its config describes.
+var ErrContractMismatch = errors.New("server does not match its config")
+
+// toolAnnotations are the annotations mcpgen generates for every tool.
+const toolAnnotations = `{"readOnlyHint":true,"destructiveHint":false}`
+
+const (
+	absent  = "absent"
+	present = "present"
+)
+
+// Mismatch is a field of a tool, resource, resource template or prompt
+// whose advertised value differs from its config. An empty Field means
+// the entity itself is only on one side: Got and Want are then "absent"
+// or "present".
+type Mismatch struct {
+	Kind  string
+	ID    string
+	Field string
+	// Got is the advertised value and Want the configured one, as Go
+	// quoted strings or compact JSON.
+	Got  string
+	Want string
+}
+
+func (m *Mismatch) Error() string {
+	switch {
+	case m.Field != "":
+		return fmt.Sprintf("%s %q %s: server has %s, config has %s", m.Kind, m.ID, m.Field, m.Got, m.Want)
+	case m.Got == absent:
+		return fmt.Sprintf("%s %q is configured but not advertised", m.Kind, m.ID)
+	default:
+		return fmt.Sprintf("%s %q is advertised but not configured", m.Kind, m.ID)
+	}
+}
+
+func (m *Mismatch) Unwrap() error {
+	return ErrContractMismatch
+}
+
+// contract collects the mismatches of one kind of entity.
+type contract struct {
+	kind string
+	errs []error
+}
+
+func (c *contract) text(id, field, got, want string) {
+	if got != want {
+		c.errs = append(c.errs, &Mismatch{Kind: c.kind, ID: id, Field: field, Got: strconv.Quote(got), Want: strconv.Quote(want)})
+	}
+}
+
+// json compares got and want as JSON values, so formatting and key order
+// do not matter. An empty want is not compared.
+func (c *contract) json(id, field string, got json.RawMessage, want string) {
+	if want == "" {
+		return
+	}
+
+	var g, w any
+	_ = json.Unmarshal(got, &g)
+	if err := json.Unmarshal([]byte(want), &w); err != nil || !reflect.DeepEqual(g, w) {
+		c.errs = append(c.errs, &Mismatch{Kind: c.kind, ID: id, Field: field, Got: compactJSON(got), Want: compactJSON([]byte(want))})
+	}
+}
+
+func (c *contract) err() error {
+	return errors.Join(c.errs...)
+}
+
+// match pairs the configured ids with the advertised entities of the same
+// name, calling compare for every pair and recording the others.
+func match[T any](c *contract, ids []string, advertised []T, name func(T) string, compare func(id string, got T)) {
+	for _, id := range ids {
+		i := slices.IndexFunc(advertised, func(a T) bool { return name(a) == id })
+		if i < 0 {
+			c.errs = append(c.errs, &Mismatch{Kind: c.kind, ID: id, Got: absent, Want: present})
+			continue
+		}
+		compare(id, advertised[i])
+	}
+
+	for _, a := range advertised {
+		if !slices.Contains(ids, name(a)) {
+			c.errs = append(c.errs, &Mismatch{Kind: c.kind, ID: name(a), Got: present, Want: absent})
+		}
+	}
+}
+
+type advertisedTool struct {
+	Name         string          `json:"name"`
+	Title        string          `json:"title"`
+	Description  string          `json:"description"`
+	InputSchema  json.RawMessage `json:"inputSchema"`
+	OutputSchema json.RawMessage `json:"outputSchema"`
+	Annotations  json.RawMessage `json:"annotations"`
+}
+
+// advertisedResource is a resource or a resource template.
+type advertisedResource struct {
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	URI         string `json:"uri"`
+	URITemplate string `json:"uriTemplate"`
+	MIMEType    string `json:"mimeType"`
+}
+
+type advertisedPrompt struct {
+	Name        string          `json:"name"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Arguments   json.RawMessage `json:"arguments"`
+}
+
+// promptArgument is the wire form of a prompt argument.
+type promptArgument struct {
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// checkToolList compares a tools/list result with tools.
+func checkToolList(tools []config.ToolConfig) func([]byte) error {
+	return func(result []byte) error {
+		var res struct {
+			Tools []advertisedTool `json:"tools"`
+		}
+		if err := json.Unmarshal(result, &res); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidResult, err)
+		}
+
+		c := contract{kind: "tool"}
+		ids := make([]string, 0, len(tools))
+		for _, t := range tools {
+			ids = append(ids, t.ID)
+		}
+
+		match(&c, ids, res.Tools, func(t advertisedTool) string { return t.Name }, func(id string, got advertisedTool) {
+			want := tools[slices.Index(ids, id)]
+			c.text(id, "title", got.Title, want.Title)
+			c.text(id, "description", got.Description, want.Description)
+			c.json(id, "input schema", got.InputSchema, want.InputSchema)
+			c.json(id, "output schema", got.OutputSchema, want.OutputSchema)
+			c.json(id, "annotations", got.Annotations, toolAnnotations)
+		})
+		return c.err()
+	}
+}
+
+// checkResourceList compares a resources/list result, or with templates
+// a resources/templates/list result, with the resources of that kind.
+func checkResourceList(resources []config.ResourceConfig, templates bool) func([]byte) error {
+	return func(result []byte) error {
+		var res struct {
+			Resources         []advertisedResource `json:"resources"`
+			ResourceTemplates []advertisedResource `json:"resourceTemplates"`
+		}
+		if err := json.Unmarshal(result, &res); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidResult, err)
+		}
+
+		c := contract{kind: "resource"}
+		advertised := res.Resources
+		if templates {
+			c.kind, advertised = "resource template", res.ResourceTemplates
+		}
+
+		var (
+			ids  []string
+			want []config.ResourceConfig
+		)
+		for _, r := range resources {
+			if (r.URITemplate != "") == templates {
+				ids = append(ids, r.ID)
+				want = append(want, r)
+			}
+		}
+
+		match(&c, ids, advertised, func(r advertisedResource) string { return r.Name }, func(id string, got advertisedResource) {
+			want := want[slices.Index(ids, id)]
+			c.text(id, "title", got.Title, want.Title)
+			c.text(id, "description", got.Description, want.Description)
+			if templates {
+				c.text(id, "URI template", got.URITemplate, want.URITemplate)
+			} else {
+				c.text(id, "URI", got.URI, want.URI)
+			}
+			c.text(id, "MIME type", got.MIMEType, want.MIMEType)
+		})
+		return c.err()
+	}
+}
+
+// checkPromptList compares a prompts/list result with prompts.
+func checkPromptList(prompts []config.PromptConfig) func([]byte) error {
+	return func(result []byte) error {
+		var res struct {
+			Prompts []advertisedPrompt `json:"prompts"`
+		}
+		if err := json.Unmarshal(result, &res); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidResult, err)
+		}
+
+		c := contract{kind: "prompt"}
+		ids := make([]string, 0, len(prompts))
+		for _, p := range prompts {
+			ids = append(ids, p.ID)
+		}
+
+		match(&c, ids, res.Prompts, func(p advertisedPrompt) string { return p.Name }, func(id string, got advertisedPrompt) {
+			want := prompts[slices.Index(ids, id)]
+			c.text(id, "title", got.Title, want.Title)
+			c.text(id, "description", got.Description, want.Description)
+
+			args := make([]promptArgument, 0, len(want.Arguments))
+			for _, a := range want.Arguments {
+				args = append(args, promptArgument(a))
+			}
+			gotArgs := got.Arguments
+			if len(gotArgs) == 0 || string(gotArgs) == "null" {
+				gotArgs = json.RawMessage("[]")
+			}
+			wantArgs, _ := json.Marshal(args)
+			c.json(id, "arguments", gotArgs, string(wantArgs))
+		})
+		return c.err()
+	}
+}
+
+func compactJSON(raw []byte) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return strconv.Quote(string(raw))
+	}
+	return buf.String()
+}
@@ -0,0 +1,130 @@
+package inspector
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mismatches returns the messages of the mismatches err joins.
+func mismatches(t *testing.T, err error) []string {
+	t.Helper()
+
+	if err == nil {
+		return nil
+	}
+	require.ErrorIs(t, err, ErrContractMismatch)
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	require.True(t, ok, "not joined: %v", err)
+
+	var msgs []string
+	for _, e := range joined.Unwrap() {
+		var m *Mismatch
+		require.True(t, errors.As(e, &m), "not a mismatch: %v", e)
+		msgs = append(msgs, m.Error())
+	}
+	return msgs
+}
+
+func TestCheckToolList(t *testing.T) {
+	t.Parallel()
+
+	tools := []config.ToolConfig{
+		{ID: "forecast", Title: "Forecast", Description: "Weather", InputSchema: `{"type":"object"}`, OutputSchema: `{"type":"object"}`},
+		{ID: "alerts", Title: "Alerts", InputSchema: `{"type":"object"}`},
+	}
+
+	tests := []struct {
+		name   string
+		result string
+		want   []string
+	}{
+		{
+			name: "matches",
+			result: `{"tools":[
+				{"name":"alerts","title":"Alerts","inputSchema":{"type":"object"},"annotations":{"readOnlyHint":true,"destructiveHint":false}},
+				{"name":"forecast","title":"Forecast","description":"Weather","inputSchema":{ "type" : "object" },"outputSchema":{"type":"object"},"annotations":{"destructiveHint":false,"readOnlyHint":true}}
+			]}`,
+		},
+		{
+			name: "drifted",
+			result: `{"tools":[
+				{"name":"forecast","title":"Forecast!","description":"Weather","inputSchema":{"type":"object","required":["city"]},"outputSchema":{"type":"object"},"annotations":{"readOnlyHint":false}},
+				{"name":"debug","inputSchema":{"type":"object"}}
+			]}`,
+			want: []string{
+				`tool "forecast" title: server has "Forecast!", config has "Forecast"`,
+				`tool "forecast" input schema: server has {"type":"object","required":["city"]}, config has {"type":"object"}`,
+				`tool "forecast" annotations: server has {"readOnlyHint":false}, config has {"readOnlyHint":true,"destructiveHint":false}`,
+				`tool "alerts" is configured but not advertised`,
+				`tool "debug" is advertised but not configured`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := checkToolList(tools)([]byte(tt.result))
+			assert.Equal(t, tt.want, mismatches(t, err))
+		})
+	}
+}
+
+func TestCheckResourceList(t *testing.T) {
+	t.Parallel()
+
+	resources := []config.ResourceConfig{
+		{ID: "readme", Title: "Readme", URI: "docs://readme", MIMEType: "text/markdown"},
+		{ID: "station", Title: "Station", URITemplate: "station://{id}"},
+	}
+
+	t.Run("resources", func(t *testing.T) {
+		t.Parallel()
+
+		err := checkResourceList(resources, false)([]byte(`{"resources":[{"name":"readme","title":"Readme","uri":"docs://README","mimeType":"text/plain"}]}`))
+		assert.Equal(t, []string{
+			`resource "readme" URI: server has "docs://README", config has "docs://readme"`,
+			`resource "readme" MIME type: server has "text/plain", config has "text/markdown"`,
+		}, mismatches(t, err))
+	})
+
+	t.Run("templates", func(t *testing.T) {
+		t.Parallel()
+
+		err := checkResourceList(resources, true)([]byte(`{"resourceTemplates":[{"name":"station","title":"Station","uriTemplate":"station://{id}"}]}`))
+		assert.NoError(t, err)
+
+		err = checkResourceList(resources, true)([]byte(`{"resourceTemplates":[]}`))
+		assert.Equal(t, []string{`resource template "station" is configured but not advertised`}, mismatches(t, err))
+	})
+}
+
+func TestCheckPromptList(t *testing.T) {
+	t.Parallel()
+
+	prompts := []config.PromptConfig{
+		{ID: "report", Title: "Report", Arguments: []config.PromptArgumentConfig{{Name: "city", Required: true}}},
+		{ID: "plain", Title: "Plain"},
+	}
+
+	err := checkPromptList(prompts)([]byte(`{"prompts":[
+		{"name":"report","title":"Report","arguments":[{"name":"city","required":true}]},
+		{"name":"plain","title":"Plain"}
+	]}`))
+	assert.NoError(t, err)
+
+	err = checkPromptList(prompts)([]byte(`{"prompts":[
+		{"name":"report","title":"Report","arguments":[{"name":"city"}]},
+		{"name":"plain","title":"Plain","arguments":[{"name":"tone"}]}
+	]}`))
+	assert.Equal(t, []string{
+		`prompt "report" arguments: server has [{"name":"city"}], config has [{"name":"city","required":true}]`,
+		`prompt "plain" arguments: server has [{"name":"tone"}], config has []`,
+	}, mismatches(t, err))
+}
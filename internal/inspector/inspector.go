@@ -1,153 +1,148 @@
 package inspector
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"net"
-	"os"
-	"os/exec"
+	"io"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/alesr/mcpgen/internal/checks"
 	"github.com/alesr/mcpgen/internal/config"
-	"github.com/alesr/mcpgen/internal/pkg/utils"
 )
 
-type inspectorCall struct {
-	method    string
-	extraArgs []string
-}
+var ErrUnknownMode = errors.New("unknown inspector mode")
 
-func RunTest(outDir string, cfg *config.Config) error {
-	serverName := utils.DefaultServerName(cfg.Server.Name)
-	methods := make([]inspectorCall, 0)
+// Mode is how the inspector talks to the server.
+type Mode string
 
-	if cfg.Tool != nil {
-		methods = append(methods, inspectorCall{method: "tools/list"})
-	}
+const (
+	// ModeNative builds the server and calls it with the MCP go-sdk
+	// client, in-process. It needs neither Node.js nor the network.
+	ModeNative Mode = "native"
+	// ModeNPX runs the MCP inspector CLI through npx for every call.
+	ModeNPX Mode = "npx"
+)
 
-	if cfg.Resource != nil {
-		methods = append(methods, inspectorCall{method: "resources/list"})
-	}
+// Modes lists the inspector modes.
+var Modes = []Mode{ModeNative, ModeNPX}
 
-	if cfg.Prompt != nil {
-		methods = append(methods, inspectorCall{method: "prompts/list"})
-	}
+// DefaultReadyTimeout is how long an HTTP server may take to serve /mcp
+// once started, unless Options say otherwise.
+const DefaultReadyTimeout = 10 * time.Second
 
-	if len(methods) == 0 {
-		return nil
-	}
+// Options configure an inspector run.
+type Options struct {
+	// Mode is how the inspector talks to the server; empty is ModeNative.
+	Mode Mode
+	// ReadyTimeout bounds the wait for an HTTP server to serve /mcp;
+	// zero is DefaultReadyTimeout.
+	ReadyTimeout time.Duration
+}
 
-	var methodNames []string
-	for _, call := range methods {
-		methodNames = append(methodNames, call.method)
+func (o Options) readyTimeout() time.Duration {
+	if o.ReadyTimeout > 0 {
+		return o.ReadyTimeout
 	}
+	return DefaultReadyTimeout
+}
 
-	fmt.Printf("Running inspector checks: %s\n", strings.Join(methodNames, ", "))
-	fmt.Println("---")
-
-	if cfg.Transport.Type == "http" {
-		serverCmd := exec.Command("go", "run", "./cmd/"+serverName)
-		serverCmd.Dir = outDir
-		serverCmd.Env = append(os.Environ(), "GOWORK=off")
-		serverCmd.Stdout = os.Stdout
-		serverCmd.Stderr = os.Stderr
-
-		if err := serverCmd.Start(); err != nil {
-			return err
-		}
+// CallError reports the inspector method that failed.
+type CallError struct {
+	Method string
+	Err    error
+}
 
-		defer func() {
-			_ = serverCmd.Process.Kill()
-		}()
+func (e *CallError) Error() string {
+	return fmt.Sprintf("%s failed: %v", e.Method, e.Err)
+}
 
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
-		defer cancel()
+func (e *CallError) Unwrap() error {
+	return e.Err
+}
 
-		if err := waitForPort(ctx, cfg.Transport.HTTPPort); err != nil {
-			return fmt.Errorf("could not wait for port: %w", err)
-		}
+// RunTest lists the tools, resources and prompts of the server generated
+// in outDir, then calls every tool, gets every prompt and reads every
+// resource, checking each result against cfg. It writes what it does to
+// out. The server is built once; an HTTP server listens on a free port.
+func RunTest(ctx context.Context, outDir string, cfg *config.Config, opts Options, out io.Writer) error {
+	calls, err := planCalls(cfg)
+	if err != nil {
+		return err
 	}
 
-	for i, call := range methods {
-		if i > 0 {
-			fmt.Println()
-		}
+	if len(calls) == 0 {
+		return nil
+	}
 
-		if err := runInspectorCall(outDir, cfg, serverName, call); err != nil {
-			return fmt.Errorf("%s failed: %w", call.method, err)
+	var methodNames []string
+	for _, call := range calls {
+		if !slices.Contains(methodNames, call.method) {
+			methodNames = append(methodNames, call.method)
 		}
-		fmt.Printf("✓ %s\n", call.method)
 	}
 
-	fmt.Println("All checks passed.")
-	return nil
-}
-
-func runInspectorCall(outDir string, cfg *config.Config, serverName string, call inspectorCall) error {
-	fmt.Printf("→ %s\n", call.method)
+	fmt.Fprintf(out, "Running inspector checks: %s\n", strings.Join(methodNames, ", "))
+	fmt.Fprintln(out, "---")
 
-	cmdArgs, err := inspectorArgs(cfg, serverName, call)
+	switch opts.Mode {
+	case ModeNative, "":
+		err = runNative(ctx, outDir, cfg, opts, calls, out)
+	case ModeNPX:
+		err = runNPX(ctx, outDir, cfg, opts, calls, out)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownMode, opts.Mode)
+	}
 	if err != nil {
 		return err
 	}
 
-	cmd := exec.Command("npx", cmdArgs...)
-	cmd.Dir = outDir
-	cmd.Env = append(os.Environ(), "GOWORK=off")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
-
-	return cmd.Run()
+	fmt.Fprintln(out, "All checks passed.")
+	return nil
 }
 
-func inspectorArgs(cfg *config.Config, serverName string, call inspectorCall) ([]string, error) {
-	if cfg.Transport.Type == "http" {
-		url := fmt.Sprintf("http://localhost:%d/mcp", cfg.Transport.HTTPPort)
-		args := []string{"@modelcontextprotocol/inspector", "--cli", url, "--transport", "http", "--method", call.method}
-
-		if len(call.extraArgs) > 0 {
-			args = append(args, call.extraArgs...)
-		}
-		return args, nil
+// runCall runs call through fn, checks the result fn writes to stdout and
+// records the call as a case of the check step running the inspector, if
+// any. A failure is a *CallError.
+func runCall(ctx context.Context, call inspectorCall, out io.Writer, fn func(stdout, stderr io.Writer) error) error {
+	name := call.name()
+	fmt.Fprintf(out, "→ %s\n", name)
+
+	var stdout, stderr bytes.Buffer
+	start := time.Now()
+	err := fn(io.MultiWriter(out, &stdout), io.MultiWriter(checks.Stderr(out), &stderr))
+	if err == nil && call.check != nil {
+		err = call.check(stdout.Bytes())
 	}
 
-	args := []string{
-		"@modelcontextprotocol/inspector",
-		"--cli",
-		"--transport",
-		"stdio",
-		"-e",
-		"GOWORK=off",
-		"--method",
-		call.method,
+	res := checks.Result{
+		Name:     name,
+		Status:   checks.StatusPassed,
+		Duration: time.Since(start),
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
 	}
-
-	if len(call.extraArgs) > 0 {
-		args = append(args, call.extraArgs...)
+	if err != nil {
+		res.Status, res.Error = checks.StatusFailed, err.Error()
 	}
+	checks.RecordCase(ctx, res)
 
-	args = append(args, "--", "go", "run", "./cmd/"+serverName)
-	return args, nil
+	if err != nil {
+		return &CallError{Method: name, Err: err}
+	}
+	fmt.Fprintf(out, "✓ %s\n", name)
+	return nil
 }
 
-func waitForPort(ctx context.Context, port int) error {
-	address := net.JoinHostPort("127.0.0.1", fmt.Sprintf("%d", port))
-	var d net.Dialer
-
-	for {
-		conn, err := d.DialContext(ctx, "tcp", address)
-		if err == nil {
-			conn.Close()
-			return nil
-		}
+// StepName names the inspector run in a check pipeline.
+const StepName = "inspector"
 
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("port %d not reachable: %w", port, ctx.Err())
-		case <-time.After(500 * time.Millisecond):
-			// try again
-		}
-	}
+// Step runs RunTest for cfg as a step of a check pipeline.
+func Step(cfg *config.Config, opts Options) checks.Step {
+	return checks.Func(StepName, func(ctx context.Context, dir string, out io.Writer) error {
+		return RunTest(ctx, dir, cfg, opts, out)
+	})
 }
@@ -0,0 +1,150 @@
+package inspector
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/alesr/mcpgen/internal/checks"
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/generator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunTest_Native(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds generated servers")
+	}
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		transport string
+	}{
+		{name: "stdio", transport: "stdio"},
+		{name: "http", transport: "http"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// the configured port is busy: the inspector picks its own
+			busy, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			t.Cleanup(func() { busy.Close() })
+
+			cfg := &config.Config{
+				Server:    config.ServerConfig{Name: "weather"},
+				Transport: config.TransportConfig{Type: tt.transport, HTTPPort: busy.Addr().(*net.TCPAddr).Port},
+				Tools: []config.ToolConfig{{
+					ID:           "forecast",
+					InputSchema:  `{"type":"object","properties":{"city":{"type":"string"},"days":{"type":"integer","minimum":1}},"required":["city","days"]}`,
+					OutputSchema: `{"type":"object","properties":{"summary":{"type":"string"}},"required":["summary"]}`,
+				}},
+				Prompts: []config.PromptConfig{{
+					ID:        "report",
+					Template:  "Report on {{.city}} in {{.tone}} tone",
+					Arguments: []config.PromptArgumentConfig{{Name: "city", Required: true}, {Name: "tone"}},
+				}},
+				Resources: []config.ResourceConfig{
+					{ID: "station", URITemplate: "station://{id}", MIMEType: "application/json"},
+					{ID: "readme", URI: "docs://readme", MIMEType: "text/markdown"},
+				},
+			}
+			require.NoError(t, cfg.Validate())
+
+			dir := generateProject(t, cfg)
+
+			var out bytes.Buffer
+			pipeline := &checks.Pipeline{Steps: []checks.Configured{{Step: Step(cfg, Options{Mode: ModeNative})}}}
+			report, err := pipeline.RunReport(context.Background(), dir, &out)
+			require.NoError(t, err, out.String())
+
+			var methods []string
+			for _, c := range report.Steps[0].Cases {
+				assert.Equal(t, checks.StatusPassed, c.Status, c.Name)
+				methods = append(methods, c.Name)
+			}
+			assert.Equal(t, []string{
+				"initialize",
+				"tools/list",
+				"resources/list",
+				"resources/templates/list",
+				"prompts/list",
+				"tools/call forecast",
+				"prompts/get report",
+				"resources/read station://example",
+				"resources/read docs://readme",
+			}, methods)
+			assert.Contains(t, report.Steps[0].Cases[1].Stdout, `"name": "forecast"`)
+			assert.Contains(t, report.Steps[0].Cases[3].Stdout, `"uriTemplate": "station://{id}"`)
+			assert.Contains(t, report.Steps[0].Cases[6].Stdout, "Report on example in example tone")
+		})
+	}
+}
+
+func TestRunTest_Contract(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds generated servers")
+	}
+	t.Parallel()
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "weather"},
+		Tools:  []config.ToolConfig{{ID: "forecast", Title: "Forecast"}},
+	}
+	require.NoError(t, cfg.Validate())
+
+	dir := generateProject(t, cfg)
+
+	// a hand edit of the generated tool metadata
+	toolsPath := filepath.Join(dir, "internal", "mcpapp", "tools", "tools.go")
+	src, err := os.ReadFile(toolsPath)
+	require.NoError(t, err)
+	require.Contains(t, string(src), `"Forecast",`)
+	src = bytes.Replace(src, []byte(`"Forecast",`), []byte(`"Forecast v2",`), 1)
+	require.NoError(t, os.WriteFile(toolsPath, src, 0o644))
+
+	err = RunTest(context.Background(), dir, cfg, Options{}, &bytes.Buffer{})
+
+	var callErr *CallError
+	require.ErrorAs(t, err, &callErr)
+	assert.Equal(t, "tools/list", callErr.Method)
+	assert.ErrorIs(t, err, ErrContractMismatch)
+	assert.ErrorContains(t, err, `tool "forecast" title: server has "Forecast v2", config has "Forecast"`)
+}
+
+func TestRunTest_UnknownMode(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{Server: config.ServerConfig{Name: "weather"}, Tools: []config.ToolConfig{{ID: "forecast"}}}
+	err := RunTest(context.Background(), t.TempDir(), cfg, Options{Mode: "telnet"}, &bytes.Buffer{})
+	assert.ErrorIs(t, err, ErrUnknownMode)
+}
+
+// generateProject writes the project of cfg to a temporary directory and
+// resolves its dependencies, skipping the test when that needs a network
+// the sandbox does not have.
+func generateProject(t *testing.T, cfg *config.Config) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, (&generator.Generator{Config: cfg, OutDir: dir, Force: true}).Run())
+
+	tidy := exec.Command("go", "mod", "tidy")
+	tidy.Dir = dir
+	tidy.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod", "GOPROXY=off")
+	if output, err := tidy.CombinedOutput(); err != nil {
+		t.Skipf("dependencies of the generated project are not in the module cache: %s", output)
+	}
+
+	_, err := os.Stat(filepath.Join(dir, "go.sum"))
+	require.NoError(t, err)
+	return dir
+}
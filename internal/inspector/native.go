@@ -0,0 +1,109 @@
+package inspector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/alesr/mcpgen/internal/checks"
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/manifest"
+	"github.com/alesr/mcpgen/internal/pkg/proc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// clientName identifies mcpgen to the servers it inspects.
+const clientName = "mcpgen-inspector"
+
+// runNative connects to the server with the go-sdk client and runs calls
+// over one session, printing every result as JSON.
+func runNative(ctx context.Context, outDir string, cfg *config.Config, opts Options, calls []inspectorCall, out io.Writer) error {
+	bin, endpoint, stop, err := launch(ctx, outDir, cfg, opts.readyTimeout(), out)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	var transport mcp.Transport = &mcp.StreamableClientTransport{Endpoint: endpoint, DisableStandaloneSSE: true}
+	if endpoint == "" {
+		// the transport starts the server and reaps it when the session
+		// closes, so its group is only signalled when ctx is done first
+		cmd := proc.Command(ctx, bin)
+		cmd.Dir = outDir
+		cmd.Stderr = checks.Stderr(out)
+		transport = &mcp.CommandTransport{Command: cmd.Cmd}
+	}
+
+	client := mcp.NewClient(&mcp.Implementation{Name: clientName, Version: manifest.Version()}, nil)
+
+	var session *mcp.ClientSession
+	err = runCall(ctx, inspectorCall{method: "initialize"}, out, func(stdout, _ io.Writer) error {
+		s, err := client.Connect(ctx, transport, nil)
+		if err != nil {
+			return err
+		}
+		session = s
+		return printJSON(stdout, s.InitializeResult())
+	})
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	for _, call := range calls {
+		fmt.Fprintln(out)
+
+		err := runCall(ctx, call, out, func(stdout, _ io.Writer) error {
+			res, err := callNative(ctx, session, call)
+			if err != nil {
+				return err
+			}
+			return printJSON(stdout, res)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// callNative sends the request of call over session.
+func callNative(ctx context.Context, session *mcp.ClientSession, call inspectorCall) (any, error) {
+	var (
+		res any
+		err error
+	)
+	switch call.method {
+	case "tools/list":
+		res, err = session.ListTools(ctx, nil)
+	case "resources/list":
+		res, err = session.ListResources(ctx, nil)
+	case "resources/templates/list":
+		res, err = session.ListResourceTemplates(ctx, nil)
+	case "prompts/list":
+		res, err = session.ListPrompts(ctx, nil)
+	case "tools/call":
+		res, err = session.CallTool(ctx, &mcp.CallToolParams{Name: call.target, Arguments: call.args})
+	case "prompts/get":
+		args := make(map[string]string, len(call.args))
+		for k, v := range call.args {
+			args[k] = fmt.Sprint(v)
+		}
+		res, err = session.GetPrompt(ctx, &mcp.GetPromptParams{Name: call.target, Arguments: args})
+	case "resources/read":
+		res, err = session.ReadResource(ctx, &mcp.ReadResourceParams{URI: call.target})
+	default:
+		return nil, fmt.Errorf("method %q is not supported natively", call.method)
+	}
+	return res, err
+}
+
+func printJSON(w io.Writer, v any) error {
+	raw, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", raw)
+	return err
+}
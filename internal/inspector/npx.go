@@ -0,0 +1,65 @@
+package inspector
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/pkg/proc"
+)
+
+// runNPX runs calls through the MCP inspector CLI, one npx process per
+// call, against the server built once.
+func runNPX(ctx context.Context, outDir string, cfg *config.Config, opts Options, calls []inspectorCall, out io.Writer) error {
+	bin, endpoint, stop, err := launch(ctx, outDir, cfg, opts.readyTimeout(), out)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	for i, call := range calls {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+
+		err := runCall(ctx, call, out, func(stdout, stderr io.Writer) error {
+			return execInspectorCall(ctx, outDir, inspectorArgs(bin, endpoint, call), stdout, stderr)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execInspectorCall runs npx without a terminal: in a process group of
+// its own it could not read one, so --yes skips its install prompt.
+func execInspectorCall(ctx context.Context, outDir string, args []string, stdout, stderr io.Writer) error {
+	cmd := proc.Command(ctx, "npx", append([]string{"--yes"}, args...)...)
+	cmd.Dir = outDir
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	return cmd.Run()
+}
+
+// inspectorArgs returns the npx arguments of call, sent to endpoint over
+// HTTP or, when it is empty, to bin over stdio.
+func inspectorArgs(bin, endpoint string, call inspectorCall) []string {
+	if endpoint != "" {
+		args := []string{"@modelcontextprotocol/inspector", "--cli", endpoint, "--transport", "http", "--method", call.method}
+		return append(args, call.extraArgs...)
+	}
+
+	args := []string{
+		"@modelcontextprotocol/inspector",
+		"--cli",
+		"--transport",
+		"stdio",
+		"--method",
+		call.method,
+	}
+	args = append(args, call.extraArgs...)
+	return append(args, "--", bin)
+}
@@ -0,0 +1,351 @@
+package inspector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alesr/mcpgen/internal/manifest"
+	"github.com/alesr/mcpgen/internal/pkg/proc"
+)
+
+// errConnClosed means a server closed its connection instead of answering.
+var errConnClosed = errors.New("server closed the connection")
+
+// rpcMessage is a JSON-RPC request, notification or response, written
+// and read as is: the go-sdk client would not send the malformed or
+// outdated messages conformance cases are made of.
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  any             `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("error %d: %s", e.Code, e.Message)
+}
+
+// rpcConn carries JSON-RPC payloads, single messages or batches, to a
+// server.
+type rpcConn interface {
+	// send writes payload for protocol version, if known, and waits for
+	// n responses, skipping the requests and notifications of the server.
+	send(ctx context.Context, payload []byte, version string, n int) ([]rpcMessage, error)
+	close()
+}
+
+// decodeMessages decodes a message or a batch of them.
+func decodeMessages(data []byte) ([]rpcMessage, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var msgs []rpcMessage
+		if err := json.Unmarshal(data, &msgs); err != nil {
+			return nil, fmt.Errorf("could not decode batch %s: %w", data, err)
+		}
+		return msgs, nil
+	}
+
+	var msg rpcMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("could not decode message %s: %w", data, err)
+	}
+	return []rpcMessage{msg}, nil
+}
+
+// stdioConn runs a server of its own and talks to it over its standard
+// input and output, one message or batch per line.
+type stdioConn struct {
+	cmd   *proc.Cmd
+	stdin io.WriteCloser
+	lines chan []byte
+	done  chan struct{}
+}
+
+func dialStdio(ctx context.Context, bin, dir string, stderr io.Writer) (*stdioConn, error) {
+	cmd := proc.Command(ctx, bin)
+	cmd.Dir = dir
+	cmd.Stderr = stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("could not start the server: %w", err)
+	}
+
+	c := &stdioConn{cmd: cmd, stdin: stdin, lines: make(chan []byte), done: make(chan struct{})}
+	go func() {
+		defer close(c.lines)
+
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(nil, 16<<20)
+		for scanner.Scan() {
+			select {
+			case c.lines <- bytes.Clone(scanner.Bytes()):
+			case <-c.done:
+				return
+			}
+		}
+	}()
+	return c, nil
+}
+
+func (c *stdioConn) send(ctx context.Context, payload []byte, _ string, n int) ([]rpcMessage, error) {
+	if _, err := c.stdin.Write(append(payload, '\n')); err != nil {
+		return nil, fmt.Errorf("%w: %v", errConnClosed, err)
+	}
+
+	var resps []rpcMessage
+	for len(resps) < n {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case line, ok := <-c.lines:
+			if !ok {
+				return nil, errConnClosed
+			}
+
+			msgs, err := decodeMessages(line)
+			if err != nil {
+				return nil, err
+			}
+			resps = append(resps, responses(msgs)...)
+		}
+	}
+	return resps, nil
+}
+
+func (c *stdioConn) close() {
+	close(c.done)
+	_ = c.stdin.Close()
+	c.cmd.Kill()
+	_ = c.cmd.Wait()
+}
+
+// statusError is an HTTP response that carried no JSON-RPC message.
+type statusError struct {
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("HTTP %d %s: %s", e.code, http.StatusText(e.code), e.body)
+}
+
+// httpConn posts messages to the streamable HTTP endpoint of a server.
+type httpConn struct {
+	endpoint  string
+	sessionID string
+}
+
+func (c *httpConn) send(ctx context.Context, payload []byte, version string, n int) ([]rpcMessage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if version != "" {
+		req.Header.Set("MCP-Protocol-Version", version)
+	}
+	if c.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", c.sessionID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if id := resp.Header.Get("Mcp-Session-Id"); id != "" {
+		c.sessionID = id
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return nil, &statusError{code: resp.StatusCode, body: strings.TrimSpace(string(body))}
+	}
+
+	if n == 0 {
+		return nil, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/event-stream" {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		msgs, err := decodeMessages(body)
+		if err != nil {
+			return nil, err
+		}
+		return responses(msgs), nil
+	}
+	return readEvents(resp.Body, n)
+}
+
+func (c *httpConn) close() {}
+
+// readEvents reads the messages of a server-sent event stream until it
+// has n responses or the stream ends.
+func readEvents(r io.Reader, n int) ([]rpcMessage, error) {
+	var (
+		resps []rpcMessage
+		data  bytes.Buffer
+	)
+	flush := func() error {
+		if data.Len() == 0 {
+			return nil
+		}
+		msgs, err := decodeMessages(data.Bytes())
+		if err != nil {
+			return err
+		}
+		resps = append(resps, responses(msgs)...)
+		data.Reset()
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	for len(resps) < n && scanner.Scan() {
+		line := scanner.Bytes()
+		if d, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+			data.Write(bytes.TrimPrefix(d, []byte(" ")))
+			continue
+		}
+		// a blank line ends an event
+		if len(line) == 0 {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	if len(resps) < n {
+		return nil, fmt.Errorf("%w after %d of %d responses", errConnClosed, len(resps), n)
+	}
+	return resps, nil
+}
+
+// responses drops the requests and notifications a server sends along
+// with its responses.
+func responses(msgs []rpcMessage) []rpcMessage {
+	var resps []rpcMessage
+	for _, msg := range msgs {
+		if msg.Method == "" {
+			resps = append(resps, msg)
+		}
+	}
+	return resps
+}
+
+// rpcSession numbers the requests sent over a connection and keeps the
+// protocol version it negotiated. Every message goes to log.
+type rpcSession struct {
+	conn    rpcConn
+	log     io.Writer
+	version string
+	lastID  int
+}
+
+// request returns a request of method with the next ID.
+func (s *rpcSession) request(method string, params any) rpcMessage {
+	s.lastID++
+	return rpcMessage{JSONRPC: "2.0", ID: json.RawMessage(strconv.Itoa(s.lastID)), Method: method, Params: params}
+}
+
+// send writes v, a message or batch, and returns the n responses to it.
+func (s *rpcSession) send(ctx context.Context, v any, n int) ([]rpcMessage, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(s.log, "> %s\n", payload)
+
+	resps, err := s.conn.send(ctx, payload, s.version, n)
+	for _, resp := range resps {
+		raw, _ := json.Marshal(resp)
+		fmt.Fprintf(s.log, "< %s\n", raw)
+	}
+	if err != nil {
+		fmt.Fprintf(s.log, "< %v\n", err)
+	}
+	return resps, err
+}
+
+// call sends a request of method and returns the response to it, which
+// may be a JSON-RPC error.
+func (s *rpcSession) call(ctx context.Context, method string, params any) (rpcMessage, error) {
+	req := s.request(method, params)
+	resps, err := s.send(ctx, req, 1)
+	if err != nil {
+		return rpcMessage{}, err
+	}
+
+	if !bytes.Equal(resps[0].ID, req.ID) {
+		return rpcMessage{}, fmt.Errorf("%w: answered request %s with id %s", ErrNonConformant, req.ID, resps[0].ID)
+	}
+	return resps[0], nil
+}
+
+// initializeResult is the part of an initialize result the cases check.
+type initializeResult struct {
+	ProtocolVersion string `json:"protocolVersion"`
+}
+
+// initialize asks for version and, once the server answered, sends the
+// initialized notification.
+func (s *rpcSession) initialize(ctx context.Context, version string) (initializeResult, error) {
+	resp, err := s.call(ctx, "initialize", map[string]any{
+		"protocolVersion": version,
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]any{"name": clientName, "version": manifest.Version()},
+	})
+	if err != nil {
+		return initializeResult{}, err
+	}
+	if resp.Error != nil {
+		return initializeResult{}, resp.Error
+	}
+
+	var res initializeResult
+	if err := json.Unmarshal(resp.Result, &res); err != nil {
+		return initializeResult{}, fmt.Errorf("could not decode initialize result: %w", err)
+	}
+	s.version = res.ProtocolVersion
+
+	_, err = s.send(ctx, rpcMessage{JSONRPC: "2.0", Method: "notifications/initialized"}, 0)
+	return res, err
+}
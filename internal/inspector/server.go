@@ -0,0 +1,170 @@
+package inspector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/alesr/mcpgen/internal/checks"
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/pkg/proc"
+	"github.com/alesr/mcpgen/internal/pkg/utils"
+)
+
+// ErrServerExited means a server stopped before it was ready.
+var ErrServerExited = errors.New("server exited")
+
+// launch builds the server of the project in outDir once and, for HTTP,
+// starts it on a free port and waits until it serves /mcp. endpoint is
+// the URL of that handler, or empty for stdio, where every client runs
+// bin itself. stop ends the server and removes the binary.
+func launch(ctx context.Context, outDir string, cfg *config.Config, readyTimeout time.Duration, out io.Writer) (bin, endpoint string, stop func(), err error) {
+	bin, cleanup, err := buildServer(ctx, outDir, utils.DefaultServerName(cfg.Server.Name), out)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	if cfg.Transport.Type != "http" {
+		return bin, "", cleanup, nil
+	}
+
+	endpoint, stopServer, err := startHTTP(ctx, outDir, bin, readyTimeout, out)
+	if err != nil {
+		cleanup()
+		return "", "", nil, err
+	}
+
+	stop = func() {
+		stopServer()
+		cleanup()
+	}
+	return bin, endpoint, stop, nil
+}
+
+// buildServer compiles the server of the project in outDir into a
+// temporary directory that cleanup removes.
+func buildServer(ctx context.Context, outDir, serverName string, out io.Writer) (bin string, cleanup func(), err error) {
+	dir, err := os.MkdirTemp("", "mcpgen-inspector-*")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup = func() { _ = os.RemoveAll(dir) }
+
+	bin = filepath.Join(dir, serverName)
+	if runtime.GOOS == "windows" {
+		bin += ".exe"
+	}
+
+	cmd := proc.Command(ctx, "go", "build", "-o", bin, "./cmd/"+serverName)
+	cmd.Dir = outDir
+	cmd.Env = append(os.Environ(), "GOWORK=off")
+	cmd.Stdout = out
+	cmd.Stderr = checks.Stderr(out)
+
+	if err := cmd.Run(); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("could not build the server: %w", err)
+	}
+	return bin, cleanup, nil
+}
+
+// startHTTP runs bin with its port set to a free one through
+// config.HTTPPortEnv, so a busy configured port does not matter.
+func startHTTP(ctx context.Context, outDir, bin string, readyTimeout time.Duration, out io.Writer) (endpoint string, stop func(), err error) {
+	port, err := freePort()
+	if err != nil {
+		return "", nil, fmt.Errorf("could not find a free port: %w", err)
+	}
+
+	cmd := proc.Command(ctx, bin)
+	cmd.Dir = outDir
+	cmd.Env = append(os.Environ(), config.HTTPPortEnv+"="+strconv.Itoa(port))
+	cmd.Stdout = out
+	cmd.Stderr = checks.Stderr(out)
+
+	if err := cmd.Start(); err != nil {
+		return "", nil, fmt.Errorf("could not start the server: %w", err)
+	}
+
+	var (
+		exited  = make(chan struct{})
+		waitErr error
+	)
+	go func() {
+		waitErr = cmd.Wait()
+		close(exited)
+	}()
+
+	stop = func() {
+		cmd.Kill()
+		<-exited
+	}
+
+	endpoint = fmt.Sprintf("http://127.0.0.1:%d/mcp", port)
+	if err := waitReady(ctx, endpoint, readyTimeout, exited); err != nil {
+		stop()
+		if errors.Is(err, ErrServerExited) {
+			return "", nil, fmt.Errorf("%w before serving %s: %v", ErrServerExited, endpoint, waitErr)
+		}
+		return "", nil, err
+	}
+	return endpoint, stop, nil
+}
+
+// waitReady polls endpoint until the server answers it over HTTP with
+// anything but 404, exited is closed or timeout passes. When ctx is done
+// first, its error is returned as is.
+func waitReady(ctx context.Context, endpoint string, timeout time.Duration, exited <-chan struct{}) error {
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var lastErr error
+	for {
+		req, err := http.NewRequestWithContext(waitCtx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			_ = resp.Body.Close()
+			if resp.StatusCode != http.StatusNotFound {
+				return nil
+			}
+			err = fmt.Errorf("%s answered %s", endpoint, resp.Status)
+		}
+		// a request the deadline cut short says less than the last answer
+		if lastErr == nil || waitCtx.Err() == nil {
+			lastErr = err
+		}
+
+		select {
+		case <-exited:
+			return ErrServerExited
+		case <-waitCtx.Done():
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return fmt.Errorf("server not ready after %s: %w", timeout, lastErr)
+		case <-time.After(100 * time.Millisecond):
+			// try again
+		}
+	}
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,71 @@
+package inspector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitReady(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("serves /mcp", func(t *testing.T) {
+		t.Parallel()
+
+		// a streamable HTTP handler rejects a bare GET, which still means
+		// it is up
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}))
+		t.Cleanup(srv.Close)
+
+		require.NoError(t, waitReady(ctx, srv.URL+"/mcp", time.Second, nil))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+
+		srv := httptest.NewServer(http.NotFoundHandler())
+		t.Cleanup(srv.Close)
+
+		err := waitReady(ctx, srv.URL+"/mcp", 300*time.Millisecond, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not ready after 300ms")
+		assert.Contains(t, err.Error(), "404 Not Found")
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		t.Parallel()
+
+		srv := httptest.NewServer(http.NotFoundHandler())
+		t.Cleanup(srv.Close)
+
+		ctx, cancel := context.WithCancel(ctx)
+		time.AfterFunc(200*time.Millisecond, cancel)
+
+		err := waitReady(ctx, srv.URL+"/mcp", time.Minute, nil)
+		require.ErrorIs(t, err, context.Canceled)
+		assert.NotContains(t, err.Error(), "not ready")
+	})
+
+	t.Run("server exited", func(t *testing.T) {
+		t.Parallel()
+
+		port, err := freePort()
+		require.NoError(t, err)
+
+		exited := make(chan struct{})
+		close(exited)
+
+		err = waitReady(ctx, fmt.Sprintf("http://127.0.0.1:%d/mcp", port), time.Minute, exited)
+		assert.ErrorIs(t, err, ErrServerExited)
+	})
+}
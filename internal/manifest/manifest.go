@@ -0,0 +1,115 @@
+// Package manifest records the files mcpgen writes into a project
+// and compares them with what is on disk.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+)
+
+// Path is the manifest location relative to the output directory.
+const Path = ".mcpgen/manifest.json"
+
+// Owners of a generated file, mirroring the generator's notion of ownership.
+const (
+	OwnerGenerator = "generator"
+	OwnerUser      = "user"
+)
+
+var ErrNotFound = errors.New("manifest not found")
+
+// Manifest lists every file written by one generation run.
+type Manifest struct {
+	Version string  `json:"version"`
+	Files   []Entry `json:"files"`
+}
+
+// Entry is one generated file. Path is slash-separated and relative to
+// the output directory; SHA256 is the hash of the content mcpgen wrote.
+type Entry struct {
+	Path     string `json:"path"`
+	Template string `json:"template"`
+	Owner    string `json:"owner"`
+	SHA256   string `json:"sha256"`
+}
+
+// New returns an empty manifest stamped with the running mcpgen version.
+func New() *Manifest {
+	return &Manifest{Version: Version(), Files: make([]Entry, 0)}
+}
+
+// Add records a written file, replacing any previous entry for its path.
+func (m *Manifest) Add(path, template, owner string, content []byte) {
+	entry := Entry{
+		Path:     filepath.ToSlash(path),
+		Template: template,
+		Owner:    owner,
+		SHA256:   Hash(content),
+	}
+
+	for i := range m.Files {
+		if m.Files[i].Path == entry.Path {
+			m.Files[i] = entry
+			return
+		}
+	}
+	m.Files = append(m.Files, entry)
+}
+
+// Load reads the manifest of outDir. It returns ErrNotFound when the
+// project was never generated or predates the manifest.
+func Load(outDir string) (*Manifest, error) {
+	return Read(os.DirFS(outDir))
+}
+
+// Read is Load for a project in fsys.
+func Read(fsys fs.FS) (*Manifest, error) {
+	raw, err := fs.ReadFile(fsys, Path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("could not read manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("could not decode manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// Encode returns the manifest as indented JSON with files sorted by path.
+func (m *Manifest) Encode() ([]byte, error) {
+	sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Path < m.Files[j].Path })
+
+	raw, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("could not encode manifest: %w", err)
+	}
+	return append(raw, '\n'), nil
+}
+
+// Hash returns the hex encoded SHA-256 of content.
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Version reports the mcpgen module version from the build info,
+// "(devel)" for local builds.
+func Version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "" {
+		return "(devel)"
+	}
+	return info.Main.Version
+}
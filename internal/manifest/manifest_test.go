@@ -0,0 +1,88 @@
+package manifest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifest_EncodeLoad(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	m := New()
+	m.Add(filepath.Join("internal", "b.go"), "b.go.gotmpl", OwnerUser, []byte("b"))
+	m.Add("a.go", "a.go.gotmpl", OwnerGenerator, []byte("a"))
+	m.Add("a.go", "a.go.gotmpl", OwnerGenerator, []byte("a2"))
+	writeManifest(t, dir, m)
+
+	loaded, err := Load(dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, Version(), loaded.Version)
+	require.Len(t, loaded.Files, 2)
+	assert.Equal(t, "a.go", loaded.Files[0].Path)
+	assert.Equal(t, Hash([]byte("a2")), loaded.Files[0].SHA256)
+	assert.Equal(t, "internal/b.go", loaded.Files[1].Path)
+
+	assert.Equal(t, OwnerUser, loaded.Files[1].Owner)
+	assert.Equal(t, "b.go.gotmpl", loaded.Files[1].Template)
+}
+
+func TestLoad_NotFound(t *testing.T) {
+	t.Parallel()
+
+	_, err := Load(t.TempDir())
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestStatus(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	m := New()
+	for _, rel := range []string{"cmd/main.go", "internal/edited.go", "internal/gone.go"} {
+		write(rel, "original")
+		m.Add(rel, "x.gotmpl", OwnerGenerator, []byte("original"))
+	}
+	writeManifest(t, dir, m)
+
+	write("internal/edited.go", "changed")
+	require.NoError(t, os.Remove(filepath.Join(dir, "internal", "gone.go")))
+	write("internal/extra.go", "mine")
+	write("go.sum", "checksums")
+	write(".git/HEAD", "ref")
+
+	statuses, err := Status(dir, m)
+	require.NoError(t, err)
+
+	assert.Equal(t, []FileStatus{
+		{Path: "cmd/main.go", Owner: OwnerGenerator, State: StatePristine},
+		{Path: "internal/edited.go", Owner: OwnerGenerator, State: StateModified},
+		{Path: "internal/extra.go", State: StateUnknown},
+		{Path: "internal/gone.go", Owner: OwnerGenerator, State: StateMissing},
+	}, statuses)
+}
+
+// writeManifest writes m into dir the way a generation run leaves it.
+func writeManifest(t *testing.T, dir string, m *Manifest) {
+	t.Helper()
+
+	raw, err := m.Encode()
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, filepath.FromSlash(Path))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, raw, 0o644))
+}
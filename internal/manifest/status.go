@@ -0,0 +1,107 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// State is how a file on disk compares with the manifest.
+type State string
+
+const (
+	// StatePristine files still have the content mcpgen wrote.
+	StatePristine State = "pristine"
+	// StateModified files were changed after generation.
+	StateModified State = "modified"
+	// StateMissing files are in the manifest but not on disk.
+	StateMissing State = "missing"
+	// StateUnknown files are on disk but were not written by mcpgen.
+	StateUnknown State = "unknown"
+)
+
+// FileStatus is the state of one file in the output directory.
+type FileStatus struct {
+	Path  string
+	Owner string
+	State State
+}
+
+// ignoredFiles are produced by the checks that run after generation
+// rather than by mcpgen, so they are never reported as unknown.
+var ignoredFiles = map[string]bool{
+	"go.sum": true,
+}
+
+// Check compares the manifest entry with the file on disk.
+func (e Entry) Check(outDir string) (State, error) {
+	return e.CheckFS(os.DirFS(outDir))
+}
+
+// CheckFS is Check for a project in fsys.
+func (e Entry) CheckFS(fsys fs.FS) (State, error) {
+	content, err := fs.ReadFile(fsys, e.Path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return StateMissing, nil
+		}
+		return "", err
+	}
+
+	if Hash(content) != e.SHA256 {
+		return StateModified, nil
+	}
+	return StatePristine, nil
+}
+
+// Status reports every manifest file plus the files under outDir that the
+// manifest does not know about. Hidden directories are skipped.
+func Status(outDir string, m *Manifest) ([]FileStatus, error) {
+	statuses := make([]FileStatus, 0, len(m.Files))
+	known := make(map[string]bool, len(m.Files))
+
+	for _, e := range m.Files {
+		known[e.Path] = true
+
+		state, err := e.Check(outDir)
+		if err != nil {
+			return nil, fmt.Errorf("could not check %s: %w", e.Path, err)
+		}
+		statuses = append(statuses, FileStatus{Path: e.Path, Owner: e.Owner, State: state})
+	}
+
+	err := filepath.WalkDir(outDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(outDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if rel != "." && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if known[rel] || ignoredFiles[rel] || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		statuses = append(statuses, FileStatus{Path: rel, State: StateUnknown})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not scan %s: %w", outDir, err)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Path < statuses[j].Path })
+	return statuses, nil
+}
@@ -0,0 +1,110 @@
+package outfs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+)
+
+// Format is the encoding of an Archive.
+type Format string
+
+const (
+	FormatTarGz Format = "tar"
+	FormatZip   Format = "zip"
+)
+
+// modTime is stamped on every entry so the same project always produces
+// the same archive. Zip cannot represent times before 1980.
+var modTime = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Archive collects files in memory and encodes them to a writer on Close.
+// It starts empty, so a project written into it is always a new one.
+type Archive struct {
+	*Memory
+	w      io.Writer
+	format Format
+	root   string
+	closed bool
+}
+
+// NewArchive returns an archive of format that Close writes to w. Entries
+// are placed under root when it is not empty.
+func NewArchive(w io.Writer, format Format, root string) (*Archive, error) {
+	switch format {
+	case FormatTarGz, FormatZip:
+	default:
+		return nil, fmt.Errorf("unknown archive format %q (expected tar or zip)", format)
+	}
+	return &Archive{Memory: NewMemory(), w: w, format: format, root: root}, nil
+}
+
+// Close encodes the files to the writer, sorted by name. It does not
+// close the writer.
+func (a *Archive) Close() error {
+	if a.closed {
+		return nil
+	}
+	a.closed = true
+
+	if a.format == FormatZip {
+		return a.writeZip()
+	}
+	return a.writeTarGz()
+}
+
+func (a *Archive) writeTarGz() error {
+	gz := gzip.NewWriter(a.w)
+	tw := tar.NewWriter(gz)
+
+	for _, name := range a.names() {
+		data, err := a.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		hdr := &tar.Header{
+			Name:    path.Join(a.root, name),
+			Mode:    0o644,
+			Size:    int64(len(data)),
+			ModTime: modTime,
+			Format:  tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("could not write %s: %w", name, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return fmt.Errorf("could not write %s: %w", name, err)
+		}
+	}
+
+	return errors.Join(tw.Close(), gz.Close())
+}
+
+func (a *Archive) writeZip() error {
+	zw := zip.NewWriter(a.w)
+
+	for _, name := range a.names() {
+		data, err := a.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		hdr := &zip.FileHeader{Name: path.Join(a.root, name), Method: zip.Deflate, Modified: modTime}
+		hdr.SetMode(0o644)
+
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return fmt.Errorf("could not write %s: %w", name, err)
+		}
+		if _, err := fw.Write(data); err != nil {
+			return fmt.Errorf("could not write %s: %w", name, err)
+		}
+	}
+	return zw.Close()
+}
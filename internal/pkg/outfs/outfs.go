@@ -0,0 +1,157 @@
+// Package outfs provides the writable file trees a project is generated
+// into: a directory on disk, memory, and .tar.gz and .zip archives.
+package outfs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing/fstest"
+)
+
+// FS is a file tree a project is generated into. Names are slash-separated
+// and relative to its root, as in io/fs. Reading it returns what is already
+// there, so a regeneration can be compared and merged with it.
+type FS interface {
+	fs.FS
+	// WriteFile creates or replaces name, creating missing parent directories.
+	WriteFile(name string, data []byte) error
+	// Remove deletes name. Removing a file that does not exist is not an error.
+	Remove(name string) error
+}
+
+// Dir is a directory on disk.
+type Dir struct {
+	fs.FS
+	root string
+}
+
+// NewDir returns the directory at root. It is created on the first write.
+func NewDir(root string) *Dir {
+	return &Dir{FS: os.DirFS(root), root: root}
+}
+
+// Root returns the path Dir was created with.
+func (d *Dir) Root() string {
+	return d.root
+}
+
+func (d *Dir) WriteFile(name string, data []byte) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+
+	p := filepath.Join(d.root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0o644)
+}
+
+// Remove deletes name along with the parent directories it leaves empty.
+func (d *Dir) Remove(name string) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if err := os.Remove(filepath.Join(d.root, filepath.FromSlash(name))); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		p := filepath.Join(d.root, filepath.FromSlash(dir))
+
+		entries, err := os.ReadDir(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			return nil
+		}
+		if err := os.Remove(p); err != nil {
+			return fmt.Errorf("could not remove empty directory %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// Memory keeps files in a map. It is safe for concurrent use.
+type Memory struct {
+	mu    sync.RWMutex
+	files fstest.MapFS
+}
+
+// NewMemory returns an empty in-memory tree.
+func NewMemory() *Memory {
+	return &Memory{files: make(fstest.MapFS)}
+}
+
+func (m *Memory) Open(name string) (fs.File, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.files.Open(name)
+}
+
+func (m *Memory) WriteFile(name string, data []byte) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.files[name] = &fstest.MapFile{Data: append([]byte(nil), data...), Mode: 0o644}
+	return nil
+}
+
+func (m *Memory) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.files, name)
+	return nil
+}
+
+// ReadFile returns the content of name.
+func (m *Memory) ReadFile(name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	f, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), f.Data...), nil
+}
+
+// Files returns a copy of every file, keyed by name.
+func (m *Memory) Files() map[string][]byte {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := make(map[string][]byte, len(m.files))
+	for name, f := range m.files {
+		out[name] = append([]byte(nil), f.Data...)
+	}
+	return out
+}
+
+func (m *Memory) names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.files))
+	for name := range m.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,150 @@
+package outfs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDir(t *testing.T) {
+	t.Parallel()
+
+	root := filepath.Join(t.TempDir(), "out")
+	d := NewDir(root)
+
+	require.NoError(t, d.WriteFile("a/b/c.txt", []byte("c")))
+	require.NoError(t, d.WriteFile("a/keep.txt", []byte("k")))
+
+	got, err := fs.ReadFile(d, "a/b/c.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "c", string(got))
+
+	require.NoError(t, d.Remove("a/b/c.txt"))
+	_, err = os.Stat(filepath.Join(root, "a", "b"))
+	assert.True(t, os.IsNotExist(err), "empty parents are removed")
+	_, err = os.Stat(filepath.Join(root, "a", "keep.txt"))
+	assert.NoError(t, err, "non-empty parents are kept")
+
+	require.NoError(t, d.Remove("a/keep.txt"))
+	_, err = os.Stat(root)
+	assert.NoError(t, err, "the root itself is kept")
+
+	assert.NoError(t, d.Remove("missing/file.txt"))
+	assert.ErrorIs(t, d.WriteFile("../escape.txt", nil), fs.ErrInvalid)
+}
+
+func TestMemory(t *testing.T) {
+	t.Parallel()
+
+	m := NewMemory()
+	require.NoError(t, m.WriteFile("cmd/main.go", []byte("package main")))
+	require.NoError(t, m.WriteFile("go.mod", []byte("module x")))
+
+	var walked []string
+	require.NoError(t, fs.WalkDir(m, ".", func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			walked = append(walked, p)
+		}
+		return err
+	}))
+	assert.Equal(t, []string{"cmd/main.go", "go.mod"}, walked)
+
+	files := m.Files()
+	files["go.mod"][0] = 'X'
+	got, err := m.ReadFile("go.mod")
+	require.NoError(t, err)
+	assert.Equal(t, "module x", string(got), "Files returns copies")
+
+	require.NoError(t, m.Remove("go.mod"))
+	_, err = m.ReadFile("go.mod")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+
+	assert.ErrorIs(t, m.WriteFile("/abs", nil), fs.ErrInvalid)
+}
+
+func TestArchive(t *testing.T) {
+	t.Parallel()
+
+	write := func(t *testing.T, format Format) []byte {
+		t.Helper()
+
+		var buf bytes.Buffer
+		a, err := NewArchive(&buf, format, "weather")
+		require.NoError(t, err)
+		require.NoError(t, a.WriteFile("go.mod", []byte("module weather\n")))
+		require.NoError(t, a.WriteFile("cmd/weather/main.go", []byte("package main\n")))
+		require.NoError(t, a.WriteFile("stale.go", nil))
+		require.NoError(t, a.Remove("stale.go"))
+		require.NoError(t, a.Close())
+		require.NoError(t, a.Close(), "closing twice is a no-op")
+		return buf.Bytes()
+	}
+
+	want := map[string]string{
+		"weather/cmd/weather/main.go": "package main\n",
+		"weather/go.mod":              "module weather\n",
+	}
+
+	t.Run("tar.gz", func(t *testing.T) {
+		t.Parallel()
+
+		raw := write(t, FormatTarGz)
+		assert.Equal(t, raw, write(t, FormatTarGz), "archives are reproducible")
+
+		gz, err := gzip.NewReader(bytes.NewReader(raw))
+		require.NoError(t, err)
+		tr := tar.NewReader(gz)
+
+		got := make(map[string]string)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+
+			data, err := io.ReadAll(tr)
+			require.NoError(t, err)
+			got[hdr.Name] = string(data)
+		}
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("zip", func(t *testing.T) {
+		t.Parallel()
+
+		raw := write(t, FormatZip)
+		assert.Equal(t, raw, write(t, FormatZip), "archives are reproducible")
+
+		zr, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+		require.NoError(t, err)
+
+		got := make(map[string]string)
+		for _, f := range zr.File {
+			rc, err := f.Open()
+			require.NoError(t, err)
+			data, err := io.ReadAll(rc)
+			require.NoError(t, err)
+			require.NoError(t, rc.Close())
+			got[f.Name] = string(data)
+		}
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewArchive(io.Discard, "rar", "")
+		assert.Error(t, err)
+	})
+}
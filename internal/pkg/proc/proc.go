@@ -0,0 +1,87 @@
+// Package proc runs commands in process groups of their own, so stopping
+// a command also stops what it started: the server `go run` compiled, the
+// test binaries of `go test`, the node process behind npx.
+package proc
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// WaitDelay bounds how long a command may take to exit once it was asked
+// to stop, and how long it may keep its output open after exiting.
+const WaitDelay = 2 * time.Second
+
+// Cmd is an exec.Cmd that leads a process group of its own. Its group is
+// only signalled while the leader has not been reaped: once it is and the
+// group is empty, the kernel may hand the group ID to unrelated processes.
+// Use the Run and Wait of Cmd, never Run, Wait, Output or CombinedOutput
+// of the embedded exec.Cmd.
+type Cmd struct {
+	*exec.Cmd
+
+	mu     sync.Mutex
+	reaped bool
+}
+
+// Command is exec.CommandContext for a command run in a process group of
+// its own. When ctx is done the whole group is asked to stop (SIGTERM on
+// Unix), and the command is killed if it is still running WaitDelay
+// later.
+func Command(ctx context.Context, name string, args ...string) *Cmd {
+	cmd := &Cmd{Cmd: exec.CommandContext(ctx, name, args...)}
+	setGroup(cmd.Cmd)
+	cmd.Cancel = func() error { return cmd.signal(terminateGroup) }
+	cmd.WaitDelay = WaitDelay
+	return cmd
+}
+
+// Run starts the command and waits for it as Wait does.
+func (c *Cmd) Run() error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+	return c.Wait()
+}
+
+// Wait waits for the started command to exit and kills the processes it
+// left in its group before reaping it: until then the exited leader holds
+// on to the group ID.
+func (c *Cmd) Wait() error {
+	if c.Process == nil {
+		return c.Cmd.Wait()
+	}
+
+	if err := waitExit(c.Process.Pid); !errors.Is(err, errors.ErrUnsupported) {
+		c.mu.Lock()
+		if err == nil {
+			_ = killGroup(c.Cmd)
+		}
+		c.reaped = true
+		c.mu.Unlock()
+	}
+	return c.Cmd.Wait()
+}
+
+// Kill kills the process group of the command. It does nothing for a
+// command that was not started or was already waited for.
+func (c *Cmd) Kill() {
+	if c.Process == nil {
+		return
+	}
+	_ = c.signal(killGroup)
+}
+
+func (c *Cmd) signal(fn func(*exec.Cmd) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.reaped {
+		return os.ErrProcessDone
+	}
+	return fn(c.Cmd)
+}
@@ -0,0 +1,40 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package proc
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// waitExit blocks until the child pid exits, leaving it to be reaped.
+func waitExit(pid int) error {
+	kq, err := unix.Kqueue()
+	if err != nil {
+		return err
+	}
+	defer unix.Close(kq)
+
+	var change unix.Kevent_t
+	unix.SetKevent(&change, pid, unix.EVFILT_PROC, unix.EV_ADD|unix.EV_ONESHOT)
+	change.Fflags = unix.NOTE_EXIT
+
+	events := make([]unix.Kevent_t, 1)
+	for {
+		n, err := unix.Kevent(kq, []unix.Kevent_t{change}, events, nil)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if n == 1 && events[0].Flags&unix.EV_ERROR != 0 {
+			// ESRCH: the child exited before it could be watched
+			if errno := unix.Errno(events[0].Data); errno != unix.ESRCH {
+				return errno
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,25 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package proc
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// Without process groups, only the command itself can be stopped. Its
+// os.Process is never signalled once reaped.
+
+func setGroup(*exec.Cmd) {}
+
+func terminateGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+func killGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+func waitExit(int) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,167 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package proc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// helperEnv makes the test binary act as a fake server: it starts a
+// long-running child of its own, prints the child's PID and sleeps.
+const helperEnv = "PROC_TEST_HELPER"
+
+func TestHelperProcess(t *testing.T) {
+	switch os.Getenv(helperEnv) {
+	case "server":
+		child := exec.Command(os.Args[0], "-test.run=^TestHelperProcess$")
+		child.Env = append(os.Environ(), helperEnv+"=child")
+		if err := child.Start(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		fmt.Println(child.Process.Pid)
+		time.Sleep(time.Minute)
+		os.Exit(0)
+	case "stubborn":
+		signal.Ignore(syscall.SIGTERM)
+		fmt.Println(os.Getpid())
+		time.Sleep(time.Minute)
+		os.Exit(0)
+	case "child":
+		time.Sleep(time.Minute)
+		os.Exit(0)
+	}
+}
+
+// fakeServer starts the test binary as the helper kind under ctx and
+// returns it with the PID it printed.
+func fakeServer(t *testing.T, ctx context.Context, kind string) (*Cmd, int) {
+	t.Helper()
+
+	cmd := Command(ctx, os.Args[0], "-test.run=^TestHelperProcess$")
+	cmd.Env = append(os.Environ(), helperEnv+"="+kind)
+
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+	t.Cleanup(cmd.Kill)
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	require.NoError(t, err)
+
+	pid, err := strconv.Atoi(string(bytes.TrimSpace([]byte(line))))
+	require.NoError(t, err)
+	return cmd, pid
+}
+
+// alive reports whether pid runs. Zombies count as gone: their parent is
+// dead and whoever adopted them may not reap them.
+func alive(pid int) bool {
+	if syscall.Kill(pid, 0) != nil {
+		return false
+	}
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return true
+	}
+	// pid (comm) state ...
+	if i := bytes.LastIndexByte(stat, ')'); i >= 0 && i+2 < len(stat) {
+		return stat[i+2] != 'Z'
+	}
+	return true
+}
+
+func assertGone(t *testing.T, pid int) {
+	t.Helper()
+	assert.Eventually(t, func() bool { return !alive(pid) }, 5*time.Second, 20*time.Millisecond, "process %d outlived its group", pid)
+}
+
+func TestCommand_CancelStopsGroup(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd, child := fakeServer(t, ctx, "server")
+	require.True(t, alive(child))
+
+	start := time.Now()
+	cancel()
+	err := cmd.Wait()
+
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), WaitDelay, "SIGTERM to the group should be enough")
+	assertGone(t, child)
+}
+
+func TestCommand_CancelKillsStubbornCommand(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd, pid := fakeServer(t, ctx, "stubborn")
+
+	cancel()
+	err := cmd.Wait()
+
+	require.Error(t, err)
+	assertGone(t, pid)
+}
+
+func TestKill(t *testing.T) {
+	t.Parallel()
+
+	cmd, child := fakeServer(t, context.Background(), "server")
+
+	cmd.Kill()
+	_ = cmd.Wait()
+
+	assertGone(t, cmd.Process.Pid)
+	assertGone(t, child)
+
+	// the leader was reaped: its group ID may belong to others by now
+	cmd.Kill()
+	assert.ErrorIs(t, cmd.signal(killGroup), os.ErrProcessDone)
+}
+
+func TestWait_KillsLeftoversBeforeReaping(t *testing.T) {
+	t.Parallel()
+
+	// the shell exits at once and leaves its child behind in the group
+	cmd := Command(context.Background(), "sh", "-c", os.Args[0]+" -test.run='^TestHelperProcess$' & echo $!")
+	cmd.Env = append(os.Environ(), helperEnv+"=child")
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	start := time.Now()
+	require.NoError(t, cmd.Run())
+	assert.Less(t, time.Since(start), WaitDelay, "the child's hold on stdout ends with it")
+
+	child, err := strconv.Atoi(string(bytes.TrimSpace(out.Bytes())))
+	require.NoError(t, err)
+	assertGone(t, child)
+}
+
+func TestKill_NotStarted(t *testing.T) {
+	t.Parallel()
+
+	Command(context.Background(), "true").Kill()
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, Command(context.Background(), "true").Run())
+	assert.Error(t, Command(context.Background(), "false").Run())
+}
@@ -0,0 +1,43 @@
+package schema
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ResolvePointer follows a local JSON pointer reference such as "#/$defs/unit".
+// References to other documents do not resolve.
+func ResolvePointer(root any, ref string) (any, bool) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, false
+	}
+
+	ptr := strings.TrimPrefix(ref, "#")
+	if ptr == "" {
+		return root, true
+	}
+
+	cur := root
+	for _, tok := range strings.Split(strings.TrimPrefix(ptr, "/"), "/") {
+		tok = strings.ReplaceAll(tok, "~1", "/")
+		tok = strings.ReplaceAll(tok, "~0", "~")
+
+		switch v := cur.(type) {
+		case map[string]any:
+			next, ok := v[tok]
+			if !ok {
+				return nil, false
+			}
+			cur = next
+		case []any:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			cur = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
@@ -0,0 +1,280 @@
+// Package schema builds placeholder values from JSON schemas.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// formatSamples are placeholder strings for the common string formats.
+var formatSamples = map[string]string{
+	"date-time":     "1970-01-01T00:00:00Z",
+	"date":          "1970-01-01",
+	"time":          "00:00:00Z",
+	"duration":      "P1D",
+	"email":         "user@example.com",
+	"hostname":      "example.com",
+	"ipv4":          "127.0.0.1",
+	"ipv6":          "::1",
+	"uri":           "https://example.com",
+	"uri-reference": "https://example.com",
+	"iri":           "https://example.com",
+	"url":           "https://example.com",
+	"uuid":          "00000000-0000-0000-0000-000000000000",
+}
+
+// Sample returns a placeholder instance of the raw JSON schema.
+// It prefers default, const, examples and enum values, in that order,
+// and otherwise builds the smallest value of the declared type that
+// satisfies its bounds. Objects get their required properties and any
+// optional property that declares a default or examples.
+func Sample(raw string) (any, error) {
+	var root any
+	if err := json.Unmarshal([]byte(raw), &root); err != nil {
+		return nil, fmt.Errorf("could not parse schema: %w", err)
+	}
+
+	s := sampler{root: root, visiting: make(map[string]bool)}
+	return s.value(root), nil
+}
+
+type sampler struct {
+	root     any
+	visiting map[string]bool
+}
+
+func (s *sampler) value(node any) any {
+	obj, ok := node.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	if v, ok := obj["default"]; ok {
+		return v
+	}
+	if v, ok := obj["const"]; ok {
+		return v
+	}
+	if examples, ok := obj["examples"].([]any); ok && len(examples) > 0 {
+		return examples[0]
+	}
+	if enum, ok := obj["enum"].([]any); ok && len(enum) > 0 {
+		return enum[0]
+	}
+
+	if ref, ok := obj["$ref"].(string); ok {
+		// a reference back into a schema being sampled has no finite sample
+		if s.visiting[ref] {
+			return nil
+		}
+		target, ok := ResolvePointer(s.root, ref)
+		if !ok {
+			return nil
+		}
+		s.visiting[ref] = true
+		defer delete(s.visiting, ref)
+		return s.value(target)
+	}
+
+	if all, ok := obj["allOf"].([]any); ok && len(all) > 0 {
+		return s.allOf(obj, all)
+	}
+
+	typ := schemaType(obj)
+	if typ == "" {
+		for _, kw := range []string{"oneOf", "anyOf"} {
+			if branches, ok := obj[kw].([]any); ok && len(branches) > 0 {
+				return s.value(branches[0])
+			}
+		}
+	}
+
+	switch typ {
+	case "object":
+		return s.object(obj)
+	case "array":
+		return s.array(obj)
+	case "string":
+		return stringSample(obj)
+	case "integer":
+		return int64(numberSample(obj, true))
+	case "number":
+		return numberSample(obj, false)
+	case "boolean":
+		return false
+	default:
+		return nil
+	}
+}
+
+// allOf merges the object samples of every branch with the schema's own keywords.
+// Non-object branches fall back to the first branch's sample.
+func (s *sampler) allOf(obj map[string]any, branches []any) any {
+	own := make(map[string]any, len(obj))
+	for k, v := range obj {
+		if k != "allOf" {
+			own[k] = v
+		}
+	}
+
+	merged := make(map[string]any)
+	for _, node := range append([]any{own}, branches...) {
+		v := s.value(node)
+		if v == nil {
+			continue
+		}
+		m, ok := v.(map[string]any)
+		if !ok {
+			return s.value(branches[0])
+		}
+		for k, val := range m {
+			merged[k] = val
+		}
+	}
+	return merged
+}
+
+func (s *sampler) object(obj map[string]any) map[string]any {
+	out := make(map[string]any)
+	props, _ := obj["properties"].(map[string]any)
+
+	required := make(map[string]bool)
+	if list, ok := obj["required"].([]any); ok {
+		for _, r := range list {
+			if name, ok := r.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		prop, _ := props[k].(map[string]any)
+		_, hasDefault := prop["default"]
+		_, hasExamples := prop["examples"]
+		if !required[k] && !hasDefault && !hasExamples {
+			continue
+		}
+		out[k] = s.value(props[k])
+	}
+
+	// required names without a declared property accept any value
+	for k := range required {
+		if _, ok := out[k]; !ok {
+			out[k] = s.value(additional(obj))
+		}
+	}
+	return out
+}
+
+func (s *sampler) array(obj map[string]any) []any {
+	out := make([]any, 0)
+
+	if prefix, ok := obj["prefixItems"].([]any); ok {
+		for _, p := range prefix {
+			out = append(out, s.value(p))
+		}
+	}
+
+	minItems := intKeyword(obj, "minItems")
+	items, hasItems := obj["items"]
+	for len(out) < minItems {
+		if !hasItems {
+			out = append(out, nil)
+			continue
+		}
+		out = append(out, s.value(items))
+	}
+	return out
+}
+
+func additional(obj map[string]any) any {
+	if ap, ok := obj["additionalProperties"].(map[string]any); ok {
+		return ap
+	}
+	return nil
+}
+
+func stringSample(obj map[string]any) string {
+	format, _ := obj["format"].(string)
+	v := formatSamples[format]
+
+	if minLen := intKeyword(obj, "minLength"); len(v) < minLen {
+		v += strings.Repeat("x", minLen-len(v))
+	}
+	if maxLen, ok := obj["maxLength"].(float64); ok && len(v) > int(maxLen) {
+		v = v[:int(maxLen)]
+	}
+	return v
+}
+
+// numberSample picks the value closest to zero within the declared bounds,
+// rounded up to multipleOf when it is set.
+func numberSample(obj map[string]any, integer bool) float64 {
+	step := 1.0
+	if !integer {
+		step = 0.5
+	}
+
+	v := 0.0
+	if lo, ok := obj["minimum"].(float64); ok && lo > v {
+		v = lo
+	}
+	if lo, ok := obj["exclusiveMinimum"].(float64); ok && lo >= v {
+		v = lo + step
+	}
+	if hi, ok := obj["maximum"].(float64); ok && hi < v {
+		v = hi
+	}
+	if hi, ok := obj["exclusiveMaximum"].(float64); ok && hi <= v {
+		v = hi - step
+	}
+
+	if m, ok := obj["multipleOf"].(float64); ok && m > 0 {
+		v = math.Ceil(v/m) * m
+	}
+	if integer {
+		v = math.Ceil(v)
+	}
+	return v
+}
+
+// schemaType returns the first non-null JSON type of a schema,
+// inferring object and array from their keywords when type is absent.
+func schemaType(obj map[string]any) string {
+	switch t := obj["type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				return s
+			}
+		}
+		return ""
+	default:
+		if _, ok := obj["properties"]; ok {
+			return "object"
+		}
+		if _, ok := obj["required"]; ok {
+			return "object"
+		}
+		if _, ok := obj["items"]; ok {
+			return "array"
+		}
+		return ""
+	}
+}
+
+func intKeyword(obj map[string]any, key string) int {
+	v, _ := obj[key].(float64)
+	return int(v)
+}
@@ -0,0 +1,187 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSample(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		schema   string
+		expected string
+	}{
+		{
+			name:     "empty object",
+			schema:   `{"type":"object"}`,
+			expected: `{}`,
+		},
+		{
+			name: "required scalars get zero values",
+			schema: `{
+				"type": "object",
+				"properties": {
+					"name": {"type": "string"},
+					"count": {"type": "integer"},
+					"ratio": {"type": "number"},
+					"ok": {"type": "boolean"},
+					"skipped": {"type": "string"}
+				},
+				"required": ["name", "count", "ratio", "ok"]
+			}`,
+			expected: `{"name": "", "count": 0, "ratio": 0, "ok": false}`,
+		},
+		{
+			name: "default const examples and enum win over zero values",
+			schema: `{
+				"type": "object",
+				"properties": {
+					"a": {"type": "string", "default": "def"},
+					"b": {"const": 7},
+					"c": {"type": "string", "examples": ["ex"]},
+					"d": {"type": "string", "enum": ["first", "second"]},
+					"e": {"type": "integer", "default": 3}
+				},
+				"required": ["a", "b", "c", "d"]
+			}`,
+			expected: `{"a": "def", "b": 7, "c": "ex", "d": "first", "e": 3}`,
+		},
+		{
+			name: "bounds and formats",
+			schema: `{
+				"type": "object",
+				"properties": {
+					"id": {"type": "string", "format": "uuid"},
+					"code": {"type": "string", "minLength": 3},
+					"at": {"type": "string", "format": "date-time"},
+					"n": {"type": "integer", "minimum": 5, "multipleOf": 4},
+					"x": {"type": "number", "exclusiveMinimum": 1},
+					"neg": {"type": "integer", "maximum": -2}
+				},
+				"required": ["id", "code", "at", "n", "x", "neg"]
+			}`,
+			expected: `{
+				"id": "00000000-0000-0000-0000-000000000000",
+				"code": "xxx",
+				"at": "1970-01-01T00:00:00Z",
+				"n": 8,
+				"x": 1.5,
+				"neg": -2
+			}`,
+		},
+		{
+			name: "arrays honor minItems and prefixItems",
+			schema: `{
+				"type": "object",
+				"properties": {
+					"tags": {"type": "array", "items": {"type": "string"}, "minItems": 2},
+					"pair": {"type": "array", "prefixItems": [{"type": "integer"}, {"type": "boolean"}]}
+				},
+				"required": ["tags", "pair"]
+			}`,
+			expected: `{"tags": ["", ""], "pair": [0, false]}`,
+		},
+		{
+			name: "references and recursion",
+			schema: `{
+				"type": "object",
+				"properties": {
+					"root": {"$ref": "#/$defs/node"}
+				},
+				"required": ["root"],
+				"$defs": {
+					"node": {
+						"type": "object",
+						"properties": {
+							"name": {"type": "string"},
+							"children": {"type": "array", "items": {"$ref": "#/$defs/node"}}
+						},
+						"required": ["name", "children"]
+					}
+				}
+			}`,
+			expected: `{"root": {"name": "", "children": []}}`,
+		},
+		{
+			name: "composition",
+			schema: `{
+				"allOf": [
+					{"type": "object", "properties": {"a": {"type": "string"}}, "required": ["a"]},
+					{"type": "object", "properties": {"b": {"type": "integer"}}, "required": ["b"]}
+				],
+				"properties": {
+					"c": {"oneOf": [{"type": "boolean"}, {"type": "string"}]}
+				},
+				"required": ["c"]
+			}`,
+			expected: `{"a": "", "b": 0, "c": false}`,
+		},
+		{
+			name:     "nullable type uses the non-null type",
+			schema:   `{"type":"object","properties":{"v":{"type":["null","string"]}},"required":["v"]}`,
+			expected: `{"v": ""}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Sample(tt.schema)
+			require.NoError(t, err)
+
+			data, err := json.Marshal(got)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(data))
+
+			var instance any
+			require.NoError(t, json.Unmarshal(data, &instance))
+			assert.NoError(t, Validate(tt.schema, instance))
+		})
+	}
+
+	t.Run("invalid json", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Sample("{")
+		require.Error(t, err)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	schema := `{"type":"object","properties":{"n":{"type":"integer"}},"required":["n"]}`
+
+	assert.NoError(t, Validate(schema, map[string]any{"n": 1.0}))
+	assert.Error(t, Validate(schema, map[string]any{}))
+	assert.Error(t, Validate(`{"$ref":"https://example.com/s.json"}`, nil))
+}
+
+func TestResolvePointer(t *testing.T) {
+	t.Parallel()
+
+	root := map[string]any{
+		"$defs": map[string]any{"a/b": map[string]any{"type": "string"}},
+		"list":  []any{"x", "y"},
+	}
+
+	got, ok := ResolvePointer(root, "#/$defs/a~1b")
+	require.True(t, ok)
+	assert.Equal(t, map[string]any{"type": "string"}, got)
+
+	got, ok = ResolvePointer(root, "#/list/1")
+	require.True(t, ok)
+	assert.Equal(t, "y", got)
+
+	_, ok = ResolvePointer(root, "#/missing")
+	assert.False(t, ok)
+
+	_, ok = ResolvePointer(root, "other.json#/x")
+	assert.False(t, ok)
+}
@@ -0,0 +1,34 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+const resourceURL = "mcpgen:///sample.json"
+
+// Validate reports whether instance conforms to the raw JSON schema.
+// The schema is compiled as draft 2020-12 without a URL loader,
+// so only local references resolve.
+func Validate(raw string, instance any) error {
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(raw))
+	if err != nil {
+		return fmt.Errorf("could not parse schema: %w", err)
+	}
+
+	c := jsonschema.NewCompiler()
+	c.DefaultDraft(jsonschema.Draft2020)
+	c.UseLoader(nil)
+
+	if err := c.AddResource(resourceURL, doc); err != nil {
+		return fmt.Errorf("could not load schema: %w", err)
+	}
+
+	sch, err := c.Compile(resourceURL)
+	if err != nil {
+		return fmt.Errorf("could not compile schema: %w", err)
+	}
+	return sch.Validate(instance)
+}
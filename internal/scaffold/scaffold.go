@@ -2,6 +2,7 @@ package scaffold
 
 import (
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/alesr/mcpgen/internal/config"
@@ -26,66 +27,70 @@ func DefaultConfig(
 	}
 
 	if addTool {
-		cfg.Tool = &config.ToolConfig{ID: config.DefaultToolID}
+		cfg.Tools = []config.ToolConfig{{ID: config.DefaultToolID}}
 	}
 
 	if addPrompt {
-		cfg.Prompt = &config.PromptConfig{
+		cfg.Prompts = []config.PromptConfig{{
 			ID:       config.DefaultPromptID,
 			Template: config.DefaultPromptTemplate,
-		}
+		}}
 	}
 
 	if addResource {
 		res := config.ResourceConfig{ID: config.DefaultResourceID, Text: config.DefaultResourceText}
 		res.URI = "file:///" + config.DefaultResourceID
-		cfg.Resource = &res
+		cfg.Resources = []config.ResourceConfig{res}
 	}
 
 	return &cfg, outDir
 }
 
-func PrintSummary(cfg *config.Config, outDir string) {
+func PrintSummary(w io.Writer, cfg *config.Config, outDir string) {
 	var features []string
-	if cfg.Tool != nil {
-		features = append(features, cfg.Tool.ID)
+	for _, t := range cfg.Tools {
+		features = append(features, t.ID)
 	}
-	if cfg.Resource != nil {
-		features = append(features, cfg.Resource.ID)
+	for _, r := range cfg.Resources {
+		features = append(features, r.ID)
 	}
-	if cfg.Prompt != nil {
-		features = append(features, cfg.Prompt.ID)
+	for _, p := range cfg.Prompts {
+		features = append(features, p.ID)
 	}
 	featureList := "none"
 	if len(features) > 0 {
 		featureList = strings.Join(features, ", ")
 	}
 
-	var resourceDetail string
-	if cfg.Resource != nil {
-		res := cfg.Resource
+	var details strings.Builder
+	if len(cfg.Tools) > 1 || len(cfg.Resources) > 1 || len(cfg.Prompts) > 1 {
+		fmt.Fprintf(&details, "  Counts:   %d tools, %d resources, %d prompts\n",
+			len(cfg.Tools), len(cfg.Resources), len(cfg.Prompts))
+	}
+
+	for _, res := range cfg.Resources {
 		if res.URITemplate != "" {
-			resourceDetail = fmt.Sprintf("  Resource template: %s\n", res.URITemplate)
+			fmt.Fprintf(&details, "  Resource template: %s\n", res.URITemplate)
 		} else {
-			resourceDetail = fmt.Sprintf("  Resource uri: %s\n", res.URI)
+			fmt.Fprintf(&details, "  Resource uri: %s\n", res.URI)
 		}
 	}
 
-	fmt.Printf(`
+	fmt.Fprintf(w, `
 Summary
   Server:   %s (%s)
   Module:   %s
   Features: %s
 %s  Output:   %s
 
-`, cfg.Server.Name, cfg.Server.Version, cfg.Server.Module, featureList, resourceDetail, outDir)
+`, cfg.Server.Name, cfg.Server.Version, cfg.Server.Module, featureList, details.String(), outDir)
 }
 
-func PrintInspectorHint(outDir string, cfg *config.Config) {
+func PrintInspectorHint(w io.Writer, outDir string, cfg *config.Config) {
 	serverName := utils.DefaultServerName(cfg.Server.Name)
 
 	if cfg.Transport.Type == "http" {
-		fmt.Printf(`
+		fmt.Fprintf(w, `
 Open in Inspector:
   cd %s
   go run ./cmd/%s &
@@ -98,7 +103,7 @@ Open in Inspector:
 		return
 	}
 
-	fmt.Printf(`
+	fmt.Fprintf(w, `
 Open in Inspector:
   cd %s
   npx @modelcontextprotocol/inspector
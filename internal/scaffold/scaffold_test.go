@@ -27,21 +27,21 @@ func TestDefaultConfig_Features(t *testing.T) {
 			cfg, _ := DefaultConfig("./generated", "stdio", 8080, tt.addTool, tt.addResource, tt.addPrompt)
 
 			if tt.addTool {
-				assert.NotNil(t, cfg.Tool)
+				assert.Len(t, cfg.Tools, 1)
 			} else {
-				assert.Nil(t, cfg.Tool)
+				assert.Empty(t, cfg.Tools)
 			}
 
 			if tt.addResource {
-				assert.NotNil(t, cfg.Resource)
+				assert.Len(t, cfg.Resources, 1)
 			} else {
-				assert.Nil(t, cfg.Resource)
+				assert.Empty(t, cfg.Resources)
 			}
 
 			if tt.addPrompt {
-				assert.NotNil(t, cfg.Prompt)
+				assert.Len(t, cfg.Prompts, 1)
 			} else {
-				assert.Nil(t, cfg.Prompt)
+				assert.Empty(t, cfg.Prompts)
 			}
 		})
 	}
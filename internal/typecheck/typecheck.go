@@ -0,0 +1,571 @@
+// Package typecheck type-checks a generated project in-process with
+// go/parser and go/types. Dependencies are read from source: the standard
+// library from GOROOT, modules from a vendor directory or the local module
+// cache. It never runs the go command and never touches the network.
+package typecheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alesr/mcpgen/internal/manifest"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+var (
+	ErrNoGoMod = errors.New("project has no go.mod")
+	// ErrModuleNotFound is returned for an import that neither the vendor
+	// directory nor the module cache provides.
+	ErrModuleNotFound = errors.New("module not found in vendor directory or module cache")
+)
+
+// Options tell Check where dependencies are.
+type Options struct {
+	// Vendor is a directory laid out like the one `go mod vendor` writes,
+	// holding the SDK and its dependencies by import path. Packages found
+	// there win over the module cache.
+	Vendor string
+	// ModCache is the module cache. Empty uses GOMODCACHE, or pkg/mod in
+	// the first GOPATH entry.
+	ModCache string
+}
+
+// Diagnostic is one compile error of a generated file.
+type Diagnostic struct {
+	// Path is slash-separated and relative to the project root.
+	Path   string
+	Line   int
+	Column int
+	// Template is the template that produced Path, per the manifest of the
+	// project. It is empty when the manifest does not list the file.
+	Template string
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	s := fmt.Sprintf("%s:%d:%d: %s", d.Path, d.Line, d.Column, d.Message)
+	if d.Template != "" {
+		s += " (from template " + d.Template + ")"
+	}
+	return s
+}
+
+// Error lists every compile error of a project, sorted by position.
+type Error struct {
+	Diagnostics []Diagnostic
+}
+
+func (e *Error) Error() string {
+	lines := make([]string, 0, len(e.Diagnostics)+1)
+	lines = append(lines, fmt.Sprintf("%d type errors:", len(e.Diagnostics)))
+	for _, d := range e.Diagnostics {
+		lines = append(lines, "  "+d.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Check type-checks every package of the project in fsys, tests included.
+// Compile errors are returned as an *Error.
+func Check(ctx context.Context, fsys fs.FS, opts Options) error {
+	raw, err := fs.ReadFile(fsys, "go.mod")
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNoGoMod
+	}
+	if err != nil {
+		return err
+	}
+
+	mod, err := modfile.ParseLax("go.mod", raw, nil)
+	if err != nil {
+		return fmt.Errorf("could not parse go.mod: %w", err)
+	}
+	if mod.Module == nil {
+		return fmt.Errorf("could not parse go.mod: no module directive")
+	}
+
+	dirs, err := packageDirs(fsys)
+	if err != nil {
+		return err
+	}
+
+	modCache := opts.ModCache
+	if modCache == "" {
+		modCache = defaultModCache()
+	}
+
+	bctx := build.Default
+	bctx.CgoEnabled = false
+
+	c := &checker{
+		ctx:      ctx,
+		fsys:     fsys,
+		fset:     token.NewFileSet(),
+		build:    bctx,
+		vendor:   opts.Vendor,
+		modCache: modCache,
+		module:   mod.Module.Mod.Path,
+		dirs:     dirs,
+		deps:     make(map[string]*types.Package),
+		local:    make(map[string]*types.Package),
+		seen:     make(map[Diagnostic]bool),
+	}
+	c.versions = c.buildList(mod)
+	c.sizes = types.SizesFor("gc", bctx.GOARCH)
+
+	names := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		names = append(names, dir)
+	}
+	sort.Strings(names)
+
+	for _, dir := range names {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := c.checkLocal(dir); err != nil {
+			return err
+		}
+		if err := c.checkTests(dir); err != nil {
+			return err
+		}
+	}
+
+	if len(c.diags) == 0 {
+		return nil
+	}
+
+	templates := make(map[string]string)
+	if m, err := manifest.Read(fsys); err == nil {
+		for _, e := range m.Files {
+			templates[e.Path] = e.Template
+		}
+	}
+	for i := range c.diags {
+		c.diags[i].Template = templates[c.diags[i].Path]
+	}
+
+	sort.Slice(c.diags, func(i, j int) bool {
+		a, b := c.diags[i], c.diags[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return &Error{Diagnostics: c.diags}
+}
+
+// projectFiles are the Go files of one directory of the project.
+type projectFiles struct {
+	src, tests []string
+}
+
+// packageDirs groups the Go files of fsys by directory, skipping the
+// directories the go command ignores.
+func packageDirs(fsys fs.FS) (map[string]*projectFiles, error) {
+	dirs := make(map[string]*projectFiles)
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name := d.Name()
+		if d.IsDir() {
+			if p != "." && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if path.Ext(name) != ".go" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+			return nil
+		}
+
+		dir := path.Dir(p)
+		files := dirs[dir]
+		if files == nil {
+			files = &projectFiles{}
+			dirs[dir] = files
+		}
+		if strings.HasSuffix(name, "_test.go") {
+			files.tests = append(files.tests, p)
+		} else {
+			files.src = append(files.src, p)
+		}
+		return nil
+	})
+	return dirs, err
+}
+
+type checker struct {
+	ctx      context.Context
+	fsys     fs.FS
+	fset     *token.FileSet
+	build    build.Context
+	sizes    types.Sizes
+	vendor   string
+	modCache string
+
+	module   string
+	versions map[string]string
+	dirs     map[string]*projectFiles
+
+	// deps holds dependencies by directory, local project packages by
+	// import path. A nil entry is a package being checked.
+	deps  map[string]*types.Package
+	local map[string]*types.Package
+
+	diags []Diagnostic
+	seen  map[Diagnostic]bool
+}
+
+func (c *checker) Import(path string) (*types.Package, error) {
+	return c.ImportFrom(path, "", 0)
+}
+
+// ImportFrom resolves imports of both project and dependency packages.
+func (c *checker) ImportFrom(importPath, fromDir string, _ types.ImportMode) (*types.Package, error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+	if importPath == "unsafe" {
+		return types.Unsafe, nil
+	}
+
+	if dir, ok := c.localDir(importPath); ok {
+		return c.checkLocal(dir)
+	}
+
+	dir, err := c.resolve(importPath, fromDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if pkg, ok := c.deps[dir]; ok {
+		if pkg == nil {
+			return nil, fmt.Errorf("import cycle through %s", importPath)
+		}
+		return pkg, nil
+	}
+	c.deps[dir] = nil
+
+	bp, err := c.build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, fmt.Errorf("could not load %s: %w", importPath, err)
+	}
+
+	files := make([]*ast.File, 0, len(bp.GoFiles))
+	for _, name := range bp.GoFiles {
+		f, err := parser.ParseFile(c.fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %s: %w", importPath, err)
+		}
+		files = append(files, f)
+	}
+
+	// only the API of dependencies matters; their own errors are not ours
+	conf := types.Config{
+		Importer:         c,
+		Sizes:            c.sizes,
+		IgnoreFuncBodies: true,
+		FakeImportC:      true,
+		Error:            func(error) {},
+	}
+	pkg, _ := conf.Check(importPath, c.fset, files, nil)
+	c.deps[dir] = pkg
+	return pkg, nil
+}
+
+// localDir returns the project directory of importPath.
+func (c *checker) localDir(importPath string) (string, bool) {
+	var dir string
+	switch {
+	case importPath == c.module:
+		dir = "."
+	case strings.HasPrefix(importPath, c.module+"/"):
+		dir = strings.TrimPrefix(importPath, c.module+"/")
+	default:
+		return "", false
+	}
+
+	files, ok := c.dirs[dir]
+	return dir, ok && len(files.src) > 0
+}
+
+func (c *checker) importPath(dir string) string {
+	if dir == "." {
+		return c.module
+	}
+	return c.module + "/" + dir
+}
+
+// checkLocal type-checks the non-test files of a project directory.
+func (c *checker) checkLocal(dir string) (*types.Package, error) {
+	importPath := c.importPath(dir)
+	if pkg, ok := c.local[importPath]; ok {
+		if pkg == nil {
+			return nil, fmt.Errorf("import cycle through %s", importPath)
+		}
+		return pkg, nil
+	}
+	c.local[importPath] = nil
+
+	files, err := c.parseLocal(c.dirs[dir].src)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg, _ := c.config().Check(importPath, c.fset, files, nil)
+	c.local[importPath] = pkg
+	return pkg, nil
+}
+
+// checkTests type-checks the package of dir with its in-package tests,
+// then its external _test package if it has one.
+func (c *checker) checkTests(dir string) error {
+	if len(c.dirs[dir].tests) == 0 {
+		return nil
+	}
+
+	src, err := c.parseLocal(c.dirs[dir].src)
+	if err != nil {
+		return err
+	}
+	tests, err := c.parseLocal(c.dirs[dir].tests)
+	if err != nil {
+		return err
+	}
+
+	internal, external := src, []*ast.File(nil)
+	for _, f := range tests {
+		if strings.HasSuffix(f.Name.Name, "_test") {
+			external = append(external, f)
+		} else {
+			internal = append(internal, f)
+		}
+	}
+
+	importPath := c.importPath(dir)
+	withTests, _ := c.config().Check(importPath, c.fset, internal, nil)
+	if len(external) == 0 {
+		return nil
+	}
+
+	// the external tests import the package with its in-package tests
+	prev := c.local[importPath]
+	c.local[importPath] = withTests
+	_, _ = c.config().Check(importPath+"_test", c.fset, external, nil)
+	c.local[importPath] = prev
+	return nil
+}
+
+// parseLocal parses project files, recording syntax errors. Files are
+// named by their project path, so positions map back to the manifest.
+func (c *checker) parseLocal(names []string) ([]*ast.File, error) {
+	files := make([]*ast.File, 0, len(names))
+	for _, name := range names {
+		src, err := fs.ReadFile(c.fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		f, err := parser.ParseFile(c.fset, name, src, parser.SkipObjectResolution)
+		var list scanner.ErrorList
+		if errors.As(err, &list) {
+			for _, e := range list {
+				c.report(e.Pos, e.Msg)
+			}
+		} else if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+func (c *checker) config() *types.Config {
+	return &types.Config{
+		Importer: c,
+		Sizes:    c.sizes,
+		Error: func(err error) {
+			var te types.Error
+			if errors.As(err, &te) {
+				c.report(te.Fset.Position(te.Pos), te.Msg)
+			}
+		},
+	}
+}
+
+func (c *checker) report(pos token.Position, msg string) {
+	d := Diagnostic{Path: pos.Filename, Line: pos.Line, Column: pos.Column, Message: msg}
+	if c.seen[d] {
+		return
+	}
+	c.seen[d] = true
+	c.diags = append(c.diags, d)
+}
+
+// resolve returns the source directory of a dependency.
+func (c *checker) resolve(importPath, fromDir string) (string, error) {
+	goroot := filepath.Join(c.build.GOROOT, "src")
+	first, _, _ := strings.Cut(importPath, "/")
+
+	if !strings.Contains(first, ".") {
+		return filepath.Join(goroot, filepath.FromSlash(importPath)), nil
+	}
+
+	// the standard library vendors the golang.org/x packages it uses
+	if fromDir != "" && isWithin(fromDir, goroot) {
+		return filepath.Join(goroot, "vendor", filepath.FromSlash(importPath)), nil
+	}
+
+	if c.vendor != "" {
+		dir := filepath.Join(c.vendor, filepath.FromSlash(importPath))
+		if isDir(dir) {
+			return dir, nil
+		}
+	}
+
+	modPath, version := c.moduleOf(importPath)
+	if modPath == "" {
+		return "", fmt.Errorf("%w: no required module provides %s", ErrModuleNotFound, importPath)
+	}
+
+	root, err := c.moduleDir(modPath, version)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(importPath, modPath)))
+	if !isDir(dir) {
+		return "", fmt.Errorf("%w: %s@%s has no package %s", ErrModuleNotFound, modPath, version, importPath)
+	}
+	return dir, nil
+}
+
+// moduleOf returns the required module providing importPath, the one with
+// the longest matching path.
+func (c *checker) moduleOf(importPath string) (string, string) {
+	var best string
+	for modPath := range c.versions {
+		if (importPath == modPath || strings.HasPrefix(importPath, modPath+"/")) && len(modPath) > len(best) {
+			best = modPath
+		}
+	}
+	return best, c.versions[best]
+}
+
+func (c *checker) moduleDir(modPath, version string) (string, error) {
+	escPath, err := module.EscapePath(modPath)
+	if err != nil {
+		return "", err
+	}
+	escVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(c.modCache, filepath.FromSlash(escPath)+"@"+escVersion)
+	if !isDir(dir) {
+		return "", fmt.Errorf("%w: %s@%s", ErrModuleNotFound, modPath, version)
+	}
+	return dir, nil
+}
+
+// buildList selects a version of every module reachable from the
+// requirements of mod, the highest one required, as minimal version
+// selection does. go.mod files missing from the cache end the walk there.
+func (c *checker) buildList(mod *modfile.File) map[string]string {
+	versions := make(map[string]string)
+	queue := make([]module.Version, 0, len(mod.Require))
+	for _, r := range mod.Require {
+		queue = append(queue, r.Mod)
+	}
+
+	visited := make(map[module.Version]bool)
+	for len(queue) > 0 {
+		m := queue[0]
+		queue = queue[1:]
+		if visited[m] {
+			continue
+		}
+		visited[m] = true
+
+		if semver.Compare(m.Version, versions[m.Path]) > 0 {
+			versions[m.Path] = m.Version
+		}
+
+		dep, err := c.readGoMod(m)
+		if err != nil {
+			continue
+		}
+		for _, r := range dep.Require {
+			queue = append(queue, r.Mod)
+		}
+	}
+	return versions
+}
+
+// readGoMod reads the go.mod of a module version from the download cache,
+// or from the extracted module.
+func (c *checker) readGoMod(m module.Version) (*modfile.File, error) {
+	escPath, err := module.EscapePath(m.Path)
+	if err != nil {
+		return nil, err
+	}
+	escVersion, err := module.EscapeVersion(m.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []string{
+		filepath.Join(c.modCache, "cache", "download", filepath.FromSlash(escPath), "@v", escVersion+".mod"),
+		filepath.Join(c.modCache, filepath.FromSlash(escPath)+"@"+escVersion, "go.mod"),
+	}
+	for _, p := range candidates {
+		raw, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		return modfile.ParseLax(p, raw, nil)
+	}
+	return nil, fs.ErrNotExist
+}
+
+func defaultModCache() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	gopath := filepath.SplitList(build.Default.GOPATH)
+	if len(gopath) == 0 {
+		return ""
+	}
+	return filepath.Join(gopath[0], "pkg", "mod")
+}
+
+func isDir(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && info.IsDir()
+}
+
+func isWithin(p, root string) bool {
+	rel, err := filepath.Rel(root, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
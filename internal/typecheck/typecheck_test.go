@@ -0,0 +1,135 @@
+package typecheck
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/generator"
+	"github.com/alesr/mcpgen/internal/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/mod/modfile"
+)
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	vendor := t.TempDir()
+	greetDir := filepath.Join(vendor, "example.com", "greet")
+	require.NoError(t, os.MkdirAll(greetDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(greetDir, "greet.go"), []byte("package greet\n\nfunc Hello(name string) string { return \"hello \" + name }\n"), 0o644))
+
+	// an empty module cache makes sure nothing is found outside the vendor directory
+	opts := Options{Vendor: vendor, ModCache: t.TempDir()}
+
+	project := func(mainGo string) fstest.MapFS {
+		files := fstest.MapFS{
+			"go.mod":                       {Data: []byte("module example.com/app\n\ngo 1.25\n\nrequire example.com/greet v1.0.0\n")},
+			"cmd/app/main.go":              {Data: []byte(mainGo)},
+			"internal/names/names.go":      {Data: []byte("package names\n\nconst Default = \"world\"\n")},
+			"internal/names/names_test.go": {Data: []byte("package names\n\nimport \"testing\"\n\nfunc TestDefault(t *testing.T) {\n\tif Default == \"\" {\n\t\tt.Fatal(\"empty\")\n\t}\n}\n")},
+			"internal/names/ext_test.go":   {Data: []byte("package names_test\n\nimport (\n\t\"testing\"\n\n\t\"example.com/app/internal/names\"\n)\n\nfunc TestExt(t *testing.T) { _ = names.Default }\n")},
+		}
+
+		m := manifest.New()
+		m.Add("cmd/app/main.go", "cmd_main.go.gotmpl", manifest.OwnerGenerator, []byte(mainGo))
+		raw, err := m.Encode()
+		require.NoError(t, err)
+		files[manifest.Path] = &fstest.MapFile{Data: raw}
+		return files
+	}
+
+	t.Run("valid project", func(t *testing.T) {
+		t.Parallel()
+
+		src := "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/app/internal/names\"\n\t\"example.com/greet\"\n)\n\nfunc main() { fmt.Println(greet.Hello(names.Default)) }\n"
+		assert.NoError(t, Check(ctx, project(src), opts))
+	})
+
+	t.Run("errors map to templates", func(t *testing.T) {
+		t.Parallel()
+
+		src := "package main\n\nimport \"example.com/greet\"\n\nfunc main() {\n\t_ = greet.Hello(1)\n\t_ = greet.Bye()\n}\n"
+		err := Check(ctx, project(src), opts)
+
+		var te *Error
+		require.ErrorAs(t, err, &te)
+		require.Len(t, te.Diagnostics, 2)
+
+		first := te.Diagnostics[0]
+		assert.Equal(t, "cmd/app/main.go", first.Path)
+		assert.Equal(t, 6, first.Line)
+		assert.Equal(t, "cmd_main.go.gotmpl", first.Template)
+		assert.Contains(t, first.Message, "cannot use 1")
+		assert.Contains(t, te.Diagnostics[1].Message, "undefined: greet.Bye")
+		assert.Contains(t, err.Error(), "(from template cmd_main.go.gotmpl)")
+	})
+
+	t.Run("syntax errors", func(t *testing.T) {
+		t.Parallel()
+
+		err := Check(ctx, project("package main\n\nfunc main() {\n"), opts)
+
+		var te *Error
+		require.ErrorAs(t, err, &te)
+		assert.Equal(t, "cmd/app/main.go", te.Diagnostics[0].Path)
+	})
+
+	t.Run("missing module", func(t *testing.T) {
+		t.Parallel()
+
+		src := "package main\n\nimport \"example.com/greet\"\n\nfunc main() { _ = greet.Hello(\"x\") }\n"
+		err := Check(ctx, project(src), Options{ModCache: t.TempDir()})
+
+		var te *Error
+		require.ErrorAs(t, err, &te)
+		assert.Contains(t, te.Diagnostics[0].Message, ErrModuleNotFound.Error())
+	})
+
+	t.Run("no go.mod", func(t *testing.T) {
+		t.Parallel()
+
+		err := Check(ctx, fstest.MapFS{"main.go": {Data: []byte("package main\n")}}, opts)
+		assert.True(t, errors.Is(err, ErrNoGoMod))
+	})
+}
+
+// TestCheck_GeneratedProject type-checks a rendered project against the
+// SDK in the local module cache, when it is there.
+func TestCheck_GeneratedProject(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{
+		Server:    config.ServerConfig{Name: "weather"},
+		Tools:     []config.ToolConfig{{ID: "forecast", InputSchema: `{"type":"object","properties":{"city":{"type":"string"}}}`}},
+		Prompts:   []config.PromptConfig{{ID: "report", Arguments: []config.PromptArgumentConfig{{Name: "city", Required: true}}}},
+		Resources: []config.ResourceConfig{{ID: "station", URITemplate: "station://{id}"}},
+	}
+	require.NoError(t, cfg.Validate())
+
+	files, err := generator.Render(cfg)
+	require.NoError(t, err)
+
+	fsys := make(fstest.MapFS, len(files))
+	for p, content := range files {
+		fsys[p] = &fstest.MapFile{Data: content}
+	}
+
+	mod, err := modfile.ParseLax("go.mod", files["go.mod"], nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, mod.Require)
+
+	sdk := mod.Require[0].Mod
+	if _, err := (&checker{modCache: defaultModCache()}).moduleDir(sdk.Path, sdk.Version); err != nil {
+		t.Skipf("SDK not in the module cache: %v", err)
+	}
+
+	assert.NoError(t, Check(context.Background(), fsys, Options{}))
+}
@@ -86,7 +86,7 @@ func RunInteractive() (*config.Config, string, bool, error) {
 		return nil, "", false, fmt.Errorf("could not validate config: %w", err)
 	}
 
-	scaffold.PrintSummary(cfg, out)
+	scaffold.PrintSummary(os.Stdout, cfg, out)
 	return cfg, out, state.runTest, nil
 }
 
@@ -105,7 +105,7 @@ func runNonInteractive() (*config.Config, string, bool, error) {
 		return nil, "", false, fmt.Errorf("could not validate config: %w", err)
 	}
 
-	scaffold.PrintSummary(cfg, out)
+	scaffold.PrintSummary(os.Stdout, cfg, out)
 	return cfg, out, false, nil
 }
 
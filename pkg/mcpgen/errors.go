@@ -0,0 +1,98 @@
+package mcpgen
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/alesr/mcpgen/internal/checks"
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/generator"
+	"github.com/alesr/mcpgen/internal/inspector"
+)
+
+// Stage is the part of a run an error comes from.
+type Stage string
+
+const (
+	StageValidate Stage = "validate"
+	StageRender   Stage = "render"
+	StageWrite    Stage = "write"
+	StageCheck    Stage = "check"
+	StageInspect  Stage = "inspect"
+)
+
+// Error is the error returned by the functions of this package. Only the
+// fields relevant to its Stage are set.
+type Error struct {
+	Stage Stage
+	// Problems lists every validation error of the config.
+	Problems []error
+	// Paths lists generated files edited by hand when Force is not set.
+	Paths []string
+	// Step names the check step or inspector method that failed, such as
+	// "vet" or "tools/list".
+	Step string
+	// Output is what the failed check printed.
+	Output string
+	// Checks reports every check step of a run that failed its checks.
+	Checks *CheckReport
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %v", e.Stage, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+var (
+	ErrConfigNil      = errors.New("config is nil")
+	ErrOutDirRequired = errors.New("out dir is required")
+
+	// ErrChecksNeedOutDir means Checks or Inspector were asked for while
+	// generating into an FS; both run the project from a directory.
+	ErrChecksNeedOutDir = errors.New("checks and inspector need an out dir, not an FS")
+
+	// ErrUnknownCheck means OnlyChecks, SkipChecks or Config.Checks name
+	// a check step that does not exist, at StageValidate.
+	ErrUnknownCheck = checks.ErrUnknownStep
+
+	// ErrModifiedFiles means generated files were edited by hand since the
+	// last run; Error.Paths lists them.
+	ErrModifiedFiles = generator.ErrModifiedFiles
+
+	// ErrUnknownTemplate and ErrInvalidExtraTemplate report a custom
+	// template directory that cannot be used, at StageRender.
+	ErrUnknownTemplate      = generator.ErrUnknownTemplate
+	ErrInvalidExtraTemplate = generator.ErrInvalidExtraTemplate
+
+	// ErrContractMismatch means the generated server advertises tools,
+	// resources or prompts that differ from the config, at StageInspect.
+	// Each difference is a *Mismatch.
+	ErrContractMismatch = inspector.ErrContractMismatch
+
+	// ErrInvalidResult means the generated server answered a tool call,
+	// prompt get or resource read with an invalid result, at StageInspect.
+	ErrInvalidResult = inspector.ErrInvalidResult
+)
+
+// Mismatch is one field the generated server advertises differently from
+// the config.
+type Mismatch = inspector.Mismatch
+
+// Validation errors, matched with errors.Is against the Problems of an Error.
+var (
+	ErrServerNameRequired   = config.ErrServerNameRequired
+	ErrServerModuleInvalid  = config.ErrServerModuleInvalid
+	ErrTransportTypeInvalid = config.ErrTransportTypeInvalid
+	ErrTransportPortInvalid = config.ErrTransportPortInvalid
+	ErrURIMissingScheme     = config.ErrURIMissingScheme
+	ErrConfigUnknownKeys    = config.ErrConfigUnknownKeys
+	ErrDuplicateName        = config.ErrDuplicateName
+	ErrIdentifierCollision  = config.ErrIdentifierCollision
+	ErrReservedIdentifier   = config.ErrReservedIdentifier
+	ErrSchemaInvalid        = config.ErrSchemaInvalid
+	ErrCheckInvalid         = config.ErrCheckInvalid
+)
@@ -0,0 +1,359 @@
+// Package mcpgen generates Go MCP servers. It is the API behind the mcpgen
+// command, for tools that drive the generator without shelling out to it.
+//
+// A run validates a Config, renders every file of the project in memory and
+// writes them to the output directory in one step. Optionally it then runs
+// the check pipeline the Config declares (by default go mod tidy, gofmt,
+// go vet and go test) and the MCP inspector. Failures are reported as
+// *Error values rather than printed.
+//
+// Instead of a directory, a project can be generated into any FS, such as
+// the in-memory and archive trees this package provides.
+package mcpgen
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"time"
+
+	"github.com/alesr/mcpgen/internal/checks"
+	"github.com/alesr/mcpgen/internal/config"
+	"github.com/alesr/mcpgen/internal/generator"
+	"github.com/alesr/mcpgen/internal/inspector"
+	"github.com/alesr/mcpgen/internal/pkg/outfs"
+	"github.com/alesr/mcpgen/internal/typecheck"
+)
+
+// Config describes the server to generate. It is what mcpgen.toml decodes into.
+type (
+	Config               = config.Config
+	ServerConfig         = config.ServerConfig
+	TransportConfig      = config.TransportConfig
+	ToolConfig           = config.ToolConfig
+	ResourceConfig       = config.ResourceConfig
+	PromptConfig         = config.PromptConfig
+	PromptArgumentConfig = config.PromptArgumentConfig
+	CheckConfig          = config.CheckConfig
+)
+
+type (
+	// CheckReport is what the check steps of a run did. Write encodes it
+	// as JSON or JUnit XML.
+	CheckReport = checks.Report
+	// CheckResult is what one check step, or inspector method, did.
+	CheckResult = checks.Result
+	// CheckStatus is the outcome of a check step.
+	CheckStatus = checks.Status
+	// ReportFormat is an encoding of a CheckReport.
+	ReportFormat = checks.ReportFormat
+)
+
+const (
+	CheckPassed  = checks.StatusPassed
+	CheckFailed  = checks.StatusFailed
+	CheckSkipped = checks.StatusSkipped
+
+	ReportJSON  = checks.ReportJSON
+	ReportJUnit = checks.ReportJUnit
+)
+
+// InspectorMode is how the inspector talks to the generated server.
+type InspectorMode = inspector.Mode
+
+const (
+	InspectorNative = inspector.ModeNative
+	InspectorNPX    = inspector.ModeNPX
+)
+
+type (
+	// TypeError lists the compile errors an offline check found.
+	TypeError = typecheck.Error
+	// Diagnostic is one compile error, with the template that produced
+	// the file it is in.
+	Diagnostic = typecheck.Diagnostic
+)
+
+// Action is what a run does to one file of the project.
+type Action = generator.Action
+
+const (
+	ActionCreate    = generator.ActionCreate
+	ActionModify    = generator.ActionModify
+	ActionDelete    = generator.ActionDelete
+	ActionUnchanged = generator.ActionUnchanged
+)
+
+// FS is a writable file tree a project can be generated into. Names are
+// slash-separated, as in io/fs. What it already holds is read back, so
+// regenerating into it keeps user-owned code like a directory does.
+type FS = outfs.FS
+
+type (
+	// MemoryFS keeps a project in memory.
+	MemoryFS = outfs.Memory
+	// ArchiveFS collects a project and encodes it as an archive on Close.
+	ArchiveFS = outfs.Archive
+	// ArchiveFormat is the encoding of an ArchiveFS.
+	ArchiveFormat = outfs.Format
+)
+
+const (
+	ArchiveTarGz = outfs.FormatTarGz
+	ArchiveZip   = outfs.FormatZip
+)
+
+// NewMemoryFS returns an empty in-memory tree.
+func NewMemoryFS() *MemoryFS {
+	return outfs.NewMemory()
+}
+
+// NewArchiveFS returns an empty archive that Close encodes to w, with
+// every file placed under root when it is not empty.
+func NewArchiveFS(w io.Writer, format ArchiveFormat, root string) (*ArchiveFS, error) {
+	return outfs.NewArchive(w, format, root)
+}
+
+// Options control where and how Generate writes a project.
+type Options struct {
+	// OutDir is the directory the project is generated into. It is created
+	// when missing; regenerating an existing project keeps user-owned code.
+	OutDir string
+	// FS receives the project instead of OutDir, which then only names it
+	// in messages. Writes to FS are not atomic, and Checks and Inspector
+	// cannot be used with it.
+	FS FS
+	// Force overwrites generated files even when they were edited by hand
+	// since the last run.
+	Force bool
+	// DryRun plans the run without writing anything. The Result reports
+	// what would change.
+	DryRun bool
+	// Checks runs the check steps of Config.Checks on the project, or
+	// go mod tidy, gofmt, go vet and go test when it lists none. When a
+	// step fails the previous contents of OutDir are restored; a new
+	// OutDir is kept as generated.
+	Checks bool
+	// OnlyChecks and SkipChecks select check steps by name, such as
+	// "vet", "race" or "coverage".
+	OnlyChecks []string
+	SkipChecks []string
+	// CheckTimeout bounds the check steps that do not set a timeout.
+	CheckTimeout time.Duration
+	// KeepGoing runs every check step even after one failed.
+	KeepGoing bool
+	// Offline skips the check steps that need the network and type-checks
+	// the project in-process instead, with the SDK read from Vendor or the
+	// module cache. Compile errors come back as a *TypeError.
+	Offline bool
+	// Vendor is a directory laid out like the one `go mod vendor` writes.
+	// Empty uses the module cache.
+	Vendor string
+	// Inspector runs the generated server as the last check step. It lists
+	// the tools, resources and prompts the server advertises, which must
+	// match Config or the step fails with ErrContractMismatch, then calls
+	// every tool, gets every prompt and reads every resource, failing with
+	// ErrInvalidResult on a result that is invalid, such as tool output
+	// that does not match its schema. It restores OutDir like the other
+	// checks when it fails.
+	Inspector bool
+	// InspectorMode is how the inspector talks to the server: in-process
+	// with the MCP Go client (InspectorNative, the default) or through the
+	// MCP inspector CLI (InspectorNPX), which needs npx.
+	InspectorMode InspectorMode
+	// InspectorReadyTimeout bounds the wait for an HTTP server to serve
+	// /mcp once started, on a free port. Zero waits ten seconds.
+	InspectorReadyTimeout time.Duration
+	// Templates is a directory of custom templates. Its .gotmpl files
+	// replace the embedded templates of the same name, and its
+	// templates.toml declares extra templates. Nil uses the embedded ones.
+	Templates fs.FS
+	// Output receives the notes of the run and the output of the checks
+	// and the inspector. Nil discards it.
+	Output io.Writer
+}
+
+// File is one file of a generated project. Path is slash-separated and
+// relative to the output directory.
+type File struct {
+	Path   string
+	Action Action
+}
+
+// Result describes a generation run.
+type Result struct {
+	// Files lists every file of the project and what the run did to it.
+	Files []File
+	// Notes describe stubs added to or kept in user-owned files.
+	Notes []string
+	// Overridden lists the embedded templates replaced by Options.Templates.
+	Overridden []string
+	// Checks reports what every check step did, when any ran.
+	Checks *CheckReport
+}
+
+// LoadConfig reads an mcpgen.toml file. Unknown keys are rejected.
+// Defaults are applied by Validate.
+func LoadConfig(path string) (*Config, error) {
+	return config.Load(path)
+}
+
+// DecodeConfig parses mcpgen.toml content. Unknown keys are rejected.
+// Defaults are applied by Validate.
+func DecodeConfig(content string) (*Config, error) {
+	return config.Decode(content)
+}
+
+// Validate fills in defaults for the values cfg leaves empty and reports
+// every problem it finds as an *Error with Stage StageValidate.
+func Validate(cfg *Config) error {
+	if cfg == nil {
+		return &Error{Stage: StageValidate, Err: ErrConfigNil}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		e := &Error{Stage: StageValidate, Err: err}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			e.Problems = joined.Unwrap()
+		} else {
+			e.Problems = []error{err}
+		}
+		return e
+	}
+	return nil
+}
+
+// Render validates cfg and renders every file of a new project in memory,
+// keyed by slash-separated path. Nothing is written.
+func Render(cfg *Config) (map[string][]byte, error) {
+	if err := Validate(cfg); err != nil {
+		return nil, err
+	}
+
+	files, err := generator.Render(cfg)
+	if err != nil {
+		return nil, &Error{Stage: StageRender, Err: err}
+	}
+	return files, nil
+}
+
+// Generate validates cfg and generates the project into opts.OutDir.
+// The new files are swapped in only once all of them rendered, so a
+// failure never leaves a half-written project behind.
+func Generate(ctx context.Context, cfg *Config, opts Options) (*Result, error) {
+	if err := Validate(cfg); err != nil {
+		return nil, err
+	}
+	if opts.OutDir == "" && opts.FS == nil {
+		return nil, &Error{Stage: StageValidate, Err: ErrOutDirRequired}
+	}
+	if opts.FS != nil && (opts.Checks || opts.Inspector) {
+		return nil, &Error{Stage: StageValidate, Err: ErrChecksNeedOutDir}
+	}
+
+	out := opts.Output
+	if out == nil {
+		out = io.Discard
+	}
+
+	var pipeline *checks.Pipeline
+	if opts.Checks || opts.Inspector {
+		p, err := checkPipeline(cfg, opts)
+		if err != nil {
+			return nil, &Error{Stage: StageValidate, Err: err}
+		}
+		pipeline = p
+	}
+
+	gen := &generator.Generator{Config: cfg, OutDir: opts.OutDir, FS: opts.FS, Force: opts.Force, Log: out, Templates: opts.Templates}
+
+	plan, err := gen.Plan()
+	if err != nil {
+		return nil, &Error{Stage: StageRender, Err: err}
+	}
+
+	res := &Result{Files: make([]File, 0, len(plan.Changes)), Notes: plan.Notes, Overridden: plan.Overridden}
+	for _, c := range plan.Changes {
+		res.Files = append(res.Files, File{Path: c.Path, Action: c.Action})
+	}
+
+	if opts.DryRun {
+		return res, nil
+	}
+
+	if opts.FS != nil {
+		if err := gen.Apply(plan); err != nil {
+			return nil, writeError(err, plan)
+		}
+		return res, nil
+	}
+
+	tx, err := gen.Begin(plan)
+	if err != nil {
+		return nil, writeError(err, plan)
+	}
+
+	if pipeline != nil {
+		report, err := pipeline.RunReport(ctx, opts.OutDir, out)
+		res.Checks = report
+		if err != nil {
+			e := checkError(err)
+			e.Checks = report
+			if rbErr := tx.Rollback(); rbErr != nil {
+				e.Err = errors.Join(err, rbErr)
+			}
+			return nil, e
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, &Error{Stage: StageWrite, Err: err}
+	}
+	return res, nil
+}
+
+// checkPipeline builds the check steps opts asks for.
+func checkPipeline(cfg *Config, opts Options) (*checks.Pipeline, error) {
+	sel := checks.Options{
+		Only:      opts.OnlyChecks,
+		Skip:      opts.SkipChecks,
+		Timeout:   opts.CheckTimeout,
+		KeepGoing: opts.KeepGoing,
+		Offline:   opts.Offline,
+		Vendor:    opts.Vendor,
+	}
+
+	switch {
+	case !opts.Checks:
+		sel.Only = []string{inspector.StepName}
+	case !opts.Inspector:
+		sel.Skip = append(slices.Clone(sel.Skip), inspector.StepName)
+	}
+	return checks.Build(cfg.Checks, sel, inspector.Step(cfg, inspector.Options{Mode: opts.InspectorMode, ReadyTimeout: opts.InspectorReadyTimeout}))
+}
+
+// checkError reports a failed check step, or inspector call.
+func checkError(err error) *Error {
+	e := &Error{Stage: StageCheck, Err: err}
+
+	var stepErr *checks.StepError
+	if errors.As(err, &stepErr) {
+		e.Step, e.Output = stepErr.Step, stepErr.Output
+	}
+
+	var callErr *inspector.CallError
+	if errors.As(err, &callErr) {
+		e.Stage, e.Step = StageInspect, callErr.Method
+	}
+	return e
+}
+
+func writeError(err error, plan *generator.Plan) *Error {
+	e := &Error{Stage: StageWrite, Err: err}
+	if errors.Is(err, generator.ErrModifiedFiles) {
+		e.Paths = plan.Modified
+	}
+	return e
+}
@@ -0,0 +1,256 @@
+package mcpgen_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alesr/mcpgen/pkg/mcpgen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newConfig() *mcpgen.Config {
+	return &mcpgen.Config{
+		Server:  mcpgen.ServerConfig{Name: "weather"},
+		Tools:   []mcpgen.ToolConfig{{ID: "forecast"}},
+		Prompts: []mcpgen.PromptConfig{{ID: "summary"}},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("applies defaults", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := newConfig()
+		require.NoError(t, mcpgen.Validate(cfg))
+		assert.Equal(t, "example.com/weather", cfg.Server.Module)
+	})
+
+	t.Run("reports every problem", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := newConfig()
+		cfg.Transport.Type = "pigeon"
+		cfg.Tools = append(cfg.Tools, mcpgen.ToolConfig{ID: "forecast"})
+
+		err := mcpgen.Validate(cfg)
+
+		var e *mcpgen.Error
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, mcpgen.StageValidate, e.Stage)
+		assert.Len(t, e.Problems, 2)
+		assert.ErrorIs(t, err, mcpgen.ErrTransportTypeInvalid)
+		assert.ErrorIs(t, err, mcpgen.ErrDuplicateName)
+	})
+
+	t.Run("nil config", func(t *testing.T) {
+		t.Parallel()
+
+		assert.ErrorIs(t, mcpgen.Validate(nil), mcpgen.ErrConfigNil)
+	})
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	files, err := mcpgen.Render(newConfig())
+	require.NoError(t, err)
+
+	for _, path := range []string{
+		"go.mod",
+		"mcpgen.toml",
+		"cmd/weather/main.go",
+		"internal/mcpapp/tools/tools.go",
+		"internal/mcpapp/prompts/handlers.go",
+		".mcpgen/manifest.json",
+	} {
+		assert.Contains(t, files, path)
+	}
+	assert.NotContains(t, files, "internal/mcpapp/resources/resources.go")
+	assert.True(t, strings.HasPrefix(string(files["go.mod"]), "module example.com/weather\n"))
+}
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	outDir := filepath.Join(t.TempDir(), "weather")
+
+	t.Run("dry run writes nothing", func(t *testing.T) {
+		res, err := mcpgen.Generate(ctx, newConfig(), mcpgen.Options{OutDir: outDir, DryRun: true})
+		require.NoError(t, err)
+		assert.Contains(t, res.Files, mcpgen.File{Path: "internal/mcpapp/tools/tools.go", Action: mcpgen.ActionCreate})
+
+		_, err = os.Stat(outDir)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("writes the project", func(t *testing.T) {
+		res, err := mcpgen.Generate(ctx, newConfig(), mcpgen.Options{OutDir: outDir})
+		require.NoError(t, err)
+		assert.Contains(t, res.Files, mcpgen.File{Path: "go.mod", Action: mcpgen.ActionCreate})
+
+		_, err = os.Stat(filepath.Join(outDir, "internal", "mcpapp", "tools", "tools.go"))
+		require.NoError(t, err)
+
+		res, err = mcpgen.Generate(ctx, newConfig(), mcpgen.Options{OutDir: outDir})
+		require.NoError(t, err)
+		for _, f := range res.Files {
+			assert.Equal(t, mcpgen.ActionUnchanged, f.Action, f.Path)
+		}
+	})
+
+	t.Run("refuses hand edits unless forced", func(t *testing.T) {
+		toolsPath := filepath.Join(outDir, "internal", "mcpapp", "tools", "tools.go")
+		f, err := os.OpenFile(toolsPath, os.O_APPEND|os.O_WRONLY, 0o644)
+		require.NoError(t, err)
+		_, err = f.WriteString("\n// hand edit\n")
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		_, err = mcpgen.Generate(ctx, newConfig(), mcpgen.Options{OutDir: outDir})
+
+		var e *mcpgen.Error
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, mcpgen.StageWrite, e.Stage)
+		assert.Equal(t, []string{"internal/mcpapp/tools/tools.go"}, e.Paths)
+		assert.True(t, errors.Is(err, mcpgen.ErrModifiedFiles))
+
+		res, err := mcpgen.Generate(ctx, newConfig(), mcpgen.Options{OutDir: outDir, Force: true})
+		require.NoError(t, err)
+		assert.Contains(t, res.Files, mcpgen.File{Path: "internal/mcpapp/tools/tools.go", Action: mcpgen.ActionModify})
+	})
+
+	t.Run("out dir is required", func(t *testing.T) {
+		_, err := mcpgen.Generate(ctx, newConfig(), mcpgen.Options{})
+		assert.ErrorIs(t, err, mcpgen.ErrOutDirRequired)
+	})
+}
+
+func TestGenerate_Checks(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	newCheckedConfig := func() *mcpgen.Config {
+		cfg := newConfig()
+		cfg.Checks = []mcpgen.CheckConfig{
+			{Name: "advisory", Run: []string{"go", "no-such-command"}, ContinueOnError: true},
+			{Name: "broken", Run: []string{"go", "no-such-command"}},
+		}
+		return cfg
+	}
+
+	t.Run("failed step keeps a new out dir", func(t *testing.T) {
+		t.Parallel()
+
+		outDir := filepath.Join(t.TempDir(), "weather")
+		_, err := mcpgen.Generate(ctx, newCheckedConfig(), mcpgen.Options{OutDir: outDir, Checks: true})
+
+		var e *mcpgen.Error
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, mcpgen.StageCheck, e.Stage)
+		assert.Equal(t, "broken", e.Step)
+		assert.Contains(t, e.Output, "no-such-command")
+
+		require.NotNil(t, e.Checks)
+		assert.Equal(t, mcpgen.CheckFailed, e.Checks.Status)
+		require.Len(t, e.Checks.Steps, 2)
+		assert.True(t, e.Checks.Steps[0].ContinueOnError)
+		assert.Contains(t, e.Checks.Steps[1].Stderr, "no-such-command")
+
+		// nothing to restore: the project stays for the failure to be fixed
+		_, err = os.Stat(filepath.Join(outDir, "go.mod"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("selected steps", func(t *testing.T) {
+		t.Parallel()
+
+		outDir := filepath.Join(t.TempDir(), "weather")
+		res, err := mcpgen.Generate(ctx, newCheckedConfig(), mcpgen.Options{OutDir: outDir, Checks: true, OnlyChecks: []string{"advisory", "fmt"}})
+		require.NoError(t, err)
+		require.NotNil(t, res.Checks)
+		assert.Equal(t, mcpgen.CheckPassed, res.Checks.Status)
+
+		_, err = os.Stat(filepath.Join(outDir, "go.mod"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("unknown step", func(t *testing.T) {
+		t.Parallel()
+
+		outDir := filepath.Join(t.TempDir(), "weather")
+		_, err := mcpgen.Generate(ctx, newConfig(), mcpgen.Options{OutDir: outDir, Checks: true, SkipChecks: []string{"lint"}})
+
+		var e *mcpgen.Error
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, mcpgen.StageValidate, e.Stage)
+		assert.ErrorIs(t, err, mcpgen.ErrUnknownCheck)
+	})
+}
+
+func TestGenerate_FS(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("memory", func(t *testing.T) {
+		t.Parallel()
+
+		mem := mcpgen.NewMemoryFS()
+		res, err := mcpgen.Generate(ctx, newConfig(), mcpgen.Options{FS: mem})
+		require.NoError(t, err)
+		assert.Contains(t, res.Files, mcpgen.File{Path: "go.mod", Action: mcpgen.ActionCreate})
+
+		want, err := mcpgen.Render(newConfig())
+		require.NoError(t, err)
+		assert.Equal(t, want, mem.Files())
+
+		res, err = mcpgen.Generate(ctx, newConfig(), mcpgen.Options{FS: mem})
+		require.NoError(t, err)
+		for _, f := range res.Files {
+			assert.Equal(t, mcpgen.ActionUnchanged, f.Action, f.Path)
+		}
+	})
+
+	t.Run("archive", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		archive, err := mcpgen.NewArchiveFS(&buf, mcpgen.ArchiveZip, "weather")
+		require.NoError(t, err)
+
+		_, err = mcpgen.Generate(ctx, newConfig(), mcpgen.Options{FS: archive})
+		require.NoError(t, err)
+		require.NoError(t, archive.Close())
+
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+
+		var names []string
+		for _, f := range zr.File {
+			names = append(names, f.Name)
+		}
+		assert.Contains(t, names, "weather/go.mod")
+	})
+
+	t.Run("checks need an out dir", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := mcpgen.Generate(ctx, newConfig(), mcpgen.Options{FS: mcpgen.NewMemoryFS(), Checks: true})
+
+		var e *mcpgen.Error
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, mcpgen.StageValidate, e.Stage)
+		assert.ErrorIs(t, err, mcpgen.ErrChecksNeedOutDir)
+	})
+}